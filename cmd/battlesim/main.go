@@ -0,0 +1,80 @@
+// Command battlesim plays thousands of battleground rooms with the real effect and
+// powercard rules, so the effect table can be tuned before an event.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/marcustut/thebox/internal/battleground"
+)
+
+// strategies collects the -strategy flags, the teams of a room take them in turn.
+type strategies []string
+
+func (s *strategies) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *strategies) Set(value string) error {
+	if _, err := battleground.ParseStrategy(value); err != nil {
+		return err
+	}
+	*s = append(*s, value)
+	return nil
+}
+
+func main() {
+	// configure logger
+	log.SetFlags(0)
+
+	var config Config
+	var strategyNames strategies
+	var effectsPath string
+	var pairing string
+	flag.IntVar(&config.Rooms, "rooms", 10000, "number of rooms to simulate")
+	flag.IntVar(&config.Teams, "teams", 6, "number of teams in a room")
+	flag.IntVar(&config.Rounds, "rounds", 0, "number of rounds in a room, 0 plays a whole round robin cycle")
+	flag.StringVar(&pairing, "pairing", string(battleground.PairingRoundRobin), "pairing of the rounds, ROUND_ROBIN or RANDOM")
+	flag.Float64Var(&config.Points, "points", 1000, "points of a team when the room starts")
+	flag.Float64Var(&config.Spread, "spread", 0, "random difference in the starting points, up to this much either way")
+	flag.Var(&strategyNames, "strategy", "selection strategy of the teams, random, counter or a script such as KING,WITCH (repeatable)")
+	flag.Float64Var(&config.PowercardRate, "powercard-rate", 0.5, "chance that a team holds a random powercard")
+	flag.StringVar((*string)(&config.PowercardPolicy), "powercard-policy", string(PolicySmart), "when teams play their powercard, smart, always or never")
+	flag.StringVar(&effectsPath, "effects", "", "JSON file with the effect table to simulate instead of the default one")
+	flag.Int64Var(&config.Seed, "seed", 1, "seed of the simulation")
+	flag.Parse()
+
+	config.Pairing = battleground.Pairing(strings.ToUpper(pairing))
+	if len(strategyNames) == 0 {
+		strategyNames = strategies{"random"}
+	}
+	config.Strategies = strategyNames
+	switch config.PowercardPolicy {
+	case PolicySmart, PolicyAlways, PolicyNever:
+	default:
+		log.Fatalf("unknown powercard policy %s\n", config.PowercardPolicy)
+	}
+
+	config.Effects = battleground.DefaultEffectTable
+	if effectsPath != "" {
+		data, err := os.ReadFile(effectsPath)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		config.Effects = battleground.EffectTable{}
+		if err := json.Unmarshal(data, &config.Effects); err != nil {
+			log.Fatalf("unable to read effect table %s: %v\n", effectsPath, err)
+		}
+	}
+
+	result, err := Simulate(config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	Report(os.Stdout, config, result)
+}
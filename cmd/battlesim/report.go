@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/marcustut/thebox/internal/battleground"
+)
+
+// Report prints the statistics of a simulation.
+func Report(out io.Writer, config Config, result *Result) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	defer w.Flush()
+
+	fmt.Fprintf(w, "%d rooms of %d teams, %s pairing, %.1f rounds per room, seed %d\n",
+		config.Rooms, config.Teams, config.Pairing, float64(result.Rounds)/float64(len(result.Rooms)), config.Seed)
+	fmt.Fprintf(w, "draws %s, rounds without a box %s\n\n",
+		percent(result.Draws, result.Rounds), percent(result.Unopened, result.Rounds))
+
+	// point distribution
+	var finals, spreads, starts []float64
+	negative := 0
+	for _, room := range result.Rooms {
+		for _, team := range room {
+			finals = append(finals, team.Final)
+			starts = append(starts, team.Start)
+			if team.Final < 0 {
+				negative++
+			}
+		}
+		spreads = append(spreads, room[0].Final-room[len(room)-1].Final)
+	}
+	fmt.Fprintln(w, "points\tmean\tstddev\tmin\tp10\tp50\tp90\tmax\t")
+	fmt.Fprintln(w, "start\t"+distribution(starts))
+	fmt.Fprintln(w, "final\t"+distribution(finals))
+	fmt.Fprintln(w, "first - last\t"+distribution(spreads))
+	fmt.Fprintln(w, "swing per round\t"+distribution(result.Swings))
+	fmt.Fprintf(w, "teams finishing below zero %s\n\n", percent(negative, len(finals)))
+
+	// comebacks, the first ranked team is the winner of a room
+	comebacks, fromBottomHalf, fromLast := 0, 0, 0
+	for _, room := range result.Rooms {
+		var halfways []float64
+		for _, team := range room {
+			halfways = append(halfways, team.Halfway)
+		}
+		sort.Float64s(halfways)
+		winner := room[0].Halfway
+		if winner < halfways[len(halfways)-1] {
+			comebacks++
+		}
+		if winner < halfways[len(halfways)/2] {
+			fromBottomHalf++
+		}
+		if winner == halfways[0] && halfways[0] < halfways[len(halfways)-1] {
+			fromLast++
+		}
+	}
+	fmt.Fprintf(w, "winner was not leading halfway\t%s\t\n", percent(comebacks, len(result.Rooms)))
+	fmt.Fprintf(w, "winner was in the bottom half halfway\t%s\t\n", percent(fromBottomHalf, len(result.Rooms)))
+	fmt.Fprintf(w, "winner was last halfway\t%s\t\n\n", percent(fromLast, len(result.Rooms)))
+
+	// strategies
+	fmt.Fprintln(w, "strategy\tteams\twin rate\tmean final\tmean rank\t")
+	for _, strategy := range uniqueStrategies(config.Strategies) {
+		teams, wins, finals, ranks := 0, 0.0, 0.0, 0
+		for _, room := range result.Rooms {
+			for _, team := range room {
+				if team.Strategy != strategy {
+					continue
+				}
+				teams++
+				wins += win(room, team)
+				finals += team.Final
+				ranks += team.Rank
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%.1f\t%.2f\t\n", strategy, teams, 100*wins/float64(teams), finals/float64(teams), float64(ranks)/float64(teams))
+	}
+	fmt.Fprintln(w)
+
+	// effects
+	var effects []battleground.Effect
+	for effect := range result.Effects {
+		effects = append(effects, effect)
+	}
+	sort.Slice(effects, func(i, j int) bool { return effects[i] < effects[j] })
+	fmt.Fprintln(w, "effect\topened\tmean winner change\tmean loser change\treversed\tblocked\trerolled\t")
+	for _, effect := range effects {
+		e := result.Effects[effect]
+		opened := math.Max(float64(e.Opened), 1)
+		fmt.Fprintf(w, "%s\t%d\t%.1f\t%.1f\t%s\t%s\t%s\t\n", effect, e.Opened, e.WinnerGain/opened, e.LoserGain/opened,
+			percent(e.Reversed, e.Opened), percent(e.Blocked, e.Opened), percent(e.Rerolled, e.Opened))
+	}
+	fmt.Fprintln(w)
+
+	// powercards, compared with the teams that held none
+	fmt.Fprintln(w, "powercard\theld\tplayed\tmean gain\tp90 gain\twin rate\t")
+	for _, powercard := range battleground.Powercards {
+		p := result.Powercards[powercard]
+		winRate := winRate(result, func(team TeamResult) bool { return team.Powercard != nil && *team.Powercard == powercard })
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.1f\t%.1f\t%s\t\n", powercard, p.Held, percent(p.Played, p.Held), mean(p.Gains), quantile(p.Gains, 0.9), winRate)
+	}
+	winRate := winRate(result, func(team TeamResult) bool { return team.Powercard == nil })
+	fmt.Fprintf(w, "none\t-\t-\t-\t-\t%s\t\n", winRate)
+}
+
+// winRate is the share of rooms won by the teams that match.
+func winRate(result *Result, match func(team TeamResult) bool) string {
+	teams, wins := 0, 0.0
+	for _, room := range result.Rooms {
+		for _, team := range room {
+			if match(team) {
+				teams++
+				wins += win(room, team)
+			}
+		}
+	}
+	if teams == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*wins/float64(teams))
+}
+
+// win shares a win between the teams that rank first in a room.
+func win(room []TeamResult, team TeamResult) float64 {
+	if team.Rank != 1 {
+		return 0
+	}
+	first := 0
+	for _, t := range room {
+		if t.Rank == 1 {
+			first++
+		}
+	}
+	return 1 / float64(first)
+}
+
+func uniqueStrategies(strategies []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, strategy := range strategies {
+		if !seen[strategy] {
+			seen[strategy] = true
+			result = append(result, strategy)
+		}
+	}
+	return result
+}
+
+func distribution(values []float64) string {
+	if len(values) == 0 {
+		return "-\t-\t-\t-\t-\t-\t-\t"
+	}
+	m := mean(values)
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - m) * (value - m)
+	}
+	stddev := math.Sqrt(variance / float64(len(values)))
+	return fmt.Sprintf("%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t",
+		m, stddev, quantile(values, 0), quantile(values, 0.1), quantile(values, 0.5), quantile(values, 0.9), quantile(values, 1))
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// quantile picks the nearest value at q of the sorted values.
+func quantile(values []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	return sorted[int(math.Round(q*float64(len(sorted)-1)))]
+}
+
+func percent(n int, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/marcustut/thebox/internal/battleground"
+)
+
+// Policy decides when a team plays its powercard.
+type Policy string
+
+const (
+	// PolicySmart plays the powercard only when it gains the team points.
+	PolicySmart Policy = "smart"
+	// PolicyAlways plays the powercard in the first round a box is opened for the team.
+	PolicyAlways Policy = "always"
+	// PolicyNever keeps the powercard.
+	PolicyNever Policy = "never"
+)
+
+type Config struct {
+	Rooms           int
+	Teams           int
+	Rounds          int
+	Pairing         battleground.Pairing
+	Points          float64
+	Spread          float64
+	Strategies      []string
+	PowercardRate   float64
+	PowercardPolicy Policy
+	Effects         battleground.EffectTable
+	Seed            int64
+}
+
+// TeamResult is how a team did in a room.
+type TeamResult struct {
+	Strategy  string
+	Powercard *battleground.Powercard
+	Start     float64
+	Halfway   float64
+	Final     float64
+	Rank      int
+}
+
+// EffectResult is what an effect did every time it was opened.
+type EffectResult struct {
+	Opened     int
+	WinnerGain float64
+	LoserGain  float64
+	Reversed   int
+	Blocked    int
+	Rerolled   int
+}
+
+func (e *EffectResult) opened(winnerGain float64, loserGain float64) {
+	e.Opened++
+	e.WinnerGain += winnerGain
+	e.LoserGain += loserGain
+}
+
+// PowercardResult is what a powercard did every time it was played.
+type PowercardResult struct {
+	Held   int
+	Played int
+	Gains  []float64
+}
+
+type Result struct {
+	Rooms      [][]TeamResult
+	Rounds     int
+	Draws      int
+	Unopened   int
+	Swings     []float64
+	Effects    map[battleground.Effect]*EffectResult
+	Powercards map[battleground.Powercard]*PowercardResult
+}
+
+type team struct {
+	id         string
+	strategy   battleground.Strategy
+	powercard  *battleground.Powercard
+	points     float64
+	selections []battleground.Selection
+	result     TeamResult
+}
+
+// Simulate plays the rooms of the config, the same config always gives the same result.
+func Simulate(config Config) (*Result, error) {
+	if config.Rooms < 1 {
+		return nil, fmt.Errorf("at least 1 room is needed, got %d", config.Rooms)
+	}
+	if len(config.Effects) == 0 {
+		return nil, fmt.Errorf("the effect table has no effects")
+	}
+
+	// the boxes are sorted so the seed alone decides the order they are opened in
+	var boxes []battleground.Effect
+	for effect := range config.Effects {
+		boxes = append(boxes, effect)
+	}
+	sort.Slice(boxes, func(i, j int) bool { return boxes[i] < boxes[j] })
+
+	result := &Result{
+		Effects:    make(map[battleground.Effect]*EffectResult),
+		Powercards: make(map[battleground.Powercard]*PowercardResult),
+	}
+	for _, effect := range boxes {
+		result.Effects[effect] = &EffectResult{}
+	}
+	for _, powercard := range battleground.Powercards {
+		result.Powercards[powercard] = &PowercardResult{}
+	}
+
+	rng := rand.New(rand.NewSource(config.Seed))
+	for i := 0; i < config.Rooms; i++ {
+		room, err := simulateRoom(config, boxes, rng, result)
+		if err != nil {
+			return nil, err
+		}
+		result.Rooms = append(result.Rooms, room)
+	}
+
+	return result, nil
+}
+
+func simulateRoom(config Config, boxes []battleground.Effect, rng *rand.Rand, result *Result) ([]TeamResult, error) {
+	teams := make(map[string]*team)
+	var ids []string
+	for i := 0; i < config.Teams; i++ {
+		name := config.Strategies[i%len(config.Strategies)]
+		strategy, err := battleground.ParseStrategy(name)
+		if err != nil {
+			return nil, err
+		}
+		t := &team{id: fmt.Sprintf("team-%d", i+1), strategy: strategy}
+		t.points = config.Points + (rng.Float64()*2-1)*config.Spread
+		if rng.Float64() < config.PowercardRate {
+			powercard := battleground.Powercards[rng.Intn(len(battleground.Powercards))]
+			t.powercard = &powercard
+			result.Powercards[powercard].Held++
+		}
+		t.result = TeamResult{Strategy: name, Powercard: t.powercard, Start: t.points}
+		teams[t.id] = t
+		ids = append(ids, t.id)
+	}
+
+	matches, err := battleground.Schedule(ids, config.Pairing, config.Rounds, rng)
+	if err != nil {
+		return nil, err
+	}
+	unopened := append([]battleground.Effect{}, boxes...)
+	rng.Shuffle(len(unopened), func(i, j int) { unopened[i], unopened[j] = unopened[j], unopened[i] })
+	peek := func() (battleground.Effect, bool) {
+		if len(unopened) == 0 {
+			return "", false
+		}
+		return unopened[0], true
+	}
+
+	for i, match := range matches {
+		if i == len(matches)/2 {
+			for _, t := range teams {
+				t.result.Halfway = t.points
+			}
+		}
+		result.Rounds++
+		attacker, defender := teams[match.AttackerTeam], teams[match.DefenderTeam]
+		attackerSelection := attacker.strategy.Select(defender.selections, rng)
+		defenderSelection := defender.strategy.Select(attacker.selections, rng)
+		attacker.selections = append(attacker.selections, attackerSelection)
+		defender.selections = append(defender.selections, defenderSelection)
+
+		var winner, loser *team
+		switch battleground.Outcome(&attackerSelection, &defenderSelection) {
+		case battleground.WinnerAttacker:
+			winner, loser = attacker, defender
+		case battleground.WinnerDefender:
+			winner, loser = defender, attacker
+		default:
+			result.Draws++
+			continue
+		}
+
+		effect, ok := peek()
+		if !ok {
+			result.Unopened++
+			continue
+		}
+		unopened = unopened[1:]
+		winnerBefore, loserBefore := winner.points, loser.points
+		winnerAfter, loserAfter := config.Effects.Apply(effect, winnerBefore, loserBefore)
+		result.Effects[effect].opened(winnerAfter-winnerBefore, loserAfter-loserBefore)
+
+		// only one powercard is played per round, the winner gets to decide first
+		for _, player := range []*team{winner, loser} {
+			if player.powercard == nil || config.PowercardPolicy == PolicyNever {
+				continue
+			}
+			winnerPlayed, loserPlayed, reopen := config.Effects.Play(*player.powercard, effect, winnerBefore, loserBefore)
+			next, reopened := peek()
+			if reopen && reopened {
+				winnerPlayed, loserPlayed = config.Effects.Apply(next, winnerBefore, loserBefore)
+			}
+			gain := winnerPlayed - winnerAfter
+			if player == loser {
+				gain = loserPlayed - loserAfter
+			}
+			if config.PowercardPolicy == PolicySmart && gain <= 0 {
+				continue
+			}
+
+			switch *player.powercard {
+			case battleground.PowercardReverse:
+				result.Effects[effect].Reversed++
+			case battleground.PowercardBlock:
+				result.Effects[effect].Blocked++
+			case battleground.PowercardOneMoreChance:
+				result.Effects[effect].Rerolled++
+				if reopened {
+					unopened = unopened[1:]
+					result.Effects[next].opened(winnerPlayed-winnerBefore, loserPlayed-loserBefore)
+				}
+			}
+			powercardResult := result.Powercards[*player.powercard]
+			powercardResult.Played++
+			powercardResult.Gains = append(powercardResult.Gains, gain)
+			player.powercard = nil
+			winnerAfter, loserAfter = winnerPlayed, loserPlayed
+			break
+		}
+
+		winner.points, loser.points = winnerAfter, loserAfter
+		result.Swings = append(result.Swings, abs(winnerAfter-winnerBefore)+abs(loserAfter-loserBefore))
+	}
+
+	// rank the teams, teams with the same points share a rank
+	var room []TeamResult
+	for _, id := range ids {
+		t := teams[id]
+		t.result.Final = t.points
+		room = append(room, t.result)
+	}
+	sort.SliceStable(room, func(i, j int) bool { return room[i].Final > room[j].Final })
+	for i := range room {
+		room[i].Rank = i + 1
+		if i > 0 && room[i].Final == room[i-1].Final {
+			room[i].Rank = room[i-1].Rank
+		}
+	}
+
+	return room, nil
+}
+
+func abs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
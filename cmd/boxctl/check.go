@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/marcustut/thebox/internal/storage"
+)
+
+// check is one of the checks run by the check command, it returns what it found out
+// along with the problems it found.
+type check struct {
+	name string
+	run  func(ctx context.Context, app *app) (info []string, problems []string, err error)
+}
+
+var checks = []check{
+	{"environment", checkEnvironment},
+	{"storage", checkStorage},
+	{"database", checkDatabase},
+}
+
+var checkCommand = command{
+	name:  "check",
+	usage: "Check that the environment is configured and the database can be reached.",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			failed := 0
+			for _, c := range checks {
+				info, problems, err := c.run(ctx, app)
+				if err != nil {
+					problems = append(problems, err.Error())
+				}
+				if len(problems) == 0 {
+					fmt.Fprintf(app.out, "ok    %s\n", c.name)
+				} else {
+					failed++
+					fmt.Fprintf(app.out, "FAIL  %s\n", c.name)
+				}
+				for _, line := range append(info, problems...) {
+					fmt.Fprintf(app.out, "      %s\n", line)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+			}
+			return nil
+		}
+	},
+}
+
+// checkEnvironment makes sure the variables the server cannot start without are set.
+func checkEnvironment(ctx context.Context, app *app) ([]string, []string, error) {
+	var problems []string
+	for _, key := range []string{"DATABASE_URL", "SUPABASE_JWT_SECRET"} {
+		if os.Getenv(key) == "" {
+			problems = append(problems, fmt.Sprintf("%s is not set", key))
+		}
+	}
+	return []string{fmt.Sprintf("running against %s", app.options.env)}, problems, nil
+}
+
+func checkStorage(ctx context.Context, app *app) ([]string, []string, error) {
+	_, config, err := storage.NewFromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	return []string{fmt.Sprintf("%s storage, uploads up to %d bytes", config.Driver, config.MaxUploadSize)}, nil, nil
+}
+
+// checkDatabase counts the rows of the main tables, the connection is already made
+// before the command runs.
+func checkDatabase(ctx context.Context, app *app) ([]string, []string, error) {
+	var info []string
+	for _, table := range []string{"User", "Team", "Cluster", "Mission"} {
+		count, err := countRows(ctx, app, table)
+		if err != nil {
+			return nil, nil, err
+		}
+		info = append(info, fmt.Sprintf("%d rows in %s", count, table))
+	}
+	return info, nil, nil
+}
+
+func countRows(ctx context.Context, app *app, table string) (int, error) {
+	var res []struct {
+		Count int `json:"count"`
+	}
+	err := app.db.Prisma.QueryRaw(fmt.Sprintf(`SELECT COUNT(*) AS count FROM "%s"`, table)).Exec(ctx, &res)
+	if err != nil {
+		return 0, err
+	}
+	return res[0].Count, nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marcustut/thebox/internal/export"
+	"github.com/marcustut/thebox/internal/graphql/query"
+)
+
+var exportCommand = command{
+	name:  "export",
+	args:  "users|teams|scores",
+	usage: "Export a table as CSV or XLSX, the format is picked by the extension of the output.",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		var output string
+		var columns string
+		fs.StringVar(&output, "o", "", "file to write the export to, such as users.xlsx, the default is CSV on the standard output")
+		fs.StringVar(&columns, "columns", "", "comma separated columns to export, all of them by default")
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			table, ok := query.Exports[args[0]]
+			if !ok {
+				return fmt.Errorf("%w: there is no export %s", errUsage, args[0])
+			}
+			picked, err := export.Columns(table.Columns, columns)
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				w, err := export.NewCSV(app.out)
+				if err != nil {
+					return err
+				}
+				return query.StreamExport(ctx, app.db, table, picked, w)
+			}
+
+			format := export.Format(strings.TrimPrefix(filepath.Ext(output), "."))
+			if format != export.FormatCSV && format != export.FormatXLSX {
+				return fmt.Errorf("%w: %s is not a .csv or .xlsx file", errUsage, output)
+			}
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			w, err := export.NewWriter(f, format, table.Name)
+			if err != nil {
+				f.Close()
+				return err
+			}
+			if err := query.StreamExport(ctx, app.db, table, picked, w); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}
+	},
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/graphql/query"
+	"github.com/marcustut/thebox/internal/teamform"
+)
+
+var formTeamsCommand = command{
+	name:  "form-teams",
+	usage: "Form balanced teams out of the users without a team, printing the teams along with a checksum.\nThe teams are only created when it is run again with -commit and that checksum.",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		var param model.FormTeamsInput
+		var checksum string
+		fs.IntVar(&param.Size, "size", 0, "number of members in a team")
+		fs.IntVar(&param.Seed, "seed", 1, "seed of the formation, the same seed forms the same teams")
+		fs.StringVar(&param.NamePrefix, "prefix", "Team", "name of the teams, followed by their number")
+		fs.BoolVar(&param.DistributeClusters, "clusters", false, "distribute the teams across the clusters")
+		fs.StringVar(&checksum, "commit", "", "checksum of the preview to create the teams of")
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 0 || param.Size < 1 {
+				return errUsage
+			}
+			onlyVerified, _ := strconv.ParseBool(os.Getenv("REQUIRE_VERIFIED_PAYMENT"))
+
+			plan, err := query.PlanTeamFormation(ctx, app.db, &param, onlyVerified)
+			if err != nil {
+				return err
+			}
+			printTeamFormation(app.out, plan)
+
+			if checksum == "" {
+				fmt.Fprintf(app.out, "run again with -commit %s to create the teams\n", plan.Checksum)
+				return nil
+			}
+			if checksum != plan.Checksum {
+				return fmt.Errorf("the teams have changed since the preview, the checksum is now %s", plan.Checksum)
+			}
+			if app.dryRun {
+				app.dryRunf("would create %d teams", len(plan.Teams))
+				return nil
+			}
+			if err := app.confirmWrites(); err != nil {
+				return err
+			}
+			teams, err := query.CommitTeamFormation(ctx, app.db, plan)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(app.out, "created %d teams\n", len(teams))
+			return nil
+		}
+	},
+}
+
+func printTeamFormation(out io.Writer, plan *query.TeamFormationPlan) {
+	now := time.Now()
+	for _, team := range plan.Teams {
+		cluster := "no cluster"
+		if team.Cluster != nil {
+			cluster = team.Cluster.Name
+		}
+		fmt.Fprintf(out, "%s (%s), led by %s\n", team.Name, cluster, team.Leader.Profile().NameEng)
+		for _, member := range team.Members {
+			profile := member.Profile()
+			status, _ := profile.Status()
+			satellite, _ := profile.Satellite()
+			fmt.Fprintf(out, "  %-30s %-6s %3d %-10s %s\n", profile.NameEng, profile.Gender, teamform.Age(profile.Dob, now), satellite, status)
+		}
+	}
+	for _, warning := range plan.Warnings {
+		fmt.Fprintf(out, "warning: %s\n", warning)
+	}
+	fmt.Fprintf(out, "%d teams, checksum %s\n", len(plan.Teams), plan.Checksum)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/marcustut/thebox/internal/graphql/query"
+)
+
+var importCommand = command{
+	name:   "import",
+	args:   "<file.csv>",
+	usage:  "Create the users of a CSV of registrations, such as the responses of the registration form.\nNothing is created when any row is invalid, a dry run only validates the file.",
+	writes: true,
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			requireVerifiedPayment, _ := strconv.ParseBool(os.Getenv("REQUIRE_VERIFIED_PAYMENT"))
+			result, err := query.ImportUsers(ctx, app.db, f, app.dryRun, requireVerifiedPayment)
+			if err != nil {
+				return err
+			}
+			for _, e := range result.Errors {
+				if e.Column != nil {
+					fmt.Fprintf(app.out, "row %d, %s: %s\n", e.Row, *e.Column, e.Message)
+				} else {
+					fmt.Fprintf(app.out, "row %d: %s\n", e.Row, e.Message)
+				}
+			}
+			if len(result.Errors) > 0 {
+				return fmt.Errorf("%d errors in %d rows, nothing was created", len(result.Errors), result.Rows)
+			}
+			if result.DryRun {
+				app.dryRunf("%d rows are valid and would be created", result.Rows)
+				return nil
+			}
+			fmt.Fprintf(app.out, "created %d users\n", result.Created)
+			return nil
+		}
+	},
+}
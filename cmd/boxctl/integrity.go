@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/graphql/query"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// pointsTolerance is how far the points of a team can be from its awards before they
+// are reported, the points are floats.
+const pointsTolerance = 1e-6
+
+// profileGracePeriod is how long a profile can be without a user, a profile is created
+// before the user while signing up.
+const profileGracePeriod = 24 * time.Hour
+
+// inconsistency is a record that does not agree with the rest of the database, fix is
+// nil when it has to be fixed by hand.
+type inconsistency struct {
+	problem string
+	fix     func(ctx context.Context) error
+}
+
+// integrityCheck finds one kind of inconsistency.
+type integrityCheck struct {
+	name string
+	find func(ctx context.Context, app *app) ([]inconsistency, error)
+}
+
+var integrityChecks = []integrityCheck{
+	{"mission records", findMissingMissionRecords},
+	{"profiles", findProfilesWithoutUser},
+	{"roles", findUsersWithoutRole},
+	{"powercards", findDuplicatePowercards},
+	{"battleground rooms", findDeletedRoomTeams},
+	{"points", findPointsDrift},
+}
+
+var integrityCommand = command{
+	name:  "integrity",
+	usage: "Check the records of the database agree with each other and report what does not.\nWith -fix every inconsistency that can be fixed is fixed, the rest are left to fix by hand.",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		fix := fs.Bool("fix", false, "fix the inconsistencies found")
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+			if *fix {
+				if err := app.confirmWrites(); err != nil {
+					return err
+				}
+			}
+
+			found, left := 0, 0
+			for _, c := range integrityChecks {
+				inconsistencies, err := c.find(ctx, app)
+				if err != nil {
+					return fmt.Errorf("%s: %w", c.name, err)
+				}
+
+				var lines []string
+				checkLeft := 0
+				for _, inc := range inconsistencies {
+					switch {
+					case !*fix:
+						checkLeft++
+						lines = append(lines, inc.problem)
+					case inc.fix == nil:
+						checkLeft++
+						lines = append(lines, inc.problem+" - fix by hand")
+					case app.options.dryRun:
+						checkLeft++
+						lines = append(lines, inc.problem+" - dry run, would be fixed")
+					default:
+						if err := inc.fix(ctx); err != nil {
+							checkLeft++
+							lines = append(lines, fmt.Sprintf("%s - unable to fix: %v", inc.problem, err))
+						} else {
+							lines = append(lines, inc.problem+" - fixed")
+						}
+					}
+				}
+
+				switch {
+				case len(inconsistencies) == 0:
+					fmt.Fprintf(app.out, "ok    %s\n", c.name)
+				case checkLeft == 0:
+					fmt.Fprintf(app.out, "fixed %s\n", c.name)
+				default:
+					fmt.Fprintf(app.out, "FAIL  %s\n", c.name)
+				}
+				for _, line := range lines {
+					fmt.Fprintf(app.out, "      %s\n", line)
+				}
+				found += len(inconsistencies)
+				left += checkLeft
+			}
+
+			if left > 0 {
+				if !*fix {
+					fmt.Fprintln(app.out, "run again with -fix to fix them")
+				}
+				return fmt.Errorf("%d of %d inconsistencies left", left, found)
+			}
+			return nil
+		}
+	},
+}
+
+// findMissingMissionRecords finds the teams without the escape or speed record that
+// scoring a mission expects every team to have. A team has a single speed record, so
+// it is only created when there is a single speed mission for it to be of.
+func findMissingMissionRecords(ctx context.Context, app *app) ([]inconsistency, error) {
+	escapeMissions, err := app.db.Mission.FindMany(
+		postgresql.Mission.EscapeStages.Some(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	speedMissions, err := app.db.Mission.FindMany(
+		postgresql.Mission.SpeedAnswers.Some(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teams, err := app.db.Team.FindMany().With(
+		postgresql.Team.Escape.Fetch(),
+		postgresql.Team.Speed.Fetch(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var inconsistencies []inconsistency
+	for _, team := range teams {
+		teamID := team.ID
+		if _, ok := team.Escape(); !ok && len(escapeMissions) > 0 {
+			inconsistencies = append(inconsistencies, inconsistency{
+				problem: fmt.Sprintf("team %s has no escape record", teamName(team)),
+				fix: func(ctx context.Context) error {
+					_, err := query.UpsertUniqueEscape(ctx, app.db, &model.UpsertEscapeInput{TeamID: teamID})
+					return err
+				},
+			})
+		}
+		if _, ok := team.Speed(); !ok && len(speedMissions) > 0 {
+			inc := inconsistency{problem: fmt.Sprintf("team %s has no speed record", teamName(team))}
+			if len(speedMissions) == 1 {
+				missionID := speedMissions[0].ID
+				inc.fix = func(ctx context.Context) error {
+					_, err := query.UpsertUniqueSpeed(ctx, app.db, &model.UpsertSpeedInput{TeamID: teamID, MissionID: missionID})
+					return err
+				}
+			} else {
+				inc.problem += fmt.Sprintf(", pick one of the %d speed missions for it", len(speedMissions))
+			}
+			inconsistencies = append(inconsistencies, inc)
+		}
+	}
+
+	return inconsistencies, nil
+}
+
+// findProfilesWithoutUser finds the profiles left behind by users that were never
+// created or were deleted, nothing can reach them anymore. Profiles younger than the
+// grace period may belong to a sign up in progress and are left alone.
+func findProfilesWithoutUser(ctx context.Context, app *app) ([]inconsistency, error) {
+	var profiles []struct {
+		ID      string `json:"id"`
+		NameEng string `json:"nameEng"`
+	}
+	err := app.db.Prisma.QueryRaw(`
+		SELECT
+			P.id,
+			P."nameEng"
+		FROM
+			"Profile" P
+		WHERE
+			NOT EXISTS (SELECT 1 FROM "User" U WHERE U."profileId" = P.id)
+			AND P."createdAt" < $1::timestamp
+		ORDER BY
+			P."createdAt"
+	`, time.Now().Add(-profileGracePeriod).UTC().Format(time.RFC3339Nano)).Exec(ctx, &profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	var inconsistencies []inconsistency
+	for _, profile := range profiles {
+		profileID := profile.ID
+		inconsistencies = append(inconsistencies, inconsistency{
+			problem: fmt.Sprintf("profile %s of %s has no user", profileID, profile.NameEng),
+			fix: func(ctx context.Context) error {
+				_, err := app.db.Profile.FindUnique(postgresql.Profile.ID.Equals(profileID)).Delete().Exec(ctx)
+				return err
+			},
+		})
+	}
+
+	return inconsistencies, nil
+}
+
+// findUsersWithoutRole finds the users without any role, they are given the player role
+// like a user registered without one.
+func findUsersWithoutRole(ctx context.Context, app *app) ([]inconsistency, error) {
+	users, err := app.db.User.FindMany(
+		postgresql.User.Not(postgresql.User.UserRole.Some()),
+	).OrderBy(
+		postgresql.User.CreatedAt.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var inconsistencies []inconsistency
+	for _, user := range users {
+		userID := user.ID
+		inconsistencies = append(inconsistencies, inconsistency{
+			problem: fmt.Sprintf("user %s has no role", user.Username),
+			fix: func(ctx context.Context) error {
+				_, err := app.db.UserRole.CreateOne(
+					postgresql.UserRole.ID.Set(gofakeit.UUID()),
+					postgresql.UserRole.Role.Set(postgresql.RolePLAYER),
+					postgresql.UserRole.User.Link(postgresql.User.ID.Equals(userID)),
+				).Exec(ctx)
+				return err
+			},
+		})
+	}
+
+	return inconsistencies, nil
+}
+
+// findDuplicatePowercards finds the teams eligible for the same powercard more than once.
+func findDuplicatePowercards(ctx context.Context, app *app) ([]inconsistency, error) {
+	teams, err := app.db.Team.FindMany().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var inconsistencies []inconsistency
+	for _, team := range teams {
+		seen := make(map[postgresql.Powercard]bool)
+		var unique, duplicates []postgresql.Powercard
+		for _, powercard := range team.EligiblePowercards {
+			if seen[powercard] {
+				duplicates = append(duplicates, powercard)
+				continue
+			}
+			seen[powercard] = true
+			unique = append(unique, powercard)
+		}
+		if len(duplicates) == 0 {
+			continue
+		}
+
+		teamID := team.ID
+		inconsistencies = append(inconsistencies, inconsistency{
+			problem: fmt.Sprintf("team %s is eligible for %v more than once", teamName(team), duplicates),
+			fix: func(ctx context.Context) error {
+				_, err := app.db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Update(
+					postgresql.Team.EligiblePowercards.Set(unique),
+				).Exec(ctx)
+				return err
+			},
+		})
+	}
+
+	return inconsistencies, nil
+}
+
+// findDeletedRoomTeams finds the battleground rooms with teams that no longer exist, the
+// teams are not a relation so deleting a team leaves them behind.
+func findDeletedRoomTeams(ctx context.Context, app *app) ([]inconsistency, error) {
+	teams, err := app.db.Team.FindMany().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	exists := make(map[string]bool, len(teams))
+	for _, team := range teams {
+		exists[team.ID] = true
+	}
+
+	rooms, err := app.db.BattlegroundRoom.FindMany().OrderBy(
+		postgresql.BattlegroundRoom.CreatedAt.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := func(teamIDs []string) []string {
+		kept := []string{}
+		for _, teamID := range teamIDs {
+			if exists[teamID] {
+				kept = append(kept, teamID)
+			}
+		}
+		return kept
+	}
+
+	var inconsistencies []inconsistency
+	for _, room := range rooms {
+		teamIDs := existing(room.TeamIDs)
+		if len(teamIDs) == len(room.TeamIDs) {
+			continue
+		}
+
+		code := room.Code
+		readyTeamIDs := existing(room.ReadyTeamIDs)
+		inconsistencies = append(inconsistencies, inconsistency{
+			problem: fmt.Sprintf("room %s has %d deleted teams", code, len(room.TeamIDs)-len(teamIDs)),
+			fix: func(ctx context.Context) error {
+				_, err := app.db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Update(
+					postgresql.BattlegroundRoom.TeamIDs.Set(teamIDs),
+					postgresql.BattlegroundRoom.ReadyTeamIDs.Set(readyTeamIDs),
+				).Exec(ctx)
+				return err
+			},
+		})
+	}
+
+	return inconsistencies, nil
+}
+
+// findPointsDrift finds the teams whose points are not the total of their awards. The
+// points are what the teams have been shown, so they are kept and the difference is
+// recorded as an adjustment award. An award made in the meantime moves both the points
+// and the awards, so it does not change the difference.
+func findPointsDrift(ctx context.Context, app *app) ([]inconsistency, error) {
+	var teams []struct {
+		ID      string  `json:"id"`
+		Name    *string `json:"name"`
+		Points  float64 `json:"points"`
+		Awarded float64 `json:"awarded"`
+	}
+	err := app.db.Prisma.QueryRaw(`
+		SELECT
+			T.id,
+			T.name,
+			T.points,
+			COALESCE(SUM(A.points), 0) AS awarded
+		FROM
+			"Team" T LEFT JOIN "PointAward" A ON A."teamId" = T.id
+		GROUP BY
+			T.id
+		ORDER BY
+			T.name
+	`).Exec(ctx, &teams)
+	if err != nil {
+		return nil, err
+	}
+
+	var inconsistencies []inconsistency
+	for _, team := range teams {
+		difference := team.Awarded - team.Points
+		if math.Abs(difference) <= pointsTolerance {
+			continue
+		}
+
+		name := team.ID
+		if team.Name != nil {
+			name = *team.Name
+		}
+		teamID := team.ID
+		inconsistencies = append(inconsistencies, inconsistency{
+			problem: fmt.Sprintf("team %s has %v points but was awarded %v", name, team.Points, team.Awarded),
+			fix: func(ctx context.Context) error {
+				_, err := query.RecordPointsAdjustment(ctx, app.db, teamID, -difference, "Points given without an award, found by the integrity check")
+				return err
+			},
+		})
+	}
+
+	return inconsistencies, nil
+}
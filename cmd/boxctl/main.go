@@ -0,0 +1,233 @@
+// Command boxctl runs the admin tasks of an event against the database, such as seeding
+// the database, scoring a mission or granting a powercard.
+//
+//	boxctl [--env development|production] [--dry-run] [--yes] <command> [flags] [args]
+//
+// A dry run only reports what a command would change. Commands that change the
+// production database ask for confirmation first, unless --yes is given. boxctl exits
+// with 1 when a command fails, 2 on a usage error and 3 when it is not confirmed.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+const (
+	exitError   = 1
+	exitUsage   = 2
+	exitAborted = 3
+)
+
+// The environments boxctl can run against, each is configured by its own env file.
+const (
+	envDevelopment = "development"
+	envProduction  = "production"
+)
+
+var envFiles = map[string]string{
+	envDevelopment: ".env",
+	envProduction:  ".env.production",
+}
+
+var (
+	errUsage   = errors.New("usage")
+	errAborted = errors.New("aborted")
+)
+
+// options are the flags every command accepts, before or after the command name.
+type options struct {
+	env    string
+	dryRun bool
+	yes    bool
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.env, "env", o.env, "environment to run against, development or production")
+	fs.BoolVar(&o.dryRun, "dry-run", o.dryRun, "report what would change without changing anything")
+	fs.BoolVar(&o.yes, "yes", o.yes, "do not ask for confirmation against production")
+}
+
+// command is a subcommand of boxctl. A command that writes is confirmed before it runs
+// against production, unless it is a dry run.
+type command struct {
+	name   string
+	args   string
+	usage  string
+	writes bool
+	// flags registers the flags of the command and returns what runs it with the
+	// remaining arguments.
+	flags func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error
+}
+
+// app is what a command runs with.
+type app struct {
+	options
+	db  *postgresql.PrismaClient
+	out io.Writer
+	// action is the command line, as it is shown when asking for confirmation.
+	action string
+}
+
+var commands = []command{
+	seedCommand,
+	cleanCommand,
+	scoreCommand,
+	grantCardCommand,
+	exportCommand,
+	importCommand,
+	formTeamsCommand,
+	checkCommand,
+	integrityCommand,
+}
+
+func main() {
+	// configure logger
+	log.SetFlags(0)
+
+	os.Exit(run(context.Background(), os.Args[1:]))
+}
+
+func run(ctx context.Context, args []string) int {
+	opts := options{env: os.Getenv("APP_ENV")}
+	if opts.env == "" {
+		opts.env = envDevelopment
+	}
+
+	global := flag.NewFlagSet("boxctl", flag.ContinueOnError)
+	global.Usage = usage
+	opts.register(global)
+	if err := global.Parse(args); err != nil {
+		return parseExit(err)
+	}
+	if global.NArg() == 0 {
+		usage()
+		return exitUsage
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == global.Arg(0) {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		log.Printf("boxctl: unknown command %s\n", global.Arg(0))
+		usage()
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		log.Printf("usage: boxctl %s [flags] %s\n\n%s\n\nflags:\n", cmd.name, cmd.args, cmd.usage)
+		fs.PrintDefaults()
+	}
+	opts.register(fs)
+	runCommand := cmd.flags(fs)
+	if err := fs.Parse(global.Args()[1:]); err != nil {
+		return parseExit(err)
+	}
+
+	envFile, ok := envFiles[opts.env]
+	if !ok {
+		log.Printf("boxctl: --env must be %s or %s, got %s\n", envDevelopment, envProduction, opts.env)
+		return exitUsage
+	}
+	if err := godotenv.Load(envFile); err != nil {
+		log.Printf("boxctl: unable to load %s: %v\n", envFile, err)
+		return exitError
+	}
+	os.Setenv("APP_ENV", opts.env)
+
+	a := &app{options: opts, out: os.Stdout, action: "boxctl " + strings.Join(args, " ")}
+	if cmd.writes {
+		if err := a.confirmWrites(); err != nil {
+			log.Printf("boxctl: %v\n", err)
+			return exitAborted
+		}
+	}
+
+	// connect db
+	db := postgresql.NewClient()
+	if err := db.Connect(); err != nil {
+		log.Printf("boxctl: unable to connect to the database: %v\n", err)
+		return exitError
+	}
+	a.db = db
+
+	err := runCommand(ctx, a, fs.Args())
+
+	// disconnect db
+	if err := db.Disconnect(); err != nil {
+		log.Printf("boxctl: unable to disconnect from the database: %v\n", err)
+	}
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage):
+		fs.Usage()
+		return exitUsage
+	case errors.Is(err, errAborted):
+		log.Printf("boxctl: %v\n", err)
+		return exitAborted
+	default:
+		log.Printf("boxctl %s: %v\n", cmd.name, err)
+		return exitError
+	}
+}
+
+// confirmWrites confirms a change to the production database, a command that only
+// writes with some of its flags confirms itself once its flags are known.
+func (a *app) confirmWrites() error {
+	if a.options.dryRun || a.options.env != envProduction || a.options.yes {
+		return nil
+	}
+	return confirm(os.Stdin, fmt.Sprintf("%s changes the production database", a.action))
+}
+
+// confirm asks to type the name of the environment, so a command meant for development
+// is not run against production by accident.
+func confirm(in io.Reader, action string) error {
+	fmt.Fprintf(os.Stderr, "%s, type %s to continue: ", action, envProduction)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if strings.TrimSpace(answer) != envProduction {
+		return errAborted
+	}
+	return nil
+}
+
+// parseExit is the exit code of a failed flag parse, asking for help is not a failure.
+func parseExit(err error) int {
+	if err == flag.ErrHelp {
+		return 0
+	}
+	return exitUsage
+}
+
+func usage() {
+	log.Println("usage: boxctl [--env development|production] [--dry-run] [--yes] <command> [flags] [args]")
+	log.Println()
+	log.Println("commands:")
+	for _, cmd := range commands {
+		log.Printf("  %-12s %s\n", cmd.name, strings.SplitN(cmd.usage, "\n", 2)[0])
+	}
+}
+
+// dryRunf reports a change that a dry run does not make.
+func (a *app) dryRunf(format string, args ...interface{}) {
+	fmt.Fprintf(a.out, "dry run: "+format+"\n", args...)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+var grantCardCommand = command{
+	name:   "grant-card",
+	args:   "<team_id> <powercard>",
+	usage:  "Grant a powercard to a team, such as a prize that is not given by a mission.",
+	writes: true,
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		var reason string
+		fs.StringVar(&reason, "reason", "Granted by the crew", "why the powercard is granted, shown in the history of the team")
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 2 {
+				return errUsage
+			}
+			powercard := model.Powercard(strings.ToUpper(args[1]))
+			if !powercard.IsValid() {
+				return fmt.Errorf("%w: unknown powercard %s", errUsage, args[1])
+			}
+
+			team, err := app.db.Team.FindUnique(postgresql.Team.ID.Equals(args[0])).Exec(ctx)
+			if err != nil {
+				return fmt.Errorf("team %s: %w", args[0], err)
+			}
+			return grantCard(ctx, app, *team, &model.GrantPowercardInput{
+				TeamID:    team.ID,
+				Powercard: powercard,
+				Reason:    reason,
+			})
+		}
+	},
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/graphql/query"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// The powercards a mission grants, escaping grants its card to every team that solved
+// every stage and humanity grants its card to the teams that scored high enough.
+const (
+	escapeCard          = model.PowercardReverse
+	humanityCard        = model.PowercardOnemorechance
+	humanityCardMinimum = 200
+)
+
+const speedTimeLayout = "2006-01-02 15:04:05.000"
+
+var scoreCommand = command{
+	name:   "score",
+	args:   "escape|humanity|speed <mission_id>",
+	usage:  "Score a mission, awarding the points and powercards of its results.\nEvery award is given once, so a mission can be scored again after more teams finish.",
+	writes: true,
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 2 {
+				return errUsage
+			}
+			missionID := args[1]
+			switch args[0] {
+			case "escape":
+				return scoreEscape(ctx, app, missionID)
+			case "humanity":
+				return scoreHumanity(ctx, app, missionID)
+			case "speed":
+				return scoreSpeed(ctx, app, missionID)
+			default:
+				return fmt.Errorf("%w: unknown mission %s", errUsage, args[0])
+			}
+		}
+	},
+}
+
+// scoreEscape grants the escape card to the teams that escaped. The points are awarded
+// as soon as a stage is solved, so only the card is left to grant.
+func scoreEscape(ctx context.Context, app *app, missionID string) error {
+	teams, err := app.db.Team.FindMany().Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, team := range teams {
+		tname := teamName(team)
+		progress, err := query.GetEscapeProgress(ctx, app.db, team.ID, missionID)
+		if err != nil {
+			return fmt.Errorf("team %s: %w", tname, err)
+		}
+		fmt.Fprintf(app.out, "team: %s, escape points: %v, points: %v\n", tname, progress.Points, team.Points)
+
+		// not eligible if not every stage is solved
+		if !progress.Completed {
+			continue
+		}
+		source := query.PointAwardSourceEscape
+		if err := grantCard(ctx, app, team, &model.GrantPowercardInput{
+			TeamID:    team.ID,
+			Powercard: escapeCard,
+			Reason:    "Solved every escape stage",
+			Source:    &source,
+			Reference: &missionID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scoreHumanity publishes the averaged scores of the judges and grants the humanity card
+// to the submissions that scored high enough.
+func scoreHumanity(ctx context.Context, app *app, missionID string) error {
+	var pointAwards []*model.PointAward
+	if app.options.dryRun {
+		humanities, err := app.db.Humanity.FindMany(
+			postgresql.Humanity.MissionID.Equals(missionID),
+			postgresql.Humanity.Not(postgresql.Humanity.SubmittedAt.IsNull()),
+			postgresql.Humanity.PublishedAt.IsNull(),
+		).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		for _, humanity := range humanities {
+			average, err := query.GetHumanityFinalScore(ctx, app.db, humanity.ID)
+			if err != nil {
+				return err
+			}
+			if average == nil {
+				continue
+			}
+			app.dryRunf("team: %s would be awarded %v", humanity.TeamID, *average)
+			pointAwards = append(pointAwards, &model.PointAward{TeamID: humanity.TeamID, Points: *average, Reference: humanity.ID})
+		}
+	} else {
+		var err error
+		pointAwards, err = query.PublishHumanityResults(ctx, app.db, missionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pointAward := range pointAwards {
+		team, err := app.db.Team.FindUnique(postgresql.Team.ID.Equals(pointAward.TeamID)).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(app.out, "team: %s, awarded: %v, points: %v\n", teamName(*team), pointAward.Points, team.Points)
+
+		// ignore award lesser than the eligible score
+		if pointAward.Points < humanityCardMinimum {
+			continue
+		}
+		source := query.PointAwardSourceHumanity
+		reference := pointAward.Reference
+		if err := grantCard(ctx, app, *team, &model.GrantPowercardInput{
+			TeamID:    team.ID,
+			Powercard: humanityCard,
+			Reason:    fmt.Sprintf("Humanity score of %v", pointAward.Points),
+			Source:    &source,
+			Reference: &reference,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scoreSpeed prints the ranking of a speed mission and applies its award rules.
+func scoreSpeed(ctx context.Context, app *app, missionID string) error {
+	speedRanks, err := query.GetSpeedRanking(ctx, app.db, missionID)
+	if err != nil {
+		return err
+	}
+	for _, speedRank := range speedRanks {
+		team, err := app.db.Team.FindUnique(postgresql.Team.ID.Equals(speedRank.TeamID)).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(app.out, "%d. team: %s, completed at: %s\n", speedRank.Rank, teamName(*team), speedRank.CompletedAt.Format(speedTimeLayout))
+	}
+	if app.options.dryRun {
+		app.dryRunf("the award rules of mission %s would be applied to the ranking", missionID)
+		return nil
+	}
+
+	pointAwards, err := query.AwardSpeedRanking(ctx, app.db, missionID)
+	if err != nil {
+		return err
+	}
+	for _, pointAward := range pointAwards {
+		team, err := app.db.Team.FindUnique(postgresql.Team.ID.Equals(pointAward.TeamID)).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(app.out, "team: %s, awarded: %v, points: %v, powercards: %v\n", teamName(*team), pointAward.Points, team.Points, team.EligiblePowercards)
+	}
+
+	return nil
+}
+
+// grantCard grants a powercard to a team, a team that already holds or was already
+// granted the powercard is skipped.
+func grantCard(ctx context.Context, app *app, team postgresql.TeamModel, param *model.GrantPowercardInput) error {
+	tname := teamName(team)
+	if app.options.dryRun {
+		for _, held := range team.EligiblePowercards {
+			if held == postgresql.Powercard(param.Powercard) {
+				fmt.Fprintf(app.out, "team: %s skipped - %v\n", tname, query.ErrPowercardHeld)
+				return nil
+			}
+		}
+		app.dryRunf("team: %s would get powercard %v", tname, param.Powercard)
+		return nil
+	}
+
+	_, err := query.GrantPowercard(ctx, app.db, param, nil)
+	if errors.Is(err, query.ErrPowercardHeld) || errors.Is(err, query.ErrPowercardGranted) {
+		fmt.Fprintf(app.out, "team: %s skipped - %v\n", tname, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("team %s: %w", tname, err)
+	}
+
+	fmt.Fprintf(app.out, "team: %s get powercard - %v\n", tname, param.Powercard)
+	return nil
+}
+
+func teamName(team postgresql.TeamModel) string {
+	if name, ok := team.Name(); ok {
+		return name
+	}
+	return team.ID
+}
@@ -2,171 +2,107 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"io"
 	"math/rand"
-	"os"
 	"strings"
 
 	"github.com/brianvoe/gofakeit/v6"
-	"github.com/joho/godotenv"
 	"github.com/marcustut/thebox/internal/graphql/model"
 	"github.com/marcustut/thebox/internal/postgresql"
 )
 
-var ACTIONS map[string]bool = map[string]bool{
-	"clean": true,
-	"seed":  true,
-	"both":  true,
-}
-
-func init() {
-	if os.Getenv("APP_ENV") != "development" && os.Getenv("APP_ENV") != "production" {
-		panic(fmt.Errorf("APP_ENV is not set or not one of `development` or `production`"))
-	}
-
-	var err error
-	if os.Getenv("APP_ENV") == "development" {
-		err = godotenv.Load(".env")
-	} else if os.Getenv("APP_ENV") == "production" {
-		err = godotenv.Load(".env.production")
-	}
+// How many rows of fake data are seeded, every user has a profile and an address.
+const (
+	seedClusters = 3
+	seedTeams    = 50
+	seedMissions = 10
+	seedUsers    = 400
+	seedPosts    = 100
+	seedComments = 200
+	seedLikes    = 200
+)
 
-	if err != nil {
-		panic(err)
-	}
+// cleanTables are wiped by clean, along with the rows of other tables that refer to them.
+var cleanTables = []string{
+	"TeamMission", "Team", "Cluster", "Mission", "PostLike", "CommentLike", "Comment", "Post",
+	"User", "Profile", "Address", "UserRole",
 }
 
-func main() {
-	// get current context
-	ctx := context.Background()
-	// create db client
-	client := postgresql.NewClient()
-	// configure logger
-	log.SetFlags(0)
-
-	// connect db
-	if err := client.Connect(); err != nil {
-		panic(err)
-	}
-
-	// disconnect db
-	defer func() {
-		if err := client.Disconnect(); err != nil {
-			panic(err)
+var seedCommand = command{
+	name:   "seed",
+	usage:  "Seed the database with fake clusters, teams, missions, users and posts.",
+	writes: true,
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		var clean bool
+		fs.BoolVar(&clean, "clean", false, "clean the database before seeding it")
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+			if clean {
+				if err := runClean(ctx, app); err != nil {
+					return fmt.Errorf("error cleaning db: %w", err)
+				}
+			}
+			if app.options.dryRun {
+				app.dryRunf("seed %d Cluster, %d Team, %d Mission, %d User with a Profile and an Address, %d Post, %d Comment and up to %d likes",
+					seedClusters, seedTeams, seedMissions, seedUsers, seedPosts, seedComments, seedLikes)
+				return nil
+			}
+			if err := seed(ctx, app.db, app.out); err != nil {
+				return fmt.Errorf("error seeding db: %w", err)
+			}
+			return nil
 		}
-	}()
-
-	if len(os.Args) < 2 {
-		log.Fatal("use cli args `clean`, `seed` or `both` to perform seeding")
-	} else if len(os.Args) > 2 {
-		log.Fatal("only accept one of `clean`, `seed` or `both`")
-	}
-
-	arg := strings.ToLower(strings.TrimSpace(os.Args[1]))
+	},
+}
 
-	switch arg {
-	case "clean":
-		// clean previous records
-		if err := clean(ctx, client); err != nil {
-			panic(fmt.Errorf("error cleaning db: %s", err))
-		}
-	case "seed":
-		// seed new data
-		if err := seed(ctx, client); err != nil {
-			panic(fmt.Errorf("error seeding db: %s", err))
-		}
-	case "both":
-		// clean previous records
-		if err := clean(ctx, client); err != nil {
-			panic(fmt.Errorf("error cleaning db: %s", err))
-		}
-		// seed new data
-		if err := seed(ctx, client); err != nil {
-			panic(fmt.Errorf("error seeding db: %s", err))
+var cleanCommand = command{
+	name:   "clean",
+	usage:  "Delete the users, teams, missions and posts along with everything that refers to them.",
+	writes: true,
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, app *app, args []string) error {
+		return func(ctx context.Context, app *app, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+			if err := runClean(ctx, app); err != nil {
+				return fmt.Errorf("error cleaning db: %w", err)
+			}
+			return nil
 		}
-	default:
-		log.Fatalf("`%s` is not a valid cli argument", arg)
-	}
+	},
 }
 
-func clean(ctx context.Context, client *postgresql.PrismaClient) error {
-	res, err := client.TeamMission.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in TeamMission\n", res.Count)
-
-	res, err = client.Team.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in Team\n", res.Count)
-
-	res, err = client.Cluster.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in Cluster\n", res.Count)
-
-	res, err = client.Mission.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in Mission\n", res.Count)
-
-	res, err = client.PostLike.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in PostLike\n", res.Count)
-
-	res, err = client.CommentLike.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in CommentLike\n", res.Count)
-
-	res, err = client.Comment.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in Comment\n", res.Count)
-
-	res, err = client.Post.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in Post\n", res.Count)
-
-	res, err = client.User.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
-	}
-	log.Printf("clean: deleted %d rows in User\n", res.Count)
-
-	res, err = client.Profile.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
+// runClean truncates the tables in one statement, so the order of the foreign keys does
+// not matter and nothing is left half deleted.
+func runClean(ctx context.Context, app *app) error {
+	for _, table := range cleanTables {
+		count, err := countRows(ctx, app, table)
+		if err != nil {
+			return err
+		}
+		if app.options.dryRun {
+			app.dryRunf("clean would delete %d rows in %s", count, table)
+			continue
+		}
+		fmt.Fprintf(app.out, "clean: deleting %d rows in %s\n", count, table)
 	}
-	log.Printf("clean: deleted %d rows in Profile\n", res.Count)
-
-	res, err = client.Address.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
+	if app.options.dryRun {
+		return nil
 	}
-	log.Printf("clean: deleted %d rows in Address\n", res.Count)
 
-	res, err = client.UserRole.FindMany().Delete().Exec(ctx)
-	if err != nil {
-		return err
+	quoted := make([]string, len(cleanTables))
+	for i, table := range cleanTables {
+		quoted[i] = fmt.Sprintf(`"%s"`, table)
 	}
-	log.Printf("clean: deleted %d rows in UserRole\n", res.Count)
-
-	return nil
+	_, err := app.db.Prisma.ExecuteRaw(fmt.Sprintf(`TRUNCATE %s CASCADE`, strings.Join(quoted, ", "))).Exec(ctx)
+	return err
 }
 
-func seed(ctx context.Context, client *postgresql.PrismaClient) error {
+func seed(ctx context.Context, client *postgresql.PrismaClient, out io.Writer) error {
 	roles := []postgresql.Role{postgresql.RolePLAYER, postgresql.RoleCREW, postgresql.RoleCLUSTERLEADER, postgresql.RoleTEAMLEADER}
 
 	var clusters []string
@@ -178,7 +114,7 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 	var posts []string
 	var comments []string
 
-	for i := 0; i < 3; i++ {
+	for i := 0; i < seedClusters; i++ {
 		var c model.Cluster
 		gofakeit.Struct(&c)
 		clusters = append(clusters, c.ID)
@@ -190,10 +126,10 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created Cluster %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created Cluster %s\n", res.ID)
 	}
 
-	for i := 0; i < 50; i++ {
+	for i := 0; i < seedTeams; i++ {
 		var t model.Team
 		gofakeit.Struct(&t)
 		teams = append(teams, t.ID)
@@ -207,10 +143,10 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created Team %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created Team %s\n", res.ID)
 	}
 
-	for i := 0; i < 10; i++ {
+	for i := 0; i < seedMissions; i++ {
 		var m model.Mission
 		gofakeit.Struct(&m)
 		missions = append(missions, m.ID)
@@ -227,10 +163,10 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created Mission %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created Mission %s\n", res.ID)
 	}
 
-	for i := 0; i < 400; i++ {
+	for i := 0; i < seedUsers; i++ {
 		var a model.Address
 		var p model.Profile
 		gofakeit.Struct(&a)
@@ -256,17 +192,17 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 			postgresql.Profile.Dob.Set(p.Dob),
 			postgresql.Profile.UpdatedAt.Set(p.UpdatedAt),
 			postgresql.Profile.AddressID.Set(addresses[rand.Intn(len(addresses))]),
-			postgresql.Profile.NameEng.SetIfPresent(p.NameChi),
+			postgresql.Profile.NameChi.SetIfPresent(p.NameChi),
 			postgresql.Profile.TngReceiptURL.SetIfPresent(p.TngReceiptURL),
-			postgresql.Profile.AvatarURL.SetIfPresent(p.TngReceiptURL),
+			postgresql.Profile.AvatarURL.SetIfPresent(p.AvatarURL),
 			postgresql.Profile.Satellite.SetIfPresent((*postgresql.Satellite)(p.Satellite)),
 			postgresql.Profile.Status.SetIfPresent((*postgresql.PastoralStatus)(p.Status)),
 		).Exec(ctx)
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created Address %s\n", res.ID)
-		log.Printf("seed: created Profile %s\n", res2.ID)
+		fmt.Fprintf(out, "seed: created Address %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created Profile %s\n", res2.ID)
 	}
 
 	for i := 0; i < len(profiles); i++ {
@@ -284,7 +220,7 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created User %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created User %s\n", res.ID)
 	}
 
 	for i := 0; i < len(users); i++ {
@@ -297,7 +233,7 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created UserRole %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created UserRole %s\n", res.ID)
 	}
 
 	for i := 0; i < len(teams)*len(missions); i++ {
@@ -308,10 +244,10 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			continue
 		}
-		log.Printf("seed: created TeamMission %s,%s\n", res.TeamID, res.MissionID)
+		fmt.Fprintf(out, "seed: created TeamMission %s,%s\n", res.TeamID, res.MissionID)
 	}
 
-	for i := 0; i < 100; i++ {
+	for i := 0; i < seedPosts; i++ {
 		var p model.Post
 		gofakeit.Struct(&p)
 		posts = append(posts, p.ID)
@@ -326,10 +262,10 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created Post %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created Post %s\n", res.ID)
 	}
 
-	for i := 0; i < 200; i++ {
+	for i := 0; i < seedComments; i++ {
 		var c model.Comment
 		gofakeit.Struct(&c)
 		comments = append(comments, c.ID)
@@ -343,10 +279,10 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			return err
 		}
-		log.Printf("seed: created Comment %s\n", res.ID)
+		fmt.Fprintf(out, "seed: created Comment %s\n", res.ID)
 	}
 
-	for i := 0; i < 200; i++ {
+	for i := 0; i < seedLikes; i++ {
 		res, err := client.PostLike.CreateOne(
 			postgresql.PostLike.Post.Link(postgresql.Post.ID.Equals(posts[rand.Intn(len(posts))])),
 			postgresql.PostLike.User.Link(postgresql.User.ID.Equals(users[rand.Intn(len(users))])),
@@ -361,8 +297,8 @@ func seed(ctx context.Context, client *postgresql.PrismaClient) error {
 		if err != nil {
 			continue
 		}
-		log.Printf("seed: created PostLike %s,%s\n", res.PostID, res.UserID)
-		log.Printf("seed: created CommentLike %s,%s\n", res2.CommentID, res2.UserID)
+		fmt.Fprintf(out, "seed: created PostLike %s,%s\n", res.PostID, res.UserID)
+		fmt.Fprintf(out, "seed: created CommentLike %s,%s\n", res2.CommentID, res2.UserID)
 	}
 
 	return nil
@@ -2,26 +2,41 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	gqlgraphql "github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	echoadapter "github.com/awslabs/aws-lambda-go-api-proxy/echo"
 	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/marcustut/thebox/internal/auth"
+	"github.com/marcustut/thebox/internal/battleground"
 	"github.com/marcustut/thebox/internal/graphql"
 	"github.com/marcustut/thebox/internal/graphql/generated"
+	"github.com/marcustut/thebox/internal/storage"
 )
 
 const defaultPort = "8080"
 
-var echoApp *echo.Echo
+var allowOrigins = []string{"http://localhost:3000", "https://thebox.fgacycyw.com", "https://thebox.marcustut.tech"}
+
+var (
+	echoApp  *echo.Echo
+	resolver *graphql.Resolver
+)
 
 type CustomValidator struct {
 	validator *validator.Validate
@@ -44,13 +59,28 @@ func init() {
 	echoApp.Use(middleware.Gzip())
 
 	echoApp.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"http://localhost:3000", "https://thebox.fgacycyw.com", "https://thebox.marcustut.tech"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowOrigins: allowOrigins,
+		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
 		AllowMethods: []string{http.MethodGet, http.MethodPatch, http.MethodPost, http.MethodDelete},
 	}))
 
-	schema := generated.NewExecutableSchema(generated.Config{Resolvers: graphql.NewResolver(echoApp)})
-	server := handler.NewDefaultServer(schema)
+	// anyone can sign a token with an empty secret, so there is no running without one
+	secret := os.Getenv("SUPABASE_JWT_SECRET")
+	if secret == "" {
+		log.Fatalln("SUPABASE_JWT_SECRET is not set")
+	}
+	echoApp.Use(auth.Middleware([]byte(secret)))
+
+	var err error
+	resolver, err = graphql.NewResolver(echoApp, battleground.RealClock())
+	if err != nil {
+		log.Fatalln(err)
+	}
+	schema := generated.NewExecutableSchema(generated.Config{
+		Resolvers:  resolver,
+		Directives: generated.DirectiveRoot{HasRole: resolver.HasRole},
+	})
+	server := newServer(schema)
 	playground := playground.Handler("GraphQL playground", "/graphql")
 
 	echoApp.GET("/health", func(c echo.Context) error {
@@ -62,18 +92,71 @@ func init() {
 		return nil
 	})
 
+	// subscriptions are served over websocket
+	echoApp.GET("/graphql", func(c echo.Context) error {
+		server.ServeHTTP(c.Response(), c.Request())
+		return nil
+	})
+
 	echoApp.GET("/playground", func(c echo.Context) error {
 		playground.ServeHTTP(c.Response(), c.Request())
 		return nil
 	})
+
+	echoApp.GET(graphql.ExportRoutePrefix+":file", resolver.ExportFile)
+
+	// local storage is served by this server itself
+	if local, ok := resolver.LocalStorage(); ok {
+		echoApp.GET(storage.LocalRoutePrefix+"*", local.Handler)
+	}
+}
+
+// newServer is the default gqlgen server with a websocket transport that accepts the
+// same origins as CORS does.
+func newServer(schema gqlgraphql.ExecutableSchema) *handler.Server {
+	server := handler.New(schema)
+
+	server.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				origin := r.Header.Get("Origin")
+				for _, allowOrigin := range allowOrigins {
+					if origin == allowOrigin {
+						return true
+					}
+				}
+				return origin == ""
+			},
+		},
+	})
+	server.AddTransport(transport.Options{})
+	server.AddTransport(transport.GET{})
+	server.AddTransport(transport.POST{})
+	server.AddTransport(transport.MultipartForm{})
+
+	server.SetQueryCache(lru.New(1000))
+
+	server.Use(extension.Introspection{})
+	server.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New(100),
+	})
+
+	return server
 }
 
 func main() {
 	isRunningAtLambda := strings.Contains(os.Getenv("AWS_EXECUTION_ENV"), "AWS_Lambda_")
 
 	if isRunningAtLambda {
+		// nothing keeps running between invocations to run the round timers, a schedule
+		// invokes the function to settle the rounds past their deadline instead
 		lambda.Start(lambdaHandler)
 	} else {
+		if err := resolver.RestoreBattlegroundTimers(context.Background()); err != nil {
+			log.Printf("unable to restore the battleground timers: %v\n", err)
+		}
+
 		port := os.Getenv("PORT")
 		if port == "" {
 			port = defaultPort
@@ -85,7 +168,23 @@ func main() {
 
 }
 
-func lambdaHandler(ctx context.Context, req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+// scheduledEventSource is the source of the events a schedule invokes the function with.
+const scheduledEventSource = "aws.events"
+
+func lambdaHandler(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+	var event events.CloudWatchEvent
+	if err := json.Unmarshal(payload, &event); err == nil && event.Source == scheduledEventSource {
+		return nil, resolver.SettleExpiredBattlegroundRounds(ctx)
+	}
+
+	var req events.APIGatewayProxyRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, err
+	}
+	return proxyHandler(req)
+}
+
+func proxyHandler(req events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	echoAdapter := echoadapter.New(echoApp)
 
 	resp, err := echoAdapter.Proxy(req)
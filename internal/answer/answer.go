@@ -0,0 +1,47 @@
+package answer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// Normalize folds the differences players should not be penalised for: full-width
+// and half-width characters are unified (ＡＢＣ１２３ becomes ABC123), letters are
+// lower cased and all whitespace, including the ideographic space, is removed.
+func Normalize(s string) string {
+	s = width.Fold.String(s)
+	s = strings.ToLower(s)
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// NewSalt returns a random salt to hash an expected answer with.
+func NewSalt() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Hash returns the hex encoded hash of the normalized answer.
+func Hash(s string, salt string) string {
+	sum := sha256.Sum256([]byte(salt + Normalize(s)))
+	return hex.EncodeToString(sum[:])
+}
+
+// Matches reports whether s is the answer hashed as hash with salt.
+func Matches(s string, hash string, salt string) bool {
+	return subtle.ConstantTimeCompare([]byte(Hash(s, salt)), []byte(hash)) == 1
+}
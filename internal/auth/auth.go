@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/labstack/echo/v4"
+)
+
+type contextKey struct{}
+
+// Middleware authenticates requests carrying a Supabase access token in the
+// Authorization header, the id of the user is put into the request context.
+// Requests without a token pass through unauthenticated so public queries keep
+// working, it is up to the resolvers to require a user.
+func Middleware(secret []byte) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if header == "" {
+				return next(c)
+			}
+
+			userID, err := parseToken(strings.TrimPrefix(header, "Bearer "), secret)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			}
+
+			c.SetRequest(c.Request().WithContext(WithUserID(c.Request().Context(), userID)))
+			return next(c)
+		}
+	}
+}
+
+// WithUserID returns a copy of ctx authenticated as the given user.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserID returns the id of the authenticated user, ok is false for anonymous requests.
+func UserID(ctx context.Context) (userID string, ok bool) {
+	userID, ok = ctx.Value(contextKey{}).(string)
+	return userID, ok
+}
+
+func parseToken(raw string, secret []byte) (string, error) {
+	claims := &jwt.StandardClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("token has no subject")
+	}
+
+	return claims.Subject, nil
+}
@@ -0,0 +1,164 @@
+package battleground
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Format decides how the teams of a tournament meet each other.
+type Format string
+
+const (
+	// FormatSingleElimination knocks out the loser of every match.
+	FormatSingleElimination Format = "SINGLE_ELIMINATION"
+	// FormatSwiss pairs up teams with the same score for a fixed number of rounds.
+	FormatSwiss Format = "SWISS"
+)
+
+// Pair is a match of a tournament, a pair with a single team is a bye and the team
+// goes through without playing.
+type Pair []string
+
+// EliminationRounds is how many rounds a single elimination bracket of the teams takes.
+func EliminationRounds(teams int) int {
+	rounds := 0
+	for size := 1; size < teams; size *= 2 {
+		rounds++
+	}
+	return rounds
+}
+
+// SwissRounds is how many rounds of swiss it takes to find a single unbeaten team.
+func SwissRounds(teams int) int {
+	return EliminationRounds(teams)
+}
+
+// SeedElimination pairs up the seeded teams for the first round of a single elimination
+// bracket. The best seeds meet as late as possible and get the byes when the number of
+// teams is not a power of two.
+func SeedElimination(seeded []string) ([]Pair, error) {
+	if len(seeded) < 2 {
+		return nil, fmt.Errorf("at least 2 teams are needed, got %d", len(seeded))
+	}
+
+	// place the seeds so that 1 and 2 can only meet in the final
+	order := []int{1}
+	for size := 1; size < len(seeded); size *= 2 {
+		var next []int
+		for _, seed := range order {
+			next = append(next, seed, 2*size+1-seed)
+		}
+		order = next
+	}
+
+	var pairs []Pair
+	for i := 0; i < len(order); i += 2 {
+		pair := Pair{}
+		for _, seed := range order[i : i+2] {
+			if seed <= len(seeded) {
+				pair = append(pair, seeded[seed-1])
+			}
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// AdvanceElimination pairs up the winners of a round of single elimination, the winners
+// of two neighbouring matches meet in the next round.
+func AdvanceElimination(winners []string) []Pair {
+	var pairs []Pair
+	for i := 0; i < len(winners); i += 2 {
+		pair := Pair{winners[i]}
+		if i+1 < len(winners) {
+			pair = append(pair, winners[i+1])
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+// PairSwiss pairs up the teams for a round of swiss. Teams are ranked by their score and
+// then by their seed, and within a score the top half meets the bottom half, avoiding
+// teams that met before. A team left over moves down to the next score. With an odd
+// number of teams the lowest ranked team without a bye gets one.
+func PairSwiss(seeded []string, scores map[string]int, played map[string]map[string]bool, byes map[string]bool) []Pair {
+	ranked := RankSwiss(seeded, scores)
+
+	var bye Pair
+	if len(ranked)%2 == 1 {
+		i := len(ranked) - 1
+		for j := len(ranked) - 1; j >= 0; j-- {
+			if !byes[ranked[j]] {
+				i = j
+				break
+			}
+		}
+		bye = Pair{ranked[i]}
+		ranked = append(append([]string{}, ranked[:i]...), ranked[i+1:]...)
+	}
+
+	var pairs []Pair
+	var group []string
+	for i, team := range ranked {
+		group = append(group, team)
+		if i+1 < len(ranked) && scores[ranked[i+1]] == scores[team] {
+			continue
+		}
+		// the last team of an odd group moves down
+		var floater []string
+		if len(group)%2 == 1 && i+1 < len(ranked) {
+			floater = group[len(group)-1:]
+			group = group[:len(group)-1]
+		}
+		pairs = append(pairs, pairSwissGroup(group, played)...)
+		group = floater
+	}
+	if bye != nil {
+		pairs = append(pairs, bye)
+	}
+	return pairs
+}
+
+// pairSwissGroup pairs the top half of a group with its bottom half, a rematch is only
+// played when every other team is taken.
+func pairSwissGroup(group []string, played map[string]map[string]bool) []Pair {
+	var pairs []Pair
+	paired := make(map[string]bool)
+	half := len(group) / 2
+	for i, team := range group {
+		if paired[team] {
+			continue
+		}
+		// the bottom half comes first
+		split := half
+		if split < i+1 {
+			split = i + 1
+		}
+		candidates := append(append([]string{}, group[split:]...), group[i+1:split]...)
+		opponent := ""
+		for _, other := range candidates {
+			if paired[other] {
+				continue
+			}
+			if opponent == "" {
+				opponent = other
+			}
+			if !played[team][other] {
+				opponent = other
+				break
+			}
+		}
+		paired[team], paired[opponent] = true, true
+		pairs = append(pairs, Pair{team, opponent})
+	}
+	return pairs
+}
+
+// RankSwiss orders the seeded teams by their score, teams with the same score keep the
+// order of their seed.
+func RankSwiss(seeded []string, scores map[string]int) []string {
+	ranked := append([]string{}, seeded...)
+	sort.SliceStable(ranked, func(i, j int) bool { return scores[ranked[i]] > scores[ranked[j]] })
+	return ranked
+}
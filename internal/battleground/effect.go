@@ -0,0 +1,114 @@
+package battleground
+
+// Effect is what the box opened after a round does to the points of the winner.
+type Effect string
+
+const (
+	EffectAdd20Percent      Effect = "ADD_20_PERCENT"
+	EffectAdd30Percent      Effect = "ADD_30_PERCENT"
+	EffectAdd50Percent      Effect = "ADD_50_PERCENT"
+	EffectAdd90Percent      Effect = "ADD_90_PERCENT"
+	EffectAdd100Percent     Effect = "ADD_100_PERCENT"
+	EffectSubtract20Percent Effect = "SUBTRACT_20_PERCENT"
+	EffectSubtract30Percent Effect = "SUBTRACT_30_PERCENT"
+	EffectSubtract50Percent Effect = "SUBTRACT_50_PERCENT"
+	EffectGive80            Effect = "GIVE_80"
+	EffectGive100           Effect = "GIVE_100"
+	EffectGive150           Effect = "GIVE_150"
+	EffectGive200           Effect = "GIVE_200"
+	EffectSteal80           Effect = "STEAL_80"
+	EffectSteal100          Effect = "STEAL_100"
+	EffectSteal150          Effect = "STEAL_150"
+	EffectSteal200          Effect = "STEAL_200"
+)
+
+// Effects lists the boxes of a room, each of them can only be opened once per room.
+var Effects = []Effect{
+	EffectAdd20Percent, EffectAdd30Percent, EffectAdd50Percent, EffectAdd90Percent, EffectAdd100Percent,
+	EffectSubtract20Percent, EffectSubtract30Percent, EffectSubtract50Percent,
+	EffectGive80, EffectGive100, EffectGive150, EffectGive200,
+	EffectSteal80, EffectSteal100, EffectSteal150, EffectSteal200,
+}
+
+// EffectRule is how an effect changes the points of a round. The points of the winner
+// are multiplied first, then the transfer moves points from the loser to the winner. A
+// negative transfer gives them to the loser instead, a zero multiplier keeps the points.
+type EffectRule struct {
+	Multiplier float64 `json:"multiplier"`
+	Transfer   float64 `json:"transfer"`
+}
+
+// EffectTable holds the rule of every effect.
+type EffectTable map[Effect]EffectRule
+
+// DefaultEffectTable is the effect table used in the rooms.
+var DefaultEffectTable = EffectTable{
+	EffectAdd20Percent:      {Multiplier: 1.2},
+	EffectAdd30Percent:      {Multiplier: 1.3},
+	EffectAdd50Percent:      {Multiplier: 1.5},
+	EffectAdd90Percent:      {Multiplier: 1.9},
+	EffectAdd100Percent:     {Multiplier: 2},
+	EffectSubtract20Percent: {Multiplier: 0.8},
+	EffectSubtract30Percent: {Multiplier: 0.7},
+	EffectSubtract50Percent: {Multiplier: 0.5},
+	EffectGive80:            {Transfer: -80},
+	EffectGive100:           {Transfer: -100},
+	EffectGive150:           {Transfer: -150},
+	EffectGive200:           {Transfer: -200},
+	EffectSteal80:           {Transfer: 80},
+	EffectSteal100:          {Transfer: 100},
+	EffectSteal150:          {Transfer: 150},
+	EffectSteal200:          {Transfer: 200},
+}
+
+// Apply returns the points of the winner and the loser of a round after the effect,
+// an effect missing from the table changes nothing.
+func (t EffectTable) Apply(effect Effect, winner float64, loser float64) (float64, float64) {
+	rule, ok := t[effect]
+	if !ok {
+		return winner, loser
+	}
+	if rule.Multiplier != 0 {
+		winner *= rule.Multiplier
+	}
+	return winner + rule.Transfer, loser - rule.Transfer
+}
+
+// Play returns the points of the winner and the loser of a round after the powercard
+// is played, given their points before the effect was applied. REVERSE applies the
+// effect to the loser instead, BLOCK cancels the effect and ONEMORECHANCE cancels it
+// so another box can be opened, in which case reopen is true.
+func (t EffectTable) Play(powercard Powercard, effect Effect, winner float64, loser float64) (float64, float64, bool) {
+	switch powercard {
+	case PowercardReverse:
+		loser, winner = t.Apply(effect, loser, winner)
+		return winner, loser, false
+	case PowercardOneMoreChance:
+		return winner, loser, true
+	default:
+		return winner, loser, false
+	}
+}
+
+// Apply returns the points of the winner and the loser of a round after the effect.
+func (e Effect) Apply(winner float64, loser float64) (float64, float64) {
+	return DefaultEffectTable.Apply(e, winner, loser)
+}
+
+// Powercard is played by a team against the effect of a round it played.
+type Powercard string
+
+const (
+	PowercardReverse       Powercard = "REVERSE"
+	PowercardBlock         Powercard = "BLOCK"
+	PowercardOneMoreChance Powercard = "ONEMORECHANCE"
+)
+
+// Powercards lists every powercard.
+var Powercards = []Powercard{PowercardReverse, PowercardBlock, PowercardOneMoreChance}
+
+// Play returns the points of the winner and the loser of a round after the powercard
+// is played against the effect, see EffectTable.Play.
+func (p Powercard) Play(effect Effect, winner float64, loser float64) (float64, float64, bool) {
+	return DefaultEffectTable.Play(p, effect, winner, loser)
+}
@@ -0,0 +1,49 @@
+package battleground
+
+import "sync"
+
+// Hub lets the subscribers of a room know that something in the room changed.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan struct{}]bool
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[string]map[chan struct{}]bool)}
+}
+
+// Subscribe returns a channel notified on every change to a room and a function that
+// unsubscribes it. Notifications are coalesced so a slow subscriber never blocks.
+func (h *Hub) Subscribe(code string) (<-chan struct{}, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan struct{}, 1)
+	if h.subscribers[code] == nil {
+		h.subscribers[code] = make(map[chan struct{}]bool)
+	}
+	h.subscribers[code][ch] = true
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[code], ch)
+		if len(h.subscribers[code]) == 0 {
+			delete(h.subscribers, code)
+		}
+	}
+}
+
+// Publish notifies the subscribers of a room.
+func (h *Hub) Publish(code string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[code] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package battleground
+
+import "math/rand"
+
+// Selection is what a player picks in a round.
+type Selection string
+
+const (
+	SelectionKing   Selection = "KING"
+	SelectionKnight Selection = "KNIGHT"
+	SelectionWitch  Selection = "WITCH"
+)
+
+// Selections lists every selection a player can pick.
+var Selections = []Selection{SelectionKing, SelectionKnight, SelectionWitch}
+
+// beats maps a selection to the selection it wins against.
+var beats = map[Selection]Selection{
+	SelectionKing:   SelectionKnight,
+	SelectionKnight: SelectionWitch,
+	SelectionWitch:  SelectionKing,
+}
+
+// Winner is the side that won a round.
+type Winner string
+
+const (
+	WinnerAttacker Winner = "ATTACKER"
+	WinnerDefender Winner = "DEFENDER"
+	WinnerDraw     Winner = "DRAW"
+)
+
+// Outcome decides the winner of a round. A side without a selection forfeits the round,
+// and the round is a draw when both sides have none.
+func Outcome(attacker *Selection, defender *Selection) Winner {
+	switch {
+	case attacker == nil && defender == nil:
+		return WinnerDraw
+	case attacker == nil:
+		return WinnerDefender
+	case defender == nil:
+		return WinnerAttacker
+	case *attacker == *defender:
+		return WinnerDraw
+	case beats[*attacker] == *defender:
+		return WinnerAttacker
+	default:
+		return WinnerDefender
+	}
+}
+
+// RandomSelection picks a selection for a player that ran out of time.
+func RandomSelection(rng *rand.Rand) Selection {
+	return Selections[rng.Intn(len(Selections))]
+}
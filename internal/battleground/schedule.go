@@ -0,0 +1,193 @@
+// Package battleground holds the rules of the battleground that do not depend on the
+// database, such as how the rounds of a room are scheduled.
+package battleground
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// Pairing decides how teams are paired up for the rounds of a room.
+type Pairing string
+
+const (
+	// PairingRoundRobin has every team meet every other team once per cycle.
+	PairingRoundRobin Pairing = "ROUND_ROBIN"
+	// PairingRandom pairs up random teams, favouring the teams that played the least.
+	PairingRandom Pairing = "RANDOM"
+)
+
+// MaxRounds is the most rounds a room can be scheduled for.
+const MaxRounds = 100
+
+// Match is a round between two teams.
+type Match struct {
+	AttackerTeam string
+	DefenderTeam string
+}
+
+// Round is a match together with the players that represent each team.
+type Round struct {
+	Match
+	Attacker string
+	Defender string
+}
+
+// Plan schedules the rounds of a room and picks the players of every round, the same
+// input and seed always give the same plan.
+func Plan(teams []string, members map[string][]string, pairing Pairing, rounds int, seed int64) ([]Round, error) {
+	rng := rand.New(rand.NewSource(seed))
+
+	matches, err := Schedule(teams, pairing, rounds, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	return AssignPlayers(matches, members, rng)
+}
+
+// Schedule pairs up the teams for the given number of rounds. Round robin plays whole
+// cycles when rounds is zero and swaps the sides on every other cycle, random plays as
+// many rounds as there are teams when rounds is zero.
+func Schedule(teams []string, pairing Pairing, rounds int, rng *rand.Rand) ([]Match, error) {
+	if len(teams) < 2 {
+		return nil, fmt.Errorf("at least 2 teams are needed, got %d", len(teams))
+	}
+	if rounds < 0 || rounds > MaxRounds {
+		return nil, fmt.Errorf("number of rounds must be between 0 and %d", MaxRounds)
+	}
+
+	switch pairing {
+	case PairingRoundRobin:
+		return scheduleRoundRobin(teams, rounds, rng), nil
+	case PairingRandom:
+		if rounds == 0 {
+			rounds = len(teams)
+		}
+		return scheduleRandom(teams, rounds, rng), nil
+	default:
+		return nil, fmt.Errorf("unknown pairing %s", pairing)
+	}
+}
+
+// scheduleRoundRobin uses the circle method so a team rarely plays two rounds in a row.
+func scheduleRoundRobin(teams []string, rounds int, rng *rand.Rand) []Match {
+	circle := shuffled(teams, rng)
+	if len(circle)%2 == 1 {
+		// an empty team is the bye
+		circle = append(circle, "")
+	}
+
+	var cycle []Match
+	n := len(circle)
+	for r := 0; r < n-1; r++ {
+		for i := 0; i < n/2; i++ {
+			a, b := circle[i], circle[n-1-i]
+			if a == "" || b == "" {
+				continue
+			}
+			if (r+i)%2 == 1 {
+				a, b = b, a
+			}
+			cycle = append(cycle, Match{AttackerTeam: a, DefenderTeam: b})
+		}
+		// keep the first team in place and rotate the rest
+		last := circle[n-1]
+		copy(circle[2:], circle[1:n-1])
+		circle[1] = last
+	}
+
+	if rounds == 0 {
+		return cycle
+	}
+	var matches []Match
+	for c := 0; len(matches) < rounds; c++ {
+		for _, match := range cycle {
+			if len(matches) == rounds {
+				break
+			}
+			if c%2 == 1 {
+				match = Match{AttackerTeam: match.DefenderTeam, DefenderTeam: match.AttackerTeam}
+			}
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+func scheduleRandom(teams []string, rounds int, rng *rand.Rand) []Match {
+	played := make(map[string]int)
+	var matches []Match
+	var last Match
+	for len(matches) < rounds {
+		// the teams that played the least go first, ties are broken at random
+		candidates := shuffled(teams, rng)
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return played[candidates[i]] < played[candidates[j]]
+		})
+
+		a, b := candidates[0], candidates[1]
+		// avoid the same pair twice in a row when there is someone else
+		if len(candidates) > 2 && samePair(last, a, b) {
+			b = candidates[2]
+		}
+		if rng.Intn(2) == 1 {
+			a, b = b, a
+		}
+
+		last = Match{AttackerTeam: a, DefenderTeam: b}
+		matches = append(matches, last)
+		played[a]++
+		played[b]++
+	}
+	return matches
+}
+
+// AssignPlayers picks the players of every match, each team takes turns through its
+// members in a random order so everyone gets to play.
+func AssignPlayers(matches []Match, members map[string][]string, rng *rand.Rand) ([]Round, error) {
+	queues := make(map[string][]string)
+	next := make(map[string]int)
+	player := func(team string) (string, error) {
+		queue, ok := queues[team]
+		if !ok {
+			sorted := append([]string{}, members[team]...)
+			sort.Strings(sorted)
+			queue = shuffled(sorted, rng)
+			queues[team] = queue
+		}
+		if len(queue) == 0 {
+			return "", fmt.Errorf("team %s has no members", team)
+		}
+		p := queue[next[team]%len(queue)]
+		next[team]++
+		return p, nil
+	}
+
+	var rounds []Round
+	for _, match := range matches {
+		attacker, err := player(match.AttackerTeam)
+		if err != nil {
+			return nil, err
+		}
+		defender, err := player(match.DefenderTeam)
+		if err != nil {
+			return nil, err
+		}
+		rounds = append(rounds, Round{Match: match, Attacker: attacker, Defender: defender})
+	}
+	return rounds, nil
+}
+
+func shuffled(values []string, rng *rand.Rand) []string {
+	result := append([]string{}, values...)
+	rng.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+	return result
+}
+
+func samePair(match Match, a string, b string) bool {
+	return (match.AttackerTeam == a && match.DefenderTeam == b) || (match.AttackerTeam == b && match.DefenderTeam == a)
+}
@@ -0,0 +1,75 @@
+package battleground
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanIsReproducible(t *testing.T) {
+	members := map[string][]string{
+		"a": {"a1", "a2"},
+		"b": {"b1", "b2", "b3"},
+		"c": {"c1"},
+		"d": {"d1", "d2"},
+		"e": {"e1", "e2"},
+	}
+
+	tests := []struct {
+		name    string
+		teams   []string
+		pairing Pairing
+		rounds  int
+		seed    int64
+		want    int
+	}{
+		{"round robin full cycle", []string{"a", "b", "c", "d"}, PairingRoundRobin, 0, 1, 6},
+		{"round robin odd teams", []string{"a", "b", "c", "d", "e"}, PairingRoundRobin, 0, 2, 10},
+		{"round robin more rounds than a cycle", []string{"a", "b", "c"}, PairingRoundRobin, 7, 3, 7},
+		{"random default rounds", []string{"a", "b", "c", "d", "e"}, PairingRandom, 0, 4, 5},
+		{"random two teams", []string{"a", "b"}, PairingRandom, 9, 5, 9},
+		{"most rounds", []string{"a", "b", "c"}, PairingRandom, MaxRounds, 6, MaxRounds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := Plan(tt.teams, members, tt.pairing, tt.rounds, tt.seed)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			second, err := Plan(tt.teams, members, tt.pairing, tt.rounds, tt.seed)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if !reflect.DeepEqual(first, second) {
+				t.Errorf("Plan() with seed %d gave %v, then %v", tt.seed, first, second)
+			}
+			if len(first) != tt.want {
+				t.Errorf("Plan() gave %d rounds, want %d", len(first), tt.want)
+			}
+			for i, round := range first {
+				if round.AttackerTeam == round.DefenderTeam {
+					t.Errorf("round %d has team %s play itself", i+1, round.AttackerTeam)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRejectsRounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		rounds int
+	}{
+		{"negative", -1},
+		{"over the limit", MaxRounds + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			members := map[string][]string{"a": {"a1"}, "b": {"b1"}}
+			if _, err := Plan([]string{"a", "b"}, members, PairingRoundRobin, tt.rounds, 1); err == nil {
+				t.Errorf("Plan() with %d rounds gave no error", tt.rounds)
+			}
+		})
+	}
+}
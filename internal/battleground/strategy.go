@@ -0,0 +1,77 @@
+package battleground
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Strategy picks the selection of a player, knowing every selection the opponent made
+// before in the room.
+type Strategy interface {
+	Select(opponent []Selection, rng *rand.Rand) Selection
+}
+
+// RandomStrategy picks any selection.
+type RandomStrategy struct{}
+
+func (RandomStrategy) Select(opponent []Selection, rng *rand.Rand) Selection {
+	return RandomSelection(rng)
+}
+
+// CounterStrategy picks the selection that beats the one the opponent made the most,
+// and picks at random until the opponent made one.
+type CounterStrategy struct{}
+
+func (CounterStrategy) Select(opponent []Selection, rng *rand.Rand) Selection {
+	counts := make(map[Selection]int)
+	var favourite Selection
+	for _, selection := range opponent {
+		counts[selection]++
+		if counts[selection] > counts[favourite] {
+			favourite = selection
+		}
+	}
+	if favourite == "" {
+		return RandomSelection(rng)
+	}
+	for selection, beaten := range beats {
+		if beaten == favourite {
+			return selection
+		}
+	}
+	return RandomSelection(rng)
+}
+
+// ScriptedStrategy plays its selections in order and starts over when it runs out.
+type ScriptedStrategy struct {
+	Script []Selection
+	next   int
+}
+
+func (s *ScriptedStrategy) Select(opponent []Selection, rng *rand.Rand) Selection {
+	selection := s.Script[s.next%len(s.Script)]
+	s.next++
+	return selection
+}
+
+// ParseStrategy parses the name of a strategy, that is random, counter or a comma
+// separated script of selections such as KING,WITCH,WITCH.
+func ParseStrategy(name string) (Strategy, error) {
+	switch strings.ToLower(name) {
+	case "random":
+		return RandomStrategy{}, nil
+	case "counter":
+		return CounterStrategy{}, nil
+	}
+
+	var script []Selection
+	for _, value := range strings.Split(name, ",") {
+		selection := Selection(strings.ToUpper(strings.TrimSpace(value)))
+		if _, ok := beats[selection]; !ok {
+			return nil, fmt.Errorf("unknown strategy %s", name)
+		}
+		script = append(script, selection)
+	}
+	return &ScriptedStrategy{Script: script}, nil
+}
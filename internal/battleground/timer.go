@@ -0,0 +1,116 @@
+package battleground
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the time and runs functions later, the real clock is used in production
+// and tests can step a fake one.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f on its own goroutine once d has passed and returns a function
+	// that cancels the call.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+type realClock struct{}
+
+// RealClock returns a clock backed by the time package.
+func RealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// Deadline is the selection deadline of the round being played in a room.
+type Deadline struct {
+	Code     string
+	Round    int
+	Deadline time.Time
+}
+
+type timer struct {
+	deadline Deadline
+	stop     func() bool
+}
+
+// Timers keeps one selection deadline per room and calls a function when it passes.
+type Timers struct {
+	clock  Clock
+	mu     sync.Mutex
+	timers map[string]timer
+}
+
+func NewTimers(clock Clock) *Timers {
+	return &Timers{
+		clock:  clock,
+		timers: make(map[string]timer),
+	}
+}
+
+// Clock returns the clock the timers run on.
+func (t *Timers) Clock() Clock {
+	return t.clock
+}
+
+// Set replaces the deadline of a room, expire is called once the deadline passes unless
+// the deadline is replaced or stopped before that.
+func (t *Timers) Set(deadline Deadline, expire func(Deadline)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if existing, ok := t.timers[deadline.Code]; ok {
+		existing.stop()
+	}
+
+	stop := t.clock.AfterFunc(deadline.Deadline.Sub(t.clock.Now()), func() {
+		t.mu.Lock()
+		current, ok := t.timers[deadline.Code]
+		// a replaced timer may still fire if it was already running
+		if !ok || current.deadline != deadline {
+			t.mu.Unlock()
+			return
+		}
+		delete(t.timers, deadline.Code)
+		t.mu.Unlock()
+
+		expire(deadline)
+	})
+	t.timers[deadline.Code] = timer{deadline: deadline, stop: stop}
+}
+
+// Stop cancels the deadline of a room.
+func (t *Timers) Stop(code string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if existing, ok := t.timers[code]; ok {
+		existing.stop()
+		delete(t.timers, code)
+	}
+}
+
+// Get returns the running deadline of a room.
+func (t *Timers) Get(code string) (Deadline, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	existing, ok := t.timers[code]
+	return existing.deadline, ok
+}
+
+// Remaining returns how long is left until the deadline, never less than zero.
+func (t *Timers) Remaining(deadline Deadline) time.Duration {
+	remaining := deadline.Deadline.Sub(t.clock.Now())
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
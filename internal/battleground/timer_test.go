@@ -0,0 +1,154 @@
+package battleground
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// fakeClock runs the functions that are due when it is advanced, on the goroutine that
+// advances it.
+type fakeClock struct {
+	now     time.Time
+	pending []*fakeTimer
+}
+
+type fakeTimer struct {
+	at      time.Time
+	f       func()
+	stopped bool
+	fired   bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) func() bool {
+	timer := &fakeTimer{at: c.now.Add(d), f: f}
+	c.pending = append(c.pending, timer)
+	return func() bool {
+		stopped := !timer.stopped && !timer.fired
+		timer.stopped = true
+		return stopped
+	}
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.pending, func(i, j int) bool {
+		return c.pending[i].at.Before(c.pending[j].at)
+	})
+	var pending []*fakeTimer
+	for _, timer := range c.pending {
+		switch {
+		case timer.stopped:
+		case timer.at.After(c.now):
+			pending = append(pending, timer)
+		default:
+			timer.fired = true
+			timer.f()
+		}
+	}
+	c.pending = pending
+}
+
+func TestTimersDeadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		run     func(timers *Timers, clock *fakeClock, expire func(Deadline))
+		advance time.Duration
+		want    []int
+	}{
+		{
+			name: "expires at the deadline",
+			run: func(timers *Timers, clock *fakeClock, expire func(Deadline)) {
+				timers.Set(Deadline{Code: "ABCD", Round: 1, Deadline: clock.Now().Add(time.Minute)}, expire)
+			},
+			advance: time.Minute,
+			want:    []int{1},
+		},
+		{
+			name: "does not expire early",
+			run: func(timers *Timers, clock *fakeClock, expire func(Deadline)) {
+				timers.Set(Deadline{Code: "ABCD", Round: 1, Deadline: clock.Now().Add(time.Minute)}, expire)
+			},
+			advance: time.Minute - time.Second,
+		},
+		{
+			name: "replaced deadline does not expire",
+			run: func(timers *Timers, clock *fakeClock, expire func(Deadline)) {
+				timers.Set(Deadline{Code: "ABCD", Round: 1, Deadline: clock.Now().Add(time.Minute)}, expire)
+				timers.Set(Deadline{Code: "ABCD", Round: 2, Deadline: clock.Now().Add(2 * time.Minute)}, expire)
+			},
+			advance: 3 * time.Minute,
+			want:    []int{2},
+		},
+		{
+			name: "stopped deadline does not expire",
+			run: func(timers *Timers, clock *fakeClock, expire func(Deadline)) {
+				timers.Set(Deadline{Code: "ABCD", Round: 1, Deadline: clock.Now().Add(time.Minute)}, expire)
+				timers.Stop("ABCD")
+			},
+			advance: time.Hour,
+		},
+		{
+			name: "passed deadline expires right away",
+			run: func(timers *Timers, clock *fakeClock, expire func(Deadline)) {
+				timers.Set(Deadline{Code: "ABCD", Round: 3, Deadline: clock.Now().Add(-time.Minute)}, expire)
+			},
+			want: []int{3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			timers := NewTimers(clock)
+			var expired []int
+			tt.run(timers, clock, func(deadline Deadline) {
+				expired = append(expired, deadline.Round)
+			})
+			clock.Advance(tt.advance)
+
+			if len(expired) != len(tt.want) {
+				t.Fatalf("expired rounds %v, want %v", expired, tt.want)
+			}
+			for i := range expired {
+				if expired[i] != tt.want[i] {
+					t.Fatalf("expired rounds %v, want %v", expired, tt.want)
+				}
+			}
+			if _, ok := timers.Get("ABCD"); ok && len(tt.want) > 0 {
+				t.Errorf("deadline is still running after it expired")
+			}
+		})
+	}
+}
+
+func TestTimersRemaining(t *testing.T) {
+	clock := newFakeClock()
+	timers := NewTimers(clock)
+	deadline := Deadline{Code: "ABCD", Round: 1, Deadline: clock.Now().Add(time.Minute)}
+
+	tests := []struct {
+		advance time.Duration
+		want    time.Duration
+	}{
+		{0, time.Minute},
+		{20 * time.Second, 40 * time.Second},
+		{40 * time.Second, 0},
+		{time.Minute, 0},
+	}
+
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if got := timers.Remaining(deadline); got != tt.want {
+			t.Errorf("Remaining() at %v = %v, want %v", clock.Now().Sub(deadline.Deadline), got, tt.want)
+		}
+	}
+}
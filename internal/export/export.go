@@ -0,0 +1,131 @@
+// Package export writes tables of data as CSV or XLSX files, one row at a time so an
+// export never has to be held in memory.
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Format is the file format of an export, named after its file extension.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ContentType is the media type a file of the format is served as.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Writer writes the rows of a table, the first row written is the header.
+type Writer interface {
+	Write(row []string) error
+	// Flush sends the rows written so far to the underlying writer.
+	Flush() error
+	// Close finishes the file, nothing can be written after.
+	Close() error
+}
+
+// NewWriter creates a writer of the given format, the sheet name is only used by XLSX.
+func NewWriter(w io.Writer, format Format, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSV(w)
+	case FormatXLSX:
+		return NewXLSX(w, sheet)
+	default:
+		return nil, fmt.Errorf("unknown export format %s", format)
+	}
+}
+
+// csvWriter starts the file with a byte order mark, without it Excel reads the file in
+// the locale encoding and mangles Chinese names.
+type csvWriter struct {
+	out io.Writer
+	w   *csv.Writer
+}
+
+func NewCSV(w io.Writer) (Writer, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &csvWriter{out: w, w: csv.NewWriter(w)}, nil
+}
+
+func (c *csvWriter) Write(row []string) error {
+	escaped := make([]string, len(row))
+	for i, value := range row {
+		escaped[i] = escapeFormula(value)
+	}
+	return c.w.Write(escaped)
+}
+
+// escapeFormula keeps a spreadsheet from running a value as a formula, a value that
+// starts like one is prefixed with a quote. Numbers such as negative points are left as
+// they are. The cells of an XLSX are strings already and never run.
+func escapeFormula(value string) string {
+	if value == "" || !strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return err
+	}
+	flush(c.out)
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// flusher is a writer that buffers, such as an http response.
+type flusher interface {
+	Flush()
+}
+
+func flush(w io.Writer) {
+	if f, ok := w.(flusher); ok {
+		f.Flush()
+	}
+}
+
+// Columns picks the columns of an export from a comma separated list, in the order they
+// are listed. All the available columns are picked when the list is empty.
+func Columns(available []string, requested string) ([]string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return available, nil
+	}
+
+	known := make(map[string]bool)
+	for _, column := range available {
+		known[column] = true
+	}
+	var columns []string
+	for _, column := range strings.Split(requested, ",") {
+		column = strings.TrimSpace(column)
+		if !known[column] {
+			return nil, fmt.Errorf("unknown column %s, the columns are %s", column, strings.Join(available, ", "))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
@@ -0,0 +1,175 @@
+package graphql
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/marcustut/thebox/internal/battleground"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/graphql/query"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// battlegroundSettleRetry is how long to wait before settling a round again when it fails.
+const battlegroundSettleRetry = 5 * time.Second
+
+// syncBattlegroundTimer runs the timer of the round being played in a room and lets the
+// subscribers of the room know about the change.
+func (r *Resolver) syncBattlegroundTimer(ctx context.Context, code string) {
+	defer r.battlegroundHub.Publish(code)
+
+	round, err := query.GetCurrentBattlegroundRound(ctx, r.db, code)
+	if err == postgresql.ErrNotFound {
+		r.battlegroundTimers.Stop(code)
+		return
+	}
+	if err != nil {
+		log.Printf("unable to find the current round of room %s: %v\n", code, err)
+		return
+	}
+
+	r.battlegroundTimers.Set(battleground.Deadline{
+		Code:     code,
+		Round:    round.Round,
+		Deadline: *round.Deadline,
+	}, r.expireBattlegroundRound)
+}
+
+// expireBattlegroundRound settles a round that ran out of time.
+func (r *Resolver) expireBattlegroundRound(deadline battleground.Deadline) {
+	ctx := context.Background()
+
+	now := r.battlegroundTimers.Clock().Now()
+	if _, err := query.SettleBattlegroundRound(ctx, r.db, deadline.Code, deadline.Round, now); err != nil {
+		log.Printf("unable to settle round %d of room %s: %v\n", deadline.Round, deadline.Code, err)
+
+		// try again shortly while the round is still being played
+		current, err := query.GetCurrentBattlegroundRound(ctx, r.db, deadline.Code)
+		if err == nil && current.Round == deadline.Round {
+			retry := deadline
+			retry.Deadline = now.Add(battlegroundSettleRetry)
+			r.battlegroundTimers.Set(retry, r.expireBattlegroundRound)
+			return
+		}
+	}
+
+	r.syncBattlegroundTimer(ctx, deadline.Code)
+}
+
+// RestoreBattlegroundTimers picks up the rounds being played, a server that keeps
+// running calls it once at startup so rounds keep running across restarts.
+func (r *Resolver) RestoreBattlegroundTimers(ctx context.Context) error {
+	rooms, err := r.db.BattlegroundRoom.FindMany(
+		postgresql.BattlegroundRoom.Status.Equals(postgresql.RoomStatusONGOING),
+	).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		r.syncBattlegroundTimer(ctx, room.Code)
+	}
+	return nil
+}
+
+// SettleExpiredBattlegroundRounds settles the rounds past their deadline. It is run on a
+// schedule where the server does not keep running between requests to run the timers.
+func (r *Resolver) SettleExpiredBattlegroundRounds(ctx context.Context) error {
+	codes, err := query.SettleExpiredBattlegroundRounds(ctx, r.db, r.battlegroundTimers.Clock().Now())
+	for _, code := range codes {
+		r.battlegroundHub.Publish(code)
+	}
+	return err
+}
+
+// battlegroundTimer pushes the remaining time of the round being played in a room every
+// second, and right away whenever the room changes.
+func (r *Resolver) battlegroundTimer(ctx context.Context, code string) <-chan *model.BattlegroundTimer {
+	timers := make(chan *model.BattlegroundTimer, 1)
+	changes, unsubscribe := r.battlegroundHub.Subscribe(code)
+	ticks := make(chan struct{}, 1)
+
+	tick := func() func() bool {
+		return r.battlegroundTimers.Clock().AfterFunc(time.Second, func() {
+			select {
+			case ticks <- struct{}{}:
+			default:
+			}
+		})
+	}
+
+	go func() {
+		defer close(timers)
+		defer unsubscribe()
+
+		stop := tick()
+		defer func() { stop() }()
+
+		for {
+			if deadline, ok := r.battlegroundTimers.Get(code); ok {
+				select {
+				case timers <- &model.BattlegroundTimer{
+					Code:      deadline.Code,
+					Round:     deadline.Round,
+					Deadline:  deadline.Deadline,
+					Remaining: int(math.Ceil(r.battlegroundTimers.Remaining(deadline).Seconds())),
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-changes:
+			case <-ticks:
+				stop = tick()
+			}
+		}
+	}()
+
+	return timers
+}
+
+// battlegroundSpectator pushes what the spectators of a room see, right away and then
+// whenever the room changes.
+func (r *Resolver) battlegroundSpectator(ctx context.Context, code string) (<-chan *model.BattlegroundSpectatorView, error) {
+	changes, unsubscribe := r.battlegroundHub.Subscribe(code)
+
+	view, err := query.GetBattlegroundSpectatorView(ctx, r.db, code)
+	if err != nil {
+		unsubscribe()
+		return nil, err
+	}
+
+	views := make(chan *model.BattlegroundSpectatorView, 1)
+	go func() {
+		defer close(views)
+		defer unsubscribe()
+
+		for {
+			if view != nil {
+				select {
+				case views <- view:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-changes:
+			}
+
+			if view, err = query.GetBattlegroundSpectatorView(ctx, r.db, code); err != nil {
+				log.Printf("unable to refresh the spectators of room %s: %v\n", code, err)
+			}
+		}
+	}()
+
+	return views, nil
+}
@@ -0,0 +1,48 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/marcustut/thebox/internal/auth"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/graphql/query"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// errUnauthenticated is returned to requests without a user.
+var errUnauthenticated = errors.New("unauthenticated")
+
+// HasRole implements the @hasRole directive, only users holding at least one of the
+// given roles can resolve the field.
+func (r *Resolver) HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, roles []model.Role) (interface{}, error) {
+	if err := r.hasRole(ctx, roles); err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
+
+// hasRole checks that the user of the request holds at least one of the given roles.
+func (r *Resolver) hasRole(ctx context.Context, roles []model.Role) error {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return errUnauthenticated
+	}
+
+	userRoles, err := query.GetManyRoles(ctx, r.db, postgresql.UserRole.UserID.Equals(userID))
+	if err != nil {
+		return err
+	}
+
+	for _, userRole := range userRoles {
+		for _, role := range roles {
+			if userRole == role {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("requires one of the roles %v", roles)
+}
@@ -0,0 +1,72 @@
+package graphql
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/marcustut/thebox/internal/export"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/graphql/query"
+)
+
+// ExportRoutePrefix is where the exports are served, a file such as users.csv or
+// teams.xlsx is the name of an export followed by its format.
+const ExportRoutePrefix = "/export/"
+
+// ExportFile serves an export to the crew. The columns can be picked and ordered with
+// a comma separated `columns` parameter, all of them are exported without it.
+func (r *Resolver) ExportFile(c echo.Context) error {
+	ctx := c.Request().Context()
+	if err := r.hasRole(ctx, []model.Role{model.RoleCrew}); err != nil {
+		if err == errUnauthenticated {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+		return echo.NewHTTPError(http.StatusForbidden, err.Error())
+	}
+
+	file := c.Param("file")
+	ext := path.Ext(file)
+	table, ok := query.Exports[strings.TrimSuffix(file, ext)]
+	format := export.Format(strings.TrimPrefix(ext, "."))
+	if !ok || (format != export.FormatCSV && format != export.FormatXLSX) {
+		return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("there is no export %s", file))
+	}
+	columns, err := export.Columns(table.Columns, c.QueryParam("columns"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, format.ContentType())
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", file))
+	res.WriteHeader(http.StatusOK)
+	w, err := export.NewWriter(exportResponse{res}, format, table.Name)
+	if err != nil {
+		return err
+	}
+
+	// the status is sent already, a failed export can only be cut short
+	if err := query.StreamExport(ctx, r.db, table, columns, w); err != nil {
+		log.Printf("unable to export %s: %v\n", file, err)
+	}
+	return nil
+}
+
+// exportResponse sends the rows out as they are flushed, when the server supports it.
+type exportResponse struct {
+	res *echo.Response
+}
+
+func (e exportResponse) Write(p []byte) (int, error) {
+	return e.res.Write(p)
+}
+
+func (e exportResponse) Flush() {
+	if f, ok := e.res.Writer.(http.Flusher); ok {
+		f.Flush()
+	}
+}
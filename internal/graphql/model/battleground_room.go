@@ -7,20 +7,72 @@ import (
 )
 
 type BattlegroundRoom struct {
-	Code      string     `json:"code"`
-	TeamIds   []string   `json:"teamIds"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt time.Time  `json:"updatedAt"`
-	Status    RoomStatus `json:"status"`
+	Code             string                    `json:"code"`
+	TeamIds          []string                  `json:"teamIds"`
+	ReadyTeamIds     []string                  `json:"readyTeamIds"`
+	MinTeams         int                       `json:"minTeams"`
+	MaxTeams         int                       `json:"maxTeams"`
+	CreatedAt        time.Time                 `json:"createdAt"`
+	UpdatedAt        time.Time                 `json:"updatedAt"`
+	StartedAt        *time.Time                `json:"startedAt"`
+	EndedAt          *time.Time                `json:"endedAt"`
+	Status           RoomStatus                `json:"status"`
+	Pairing          BattlegroundPairing       `json:"pairing"`
+	Seed             int                       `json:"seed"`
+	RoundCount       *int                      `json:"roundCount"`
+	SelectionTimeout int                       `json:"selectionTimeout"`
+	TimeoutPolicy    BattlegroundTimeoutPolicy `json:"timeoutPolicy"`
+	OpenedEffects    []BattlegroundEffect      `json:"openedEffects"`
+	Practice         bool                      `json:"practice"`
+	BotStrategy      *BattlegroundBotStrategy  `json:"botStrategy"`
+	BotScript        []BattlegroundSelection   `json:"botScript"`
 }
 
 func MapToBattlegroundRoom(dbBattlegroundRoom *postgresql.BattlegroundRoomModel) (*BattlegroundRoom, error) {
+	var startedAt, endedAt *time.Time
+	var roundCount *int
+	if res, ok := dbBattlegroundRoom.StartedAt(); ok {
+		startedAt = &res
+	}
+	if res, ok := dbBattlegroundRoom.EndedAt(); ok {
+		endedAt = &res
+	}
+	if res, ok := dbBattlegroundRoom.RoundCount(); ok {
+		roundCount = &res
+	}
+	openedEffects := []BattlegroundEffect{}
+	for _, effect := range dbBattlegroundRoom.OpenedEffects {
+		openedEffects = append(openedEffects, BattlegroundEffect(effect))
+	}
+	var botStrategy *BattlegroundBotStrategy
+	if res, ok := dbBattlegroundRoom.BotStrategy(); ok {
+		botStrategy = (*BattlegroundBotStrategy)(&res)
+	}
+	botScript := []BattlegroundSelection{}
+	for _, selection := range dbBattlegroundRoom.BotScript {
+		botScript = append(botScript, BattlegroundSelection(selection))
+	}
+
 	battlegroundRoom := &BattlegroundRoom{
-		Code:      dbBattlegroundRoom.Code,
-		TeamIds:   dbBattlegroundRoom.TeamIDs,
-		CreatedAt: dbBattlegroundRoom.CreatedAt,
-		UpdatedAt: dbBattlegroundRoom.UpdatedAt,
-		Status:    RoomStatus(dbBattlegroundRoom.Status),
+		Code:             dbBattlegroundRoom.Code,
+		TeamIds:          dbBattlegroundRoom.TeamIDs,
+		ReadyTeamIds:     dbBattlegroundRoom.ReadyTeamIDs,
+		MinTeams:         dbBattlegroundRoom.MinTeams,
+		MaxTeams:         dbBattlegroundRoom.MaxTeams,
+		CreatedAt:        dbBattlegroundRoom.CreatedAt,
+		UpdatedAt:        dbBattlegroundRoom.UpdatedAt,
+		StartedAt:        startedAt,
+		EndedAt:          endedAt,
+		Status:           RoomStatus(dbBattlegroundRoom.Status),
+		Pairing:          BattlegroundPairing(dbBattlegroundRoom.Pairing),
+		Seed:             dbBattlegroundRoom.Seed,
+		RoundCount:       roundCount,
+		SelectionTimeout: dbBattlegroundRoom.SelectionTimeout,
+		TimeoutPolicy:    BattlegroundTimeoutPolicy(dbBattlegroundRoom.TimeoutPolicy),
+		OpenedEffects:    openedEffects,
+		Practice:         dbBattlegroundRoom.Practice,
+		BotStrategy:      botStrategy,
+		BotScript:        botScript,
 	}
 
 	return battlegroundRoom, nil
@@ -37,3 +89,162 @@ func MapToBattlegroundRooms(dbBattlegroundRooms []postgresql.BattlegroundRoomMod
 	}
 	return battlegroundRooms, nil
 }
+
+type BattlegroundRound struct {
+	Code                 string                 `json:"code"`
+	Round                int                    `json:"round"`
+	Attacker             *string                `json:"attacker"`
+	Defender             *string                `json:"defender"`
+	AttackerTeamID       *string                `json:"attackerTeam"`
+	DefenderTeamID       *string                `json:"defenderTeam"`
+	AttackerSelection    *BattlegroundSelection `json:"attackerSelection"`
+	DefenderSelection    *BattlegroundSelection `json:"defenderSelection"`
+	AttackerLocked       bool                   `json:"attackerLocked"`
+	DefenderLocked       bool                   `json:"defenderLocked"`
+	AttackerPowercard    *Powercard             `json:"attackerPowercard"`
+	DefenderPowercard    *Powercard             `json:"defenderPowercard"`
+	Effect               *BattlegroundEffect    `json:"effect"`
+	Deadline             *time.Time             `json:"deadline"`
+	Winner               *BattlegroundWinner    `json:"winner"`
+	AttackerTimedOut     bool                   `json:"attackerTimedOut"`
+	DefenderTimedOut     bool                   `json:"defenderTimedOut"`
+	SettledAt            *time.Time             `json:"settledAt"`
+	AttackerPointsBefore *float64               `json:"attackerPointsBefore"`
+	AttackerPointsAfter  *float64               `json:"attackerPointsAfter"`
+	DefenderPointsBefore *float64               `json:"defenderPointsBefore"`
+	DefenderPointsAfter  *float64               `json:"defenderPointsAfter"`
+	CreatedAt            time.Time              `json:"createdAt"`
+	UpdatedAt            time.Time              `json:"updatedAt"`
+}
+
+func MapToBattlegroundRound(dbBattlegroundRound *postgresql.BattlegroundRoundModel) (*BattlegroundRound, error) {
+	battlegroundRound := &BattlegroundRound{
+		Code:             dbBattlegroundRound.Code,
+		Round:            dbBattlegroundRound.Round,
+		AttackerTimedOut: dbBattlegroundRound.AttackerTimedOut,
+		DefenderTimedOut: dbBattlegroundRound.DefenderTimedOut,
+		CreatedAt:        dbBattlegroundRound.CreatedAt,
+		UpdatedAt:        dbBattlegroundRound.UpdatedAt,
+	}
+	if res, ok := dbBattlegroundRound.Attacker(); ok {
+		battlegroundRound.Attacker = &res
+	}
+	if res, ok := dbBattlegroundRound.Defender(); ok {
+		battlegroundRound.Defender = &res
+	}
+	if res, ok := dbBattlegroundRound.AttackerTeamID(); ok {
+		battlegroundRound.AttackerTeamID = &res
+	}
+	if res, ok := dbBattlegroundRound.DefenderTeamID(); ok {
+		battlegroundRound.DefenderTeamID = &res
+	}
+	// the selections of a round stay hidden until the round is settled, until then
+	// only whether each side has locked in is shown
+	_, settled := dbBattlegroundRound.SettledAt()
+	if res, ok := dbBattlegroundRound.AttackerSelection(); ok {
+		if settled {
+			battlegroundRound.AttackerSelection = (*BattlegroundSelection)(&res)
+		}
+		battlegroundRound.AttackerLocked = true
+	}
+	if res, ok := dbBattlegroundRound.DefenderSelection(); ok {
+		if settled {
+			battlegroundRound.DefenderSelection = (*BattlegroundSelection)(&res)
+		}
+		battlegroundRound.DefenderLocked = true
+	}
+	if res, ok := dbBattlegroundRound.AttackerPowercard(); ok {
+		battlegroundRound.AttackerPowercard = (*Powercard)(&res)
+	}
+	if res, ok := dbBattlegroundRound.DefenderPowercard(); ok {
+		battlegroundRound.DefenderPowercard = (*Powercard)(&res)
+	}
+	if res, ok := dbBattlegroundRound.Effect(); ok {
+		battlegroundRound.Effect = (*BattlegroundEffect)(&res)
+	}
+	if res, ok := dbBattlegroundRound.Deadline(); ok {
+		battlegroundRound.Deadline = &res
+	}
+	if res, ok := dbBattlegroundRound.Winner(); ok {
+		battlegroundRound.Winner = (*BattlegroundWinner)(&res)
+	}
+	if res, ok := dbBattlegroundRound.SettledAt(); ok {
+		battlegroundRound.SettledAt = &res
+	}
+	if res, ok := dbBattlegroundRound.AttackerPointsBefore(); ok {
+		battlegroundRound.AttackerPointsBefore = &res
+	}
+	if res, ok := dbBattlegroundRound.AttackerPointsAfter(); ok {
+		battlegroundRound.AttackerPointsAfter = &res
+	}
+	if res, ok := dbBattlegroundRound.DefenderPointsBefore(); ok {
+		battlegroundRound.DefenderPointsBefore = &res
+	}
+	if res, ok := dbBattlegroundRound.DefenderPointsAfter(); ok {
+		battlegroundRound.DefenderPointsAfter = &res
+	}
+
+	return battlegroundRound, nil
+}
+
+func MapToBattlegroundRounds(dbBattlegroundRounds []postgresql.BattlegroundRoundModel) ([]*BattlegroundRound, error) {
+	var battlegroundRounds []*BattlegroundRound
+	for _, dbBattlegroundRound := range dbBattlegroundRounds {
+		battlegroundRound, err := MapToBattlegroundRound(&dbBattlegroundRound)
+		if err != nil {
+			return nil, err
+		}
+		battlegroundRounds = append(battlegroundRounds, battlegroundRound)
+	}
+	return battlegroundRounds, nil
+}
+
+type BattlegroundActivity struct {
+	ID        string                   `json:"id"`
+	Code      string                   `json:"code"`
+	Round     *int                     `json:"round"`
+	Type      BattlegroundActivityType `json:"type"`
+	TeamID    *string                  `json:"team"`
+	Message   string                   `json:"message"`
+	CreatedAt time.Time                `json:"createdAt"`
+}
+
+func MapToBattlegroundActivity(dbBattlegroundActivity *postgresql.BattlegroundActivityModel) (*BattlegroundActivity, error) {
+	battlegroundActivity := &BattlegroundActivity{
+		ID:        dbBattlegroundActivity.ID,
+		Code:      dbBattlegroundActivity.Code,
+		Type:      BattlegroundActivityType(dbBattlegroundActivity.Type),
+		Message:   dbBattlegroundActivity.Message,
+		CreatedAt: dbBattlegroundActivity.CreatedAt,
+	}
+	if res, ok := dbBattlegroundActivity.Round(); ok {
+		battlegroundActivity.Round = &res
+	}
+	if res, ok := dbBattlegroundActivity.TeamID(); ok {
+		battlegroundActivity.TeamID = &res
+	}
+
+	return battlegroundActivity, nil
+}
+
+func MapToBattlegroundActivities(dbBattlegroundActivities []postgresql.BattlegroundActivityModel) ([]*BattlegroundActivity, error) {
+	var battlegroundActivities []*BattlegroundActivity
+	for _, dbBattlegroundActivity := range dbBattlegroundActivities {
+		battlegroundActivity, err := MapToBattlegroundActivity(&dbBattlegroundActivity)
+		if err != nil {
+			return nil, err
+		}
+		battlegroundActivities = append(battlegroundActivities, battlegroundActivity)
+	}
+	return battlegroundActivities, nil
+}
+
+type BattlegroundStanding struct {
+	Rank   int     `json:"rank"`
+	TeamID string  `json:"team"`
+	Points float64 `json:"points"`
+	Change float64 `json:"change"`
+	Wins   int     `json:"wins"`
+	Losses int     `json:"losses"`
+	Draws  int     `json:"draws"`
+}
@@ -7,24 +7,44 @@ import (
 )
 
 type Discovery struct {
-	ID          string     `json:"id"`
-	VideoURL    *string    `json:"videoUrl"`
-	TeamID      string     `json:"team"`
-	MissionID   string     `json:"mission"`
-	SubmittedAt *time.Time `json:"submittedAt"`
-	CreatedAt   time.Time  `json:"createdAt"`
-	UpdatedAt   time.Time  `json:"updatedAt"`
+	ID          string           `json:"id"`
+	VideoURL    *string          `json:"videoUrl"`
+	TeamID      string           `json:"team"`
+	MissionID   string           `json:"mission"`
+	SubmittedAt *time.Time       `json:"submittedAt"`
+	CreatedAt   time.Time        `json:"createdAt"`
+	UpdatedAt   time.Time        `json:"updatedAt"`
+	Status      *DiscoveryStatus `json:"status"`
+	Revision    int              `json:"revision"`
+}
+
+type DiscoveryRevision struct {
+	ID           string          `json:"id"`
+	Revision     int             `json:"revision"`
+	VideoURL     string          `json:"videoUrl"`
+	SubmittedAt  time.Time       `json:"submittedAt"`
+	Status       DiscoveryStatus `json:"status"`
+	Feedback     *string         `json:"feedback"`
+	ReviewerID   *string         `json:"reviewer"`
+	ReviewedAt   *time.Time      `json:"reviewedAt"`
+	SupersededAt *time.Time      `json:"supersededAt"`
+	Scores       []*RubricScore  `json:"scores"`
+	Total        *float64        `json:"total"`
 }
 
 func MapToDiscovery(dbDiscovery *postgresql.DiscoveryModel) (*Discovery, error) {
 	var videoUrl *string
 	var submittedAt *time.Time
+	var status *DiscoveryStatus
 	if res, ok := dbDiscovery.VideoURL(); ok {
 		videoUrl = &res
 	}
 	if res, ok := dbDiscovery.SubmittedAt(); ok {
 		submittedAt = &res
 	}
+	if res, ok := dbDiscovery.Status(); ok {
+		status = (*DiscoveryStatus)(&res)
+	}
 
 	discovery := &Discovery{
 		ID:          dbDiscovery.ID,
@@ -34,6 +54,8 @@ func MapToDiscovery(dbDiscovery *postgresql.DiscoveryModel) (*Discovery, error)
 		UpdatedAt:   dbDiscovery.UpdatedAt,
 		TeamID:      dbDiscovery.TeamID,
 		MissionID:   dbDiscovery.MissionID,
+		Status:      status,
+		Revision:    dbDiscovery.Revision,
 	}
 
 	return discovery, nil
@@ -50,3 +72,63 @@ func MapToDiscoveries(dbDiscoveries []postgresql.DiscoveryModel) ([]*Discovery,
 	}
 	return discoveries, nil
 }
+
+// MapToDiscoveryRevision expects the scores of the revision to be fetched along with it.
+func MapToDiscoveryRevision(dbRevision *postgresql.DiscoveryRevisionModel) (*DiscoveryRevision, error) {
+	var feedback *string
+	var reviewerID *string
+	var reviewedAt *time.Time
+	var supersededAt *time.Time
+	if res, ok := dbRevision.Feedback(); ok {
+		feedback = &res
+	}
+	if res, ok := dbRevision.ReviewerID(); ok {
+		reviewerID = &res
+	}
+	if res, ok := dbRevision.ReviewedAt(); ok {
+		reviewedAt = &res
+	}
+	if res, ok := dbRevision.SupersededAt(); ok {
+		supersededAt = &res
+	}
+
+	revision := &DiscoveryRevision{
+		ID:           dbRevision.ID,
+		Revision:     dbRevision.Revision,
+		VideoURL:     dbRevision.VideoURL,
+		SubmittedAt:  dbRevision.SubmittedAt,
+		Status:       DiscoveryStatus(dbRevision.Status),
+		Feedback:     feedback,
+		ReviewerID:   reviewerID,
+		ReviewedAt:   reviewedAt,
+		SupersededAt: supersededAt,
+		Scores:       []*RubricScore{},
+	}
+	for _, dbScore := range dbRevision.Scores() {
+		revision.Scores = append(revision.Scores, &RubricScore{
+			Criterion: dbScore.Criterion,
+			Score:     dbScore.Score,
+		})
+	}
+	if len(revision.Scores) > 0 {
+		var total float64
+		for _, score := range revision.Scores {
+			total += score.Score
+		}
+		revision.Total = &total
+	}
+
+	return revision, nil
+}
+
+func MapToDiscoveryRevisions(dbRevisions []postgresql.DiscoveryRevisionModel) ([]*DiscoveryRevision, error) {
+	var revisions []*DiscoveryRevision
+	for _, dbRevision := range dbRevisions {
+		revision, err := MapToDiscoveryRevision(&dbRevision)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, nil
+}
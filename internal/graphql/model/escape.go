@@ -33,3 +33,43 @@ func MapToEscapes(dbEscapes []postgresql.EscapeModel) ([]*Escape, error) {
 	}
 	return escapes, nil
 }
+
+type EscapeStage struct {
+	ID        string  `json:"id"`
+	Position  int     `json:"position"`
+	Title     string  `json:"title"`
+	Points    float64 `json:"points"`
+	MissionID string  `json:"mission"`
+}
+
+func MapToEscapeStage(dbEscapeStage *postgresql.EscapeStageModel) (*EscapeStage, error) {
+	escapeStage := &EscapeStage{
+		ID:        dbEscapeStage.ID,
+		Position:  dbEscapeStage.Position,
+		Title:     dbEscapeStage.Title,
+		Points:    dbEscapeStage.Points,
+		MissionID: dbEscapeStage.MissionID,
+	}
+
+	return escapeStage, nil
+}
+
+func MapToEscapeStages(dbEscapeStages []postgresql.EscapeStageModel) ([]*EscapeStage, error) {
+	var escapeStages []*EscapeStage
+	for _, dbEscapeStage := range dbEscapeStages {
+		escapeStage, err := MapToEscapeStage(&dbEscapeStage)
+		if err != nil {
+			return nil, err
+		}
+		escapeStages = append(escapeStages, escapeStage)
+	}
+	return escapeStages, nil
+}
+
+type EscapeProgress struct {
+	TeamID    string                 `json:"team"`
+	MissionID string                 `json:"mission"`
+	Stages    []*EscapeStageProgress `json:"stages"`
+	Points    float64                `json:"points"`
+	Completed bool                   `json:"completed"`
+}
@@ -18,16 +18,32 @@ type Humanity struct {
 	CreatedAt   time.Time  `json:"createdAt"`
 	UpdatedAt   time.Time  `json:"updatedAt"`
 	SubmittedAt *time.Time `json:"submittedAt"`
+	PublishedAt *time.Time `json:"publishedAt"`
+}
+
+// Photos returns the photos that have been submitted in slot order.
+func (h *Humanity) Photos() []string {
+	var photos []string
+	for _, photo := range []*string{h.Photo1, h.Photo2, h.Photo3} {
+		if photo != nil {
+			photos = append(photos, *photo)
+		}
+	}
+	return photos
 }
 
 func MapToHumanity(dbHumanity *postgresql.HumanityModel) (*Humanity, error) {
 	var submittedAt *time.Time
+	var publishedAt *time.Time
 	var photo1 *string
 	var photo2 *string
 	var photo3 *string
 	if res, ok := dbHumanity.SubmittedAt(); ok {
 		submittedAt = &res
 	}
+	if res, ok := dbHumanity.PublishedAt(); ok {
+		publishedAt = &res
+	}
 	if res, ok := dbHumanity.Photo1(); ok {
 		photo1 = &res
 	}
@@ -48,6 +64,7 @@ func MapToHumanity(dbHumanity *postgresql.HumanityModel) (*Humanity, error) {
 		CreatedAt:   dbHumanity.CreatedAt,
 		UpdatedAt:   dbHumanity.UpdatedAt,
 		SubmittedAt: submittedAt,
+		PublishedAt: publishedAt,
 		TeamID:      dbHumanity.TeamID,
 		MissionID:   dbHumanity.MissionID,
 	}
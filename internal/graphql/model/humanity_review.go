@@ -0,0 +1,54 @@
+package model
+
+import (
+	"time"
+
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+type HumanityReview struct {
+	ID          string                `json:"id"`
+	HumanityID  string                `json:"humanity"`
+	JudgeID     string                `json:"judge"`
+	Scores      []*HumanityPhotoScore `json:"scores"`
+	Total       float64               `json:"total"`
+	CreatedAt   time.Time             `json:"createdAt"`
+	CompletedAt *time.Time            `json:"completedAt"`
+}
+
+// MapToHumanityReview expects the scores of the assignment to be fetched along with it.
+func MapToHumanityReview(dbAssignment *postgresql.HumanityAssignmentModel) (*HumanityReview, error) {
+	var completedAt *time.Time
+	if res, ok := dbAssignment.CompletedAt(); ok {
+		completedAt = &res
+	}
+	review := &HumanityReview{
+		ID:          dbAssignment.ID,
+		HumanityID:  dbAssignment.HumanityID,
+		JudgeID:     dbAssignment.JudgeID,
+		Scores:      []*HumanityPhotoScore{},
+		CreatedAt:   dbAssignment.CreatedAt,
+		CompletedAt: completedAt,
+	}
+	for _, dbScore := range dbAssignment.Scores() {
+		review.Scores = append(review.Scores, &HumanityPhotoScore{
+			Photo:     dbScore.Photo,
+			Criterion: dbScore.Criterion,
+			Score:     dbScore.Score,
+		})
+		review.Total += dbScore.Score
+	}
+	return review, nil
+}
+
+func MapToHumanityReviews(dbAssignments []postgresql.HumanityAssignmentModel) ([]*HumanityReview, error) {
+	var reviews []*HumanityReview
+	for _, dbAssignment := range dbAssignments {
+		review, err := MapToHumanityReview(&dbAssignment)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, nil
+}
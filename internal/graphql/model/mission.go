@@ -7,34 +7,46 @@ import (
 )
 
 type Mission struct {
-	ID             string    `json:"id" fake:"{uuid}"`
-	Title          string    `json:"title" fake:"{appname}"`
-	Slug           string    `json:"slug" fake:"{appname}"`
-	Description    *string   `json:"description" fake:"{sentence:12}"`
-	Points         float64   `json:"points" fake:"{float64:10,100}"`
-	CreatedAt      time.Time `json:"createdAt" fake:"{date}"`
-	UpdatedAt      time.Time `json:"updatedAt" fake:"{date}"`
-	StartAt        time.Time `json:"startAt" fake:"{date}"`
-	EndAt          time.Time `json:"endAt" fake:"{date}"`
-	CompletedByIDs *[]string `json:"completedBy" fake:"skip"`
+	ID              string    `json:"id" fake:"{uuid}"`
+	Title           string    `json:"title" fake:"{appname}"`
+	Slug            string    `json:"slug" fake:"{appname}"`
+	Description     *string   `json:"description" fake:"{sentence:12}"`
+	Points          float64   `json:"points" fake:"{float64:10,100}"`
+	CreatedAt       time.Time `json:"createdAt" fake:"{date}"`
+	UpdatedAt       time.Time `json:"updatedAt" fake:"{date}"`
+	StartAt         time.Time `json:"startAt" fake:"{date}"`
+	EndAt           time.Time `json:"endAt" fake:"{date}"`
+	MaxAttempts     *int      `json:"maxAttempts" fake:"skip"`
+	AttemptCooldown *int      `json:"attemptCooldown" fake:"skip"`
+	CompletedByIDs  *[]string `json:"completedBy" fake:"skip"`
 }
 
 func MapToMission(dbMission *postgresql.MissionModel) (*Mission, error) {
 	var description *string
+	var maxAttempts *int
+	var attemptCooldown *int
 	if res, ok := dbMission.Description(); ok {
 		description = &res
 	}
+	if res, ok := dbMission.MaxAttempts(); ok {
+		maxAttempts = &res
+	}
+	if res, ok := dbMission.AttemptCooldown(); ok {
+		attemptCooldown = &res
+	}
 
 	mission := &Mission{
-		ID:          dbMission.ID,
-		Title:       dbMission.Title,
-		Slug:        dbMission.Slug,
-		Description: description,
-		Points:      dbMission.Points,
-		CreatedAt:   dbMission.CreatedAt,
-		UpdatedAt:   dbMission.UpdatedAt,
-		StartAt:     dbMission.StartAt,
-		EndAt:       dbMission.EndAt,
+		ID:              dbMission.ID,
+		Title:           dbMission.Title,
+		Slug:            dbMission.Slug,
+		Description:     description,
+		Points:          dbMission.Points,
+		CreatedAt:       dbMission.CreatedAt,
+		UpdatedAt:       dbMission.UpdatedAt,
+		StartAt:         dbMission.StartAt,
+		EndAt:           dbMission.EndAt,
+		MaxAttempts:     maxAttempts,
+		AttemptCooldown: attemptCooldown,
 	}
 
 	return mission, nil
@@ -7,20 +7,38 @@ import (
 	"io"
 	"strconv"
 	"time"
+
+	"github.com/99designs/gqlgen/graphql"
 )
 
-type BattlegroundRound struct {
-	Code              string                 `json:"code"`
-	Round             int                    `json:"round"`
-	Attacker          *User                  `json:"attacker"`
-	Defender          *User                  `json:"defender"`
-	AttackerSelection *BattlegroundSelection `json:"attackerSelection"`
-	DefenderSelection *BattlegroundSelection `json:"defenderSelection"`
-	AttackerPowercard *Powercard             `json:"attackerPowercard"`
-	DefenderPowercard *Powercard             `json:"defenderPowercard"`
-	Effect            *BattlegroundEffect    `json:"effect"`
-	CreatedAt         time.Time              `json:"createdAt"`
-	UpdatedAt         time.Time              `json:"updatedAt"`
+type AcceptDiscoveryInput struct {
+	DiscoveryID string              `json:"discoveryId"`
+	Scores      []*RubricScoreInput `json:"scores"`
+	Feedback    *string             `json:"feedback"`
+}
+
+type BattlegroundReplay struct {
+	Room   *BattlegroundRoom    `json:"room"`
+	Rounds []*BattlegroundRound `json:"rounds"`
+}
+
+type BattlegroundSpectatorView struct {
+	Code          string                  `json:"code"`
+	Status        RoomStatus              `json:"status"`
+	StartedAt     *time.Time              `json:"startedAt"`
+	EndedAt       *time.Time              `json:"endedAt"`
+	OpenedEffects []BattlegroundEffect    `json:"openedEffects"`
+	CurrentRound  *BattlegroundRound      `json:"currentRound"`
+	Rounds        []*BattlegroundRound    `json:"rounds"`
+	Standings     []*BattlegroundStanding `json:"standings"`
+	Activity      []*BattlegroundActivity `json:"activity"`
+}
+
+type BattlegroundTimer struct {
+	Code      string    `json:"code"`
+	Round     int       `json:"round"`
+	Deadline  time.Time `json:"deadline"`
+	Remaining int       `json:"remaining"`
 }
 
 type CommentLikeInput struct {
@@ -28,6 +46,77 @@ type CommentLikeInput struct {
 	UserID    string `json:"userId"`
 }
 
+type CursorPaginationInput struct {
+	After *string `json:"after"`
+	Limit int     `json:"limit"`
+}
+
+type EscapeAnswerResult struct {
+	Correct  bool            `json:"correct"`
+	Progress *EscapeProgress `json:"progress"`
+}
+
+type EscapeStageInput struct {
+	Title  string  `json:"title"`
+	Answer string  `json:"answer"`
+	Points float64 `json:"points"`
+}
+
+type EscapeStageProgress struct {
+	Stage     *EscapeStage `json:"stage"`
+	SolvedAt  *time.Time   `json:"solvedAt"`
+	SolveTime *int         `json:"solveTime"`
+}
+
+type FormTeamsInput struct {
+	Size               int     `json:"size"`
+	Seed               int     `json:"seed"`
+	NamePrefix         string  `json:"namePrefix"`
+	DistributeClusters bool    `json:"distributeClusters"`
+	Checksum           *string `json:"checksum"`
+}
+
+type FormedTeam struct {
+	Name    string   `json:"name"`
+	Team    *Team    `json:"team"`
+	Cluster *Cluster `json:"cluster"`
+	Leader  *User    `json:"leader"`
+	Members []*User  `json:"members"`
+}
+
+type GrantPowercardInput struct {
+	TeamID    string    `json:"teamId"`
+	Powercard Powercard `json:"powercard"`
+	Reason    string    `json:"reason"`
+	Source    *string   `json:"source"`
+	Reference *string   `json:"reference"`
+}
+
+type HumanityPhotoScore struct {
+	Photo     int     `json:"photo"`
+	Criterion string  `json:"criterion"`
+	Score     float64 `json:"score"`
+}
+
+type HumanityPhotoScoreInput struct {
+	Photo     int     `json:"photo"`
+	Criterion string  `json:"criterion"`
+	Score     float64 `json:"score"`
+}
+
+type ImportRowError struct {
+	Row     int     `json:"row"`
+	Column  *string `json:"column"`
+	Message string  `json:"message"`
+}
+
+type Media struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+}
+
 type NewAddress struct {
 	City       string  `json:"city"`
 	Line1      string  `json:"line1"`
@@ -38,7 +127,14 @@ type NewAddress struct {
 }
 
 type NewBattlegroundRoom struct {
-	TeamIds []string `json:"teamIds"`
+	TeamIds          []string                   `json:"teamIds"`
+	MinTeams         *int                       `json:"minTeams"`
+	MaxTeams         *int                       `json:"maxTeams"`
+	Pairing          *BattlegroundPairing       `json:"pairing"`
+	Seed             *int                       `json:"seed"`
+	RoundCount       *int                       `json:"roundCount"`
+	SelectionTimeout *int                       `json:"selectionTimeout"`
+	TimeoutPolicy    *BattlegroundTimeoutPolicy `json:"timeoutPolicy"`
 }
 
 type NewComment struct {
@@ -59,6 +155,14 @@ type NewPost struct {
 	UserID  string   `json:"userId"`
 }
 
+type NewPracticeBattleground struct {
+	Strategy         BattlegroundBotStrategy `json:"strategy"`
+	Script           []BattlegroundSelection `json:"script"`
+	Seed             *int                    `json:"seed"`
+	RoundCount       *int                    `json:"roundCount"`
+	SelectionTimeout *int                    `json:"selectionTimeout"`
+}
+
 type NewProfile struct {
 	Status        *PastoralStatus `json:"status"`
 	Gender        Gender          `json:"gender"`
@@ -79,6 +183,14 @@ type NewTeam struct {
 	ClusterID *string `json:"clusterId"`
 }
 
+type NewTournament struct {
+	Name           string           `json:"name"`
+	Format         TournamentFormat `json:"format"`
+	TeamIds        []string         `json:"teamIds"`
+	RoundCount     *int             `json:"roundCount"`
+	RoomRoundCount *int             `json:"roomRoundCount"`
+}
+
 type NewUser struct {
 	ID       *string     `json:"id"`
 	Username string      `json:"username"`
@@ -93,11 +205,135 @@ type PaginationInput struct {
 	Limit  int `json:"limit"`
 }
 
+type PostFeed struct {
+	Posts      []*Post `json:"posts"`
+	NextCursor *string `json:"nextCursor"`
+	HasMore    bool    `json:"hasMore"`
+}
+
 type PostLikeInput struct {
 	PostID string `json:"postId"`
 	UserID string `json:"userId"`
 }
 
+type RegistrationCount struct {
+	Key   *string `json:"key"`
+	Count int     `json:"count"`
+}
+
+type RegistrationDay struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+type RegistrationStats struct {
+	Total       int                  `json:"total"`
+	BySatellite []*RegistrationCount `json:"bySatellite"`
+	ByGender    []*RegistrationCount `json:"byGender"`
+	ByStatus    []*RegistrationCount `json:"byStatus"`
+	ByAgeBand   []*RegistrationCount `json:"byAgeBand"`
+	PerDay      []*RegistrationDay   `json:"perDay"`
+	TeamsByFill []*TeamFillCount     `json:"teamsByFill"`
+}
+
+type RevokePowercardInput struct {
+	TeamID    string    `json:"teamId"`
+	Powercard Powercard `json:"powercard"`
+	Reason    string    `json:"reason"`
+}
+
+type RubricCriterion struct {
+	Key         string  `json:"key"`
+	Description string  `json:"description"`
+	Max         float64 `json:"max"`
+}
+
+type RubricScore struct {
+	Criterion string  `json:"criterion"`
+	Score     float64 `json:"score"`
+}
+
+type RubricScoreInput struct {
+	Criterion string  `json:"criterion"`
+	Score     float64 `json:"score"`
+}
+
+type ScoreHumanityInput struct {
+	HumanityID string                     `json:"humanityId"`
+	Scores     []*HumanityPhotoScoreInput `json:"scores"`
+}
+
+type SetEscapeStagesInput struct {
+	MissionID string              `json:"missionId"`
+	Stages    []*EscapeStageInput `json:"stages"`
+}
+
+type SetSpeedAnswersInput struct {
+	MissionID       string   `json:"missionId"`
+	Answers         []string `json:"answers"`
+	MaxAttempts     *int     `json:"maxAttempts"`
+	AttemptCooldown *int     `json:"attemptCooldown"`
+}
+
+type SetSpeedAwardRulesInput struct {
+	MissionID string                 `json:"missionId"`
+	Rules     []*SpeedAwardRuleInput `json:"rules"`
+}
+
+type SpeedAnswerResult struct {
+	Correct      bool       `json:"correct"`
+	Speed        *Speed     `json:"speed"`
+	AttemptsLeft *int       `json:"attemptsLeft"`
+	RetryAt      *time.Time `json:"retryAt"`
+}
+
+type SpeedAwardRuleInput struct {
+	Top       *int       `json:"top"`
+	Before    *time.Time `json:"before"`
+	Points    float64    `json:"points"`
+	Powercard *Powercard `json:"powercard"`
+}
+
+type SubmitDiscoveryInput struct {
+	TeamID    string `json:"teamId"`
+	MissionID string `json:"missionId"`
+	VideoURL  string `json:"videoUrl"`
+}
+
+type SubmitEscapeAnswerInput struct {
+	TeamID  string `json:"teamId"`
+	StageID string `json:"stageId"`
+	Answer  string `json:"answer"`
+}
+
+type SubmitSpeedAnswerInput struct {
+	TeamID    string `json:"teamId"`
+	MissionID string `json:"missionId"`
+	Answer    string `json:"answer"`
+}
+
+type TeamFillCount struct {
+	Members int `json:"members"`
+	Teams   int `json:"teams"`
+}
+
+type TeamFormation struct {
+	Committed bool          `json:"committed"`
+	Checksum  string        `json:"checksum"`
+	Teams     []*FormedTeam `json:"teams"`
+	Warnings  []string      `json:"warnings"`
+}
+
+type TournamentBracket struct {
+	Tournament *Tournament        `json:"tournament"`
+	Rounds     []*TournamentRound `json:"rounds"`
+}
+
+type TournamentRound struct {
+	Round   int                `json:"round"`
+	Matches []*TournamentMatch `json:"matches"`
+}
+
 type UpdateBattlegroundRoomInput struct {
 	TeamIds []string    `json:"teamIds"`
 	Status  *RoomStatus `json:"status"`
@@ -111,10 +347,9 @@ type UpdateProfileInput struct {
 }
 
 type UpdateTeamInput struct {
-	Name      *string    `json:"name"`
-	AvatarURL *string    `json:"avatarUrl"`
-	Points    *float64   `json:"points"`
-	Powercard *Powercard `json:"powercard"`
+	Name      *string  `json:"name"`
+	AvatarURL *string  `json:"avatarUrl"`
+	Points    *float64 `json:"points"`
 }
 
 type UpdateUserInput struct {
@@ -122,11 +357,17 @@ type UpdateUserInput struct {
 	Profile *UpdateProfileInput `json:"profile"`
 }
 
+type UploadMediaInput struct {
+	Target   UploadTarget   `json:"target"`
+	TargetID string         `json:"targetId"`
+	File     graphql.Upload `json:"file"`
+	Slot     *int           `json:"slot"`
+}
+
 type UpsertDiscoveryInput struct {
-	TeamID      string     `json:"teamId"`
-	MissionID   string     `json:"missionId"`
-	VideoURL    *string    `json:"videoUrl"`
-	SubmittedAt *time.Time `json:"submittedAt"`
+	TeamID    string  `json:"teamId"`
+	MissionID string  `json:"missionId"`
+	VideoURL  *string `json:"videoUrl"`
 }
 
 type UpsertEscapeInput struct {
@@ -148,10 +389,115 @@ type UpsertHumanityInput struct {
 }
 
 type UpsertSpeedInput struct {
-	TeamID      string     `json:"teamId"`
-	MissionID   string     `json:"missionId"`
-	CompletedAt *time.Time `json:"completedAt"`
-	Answer      *string    `json:"answer"`
+	TeamID    string `json:"teamId"`
+	MissionID string `json:"missionId"`
+}
+
+type UserImportResult struct {
+	DryRun  bool              `json:"dryRun"`
+	Rows    int               `json:"rows"`
+	Created int               `json:"created"`
+	Errors  []*ImportRowError `json:"errors"`
+}
+
+type BattlegroundActivityType string
+
+const (
+	BattlegroundActivityTypeJoined    BattlegroundActivityType = "JOINED"
+	BattlegroundActivityTypeLeft      BattlegroundActivityType = "LEFT"
+	BattlegroundActivityTypeReady     BattlegroundActivityType = "READY"
+	BattlegroundActivityTypeStarted   BattlegroundActivityType = "STARTED"
+	BattlegroundActivityTypeLocked    BattlegroundActivityType = "LOCKED"
+	BattlegroundActivityTypeTimedOut  BattlegroundActivityType = "TIMED_OUT"
+	BattlegroundActivityTypeSettled   BattlegroundActivityType = "SETTLED"
+	BattlegroundActivityTypeEffect    BattlegroundActivityType = "EFFECT"
+	BattlegroundActivityTypePowercard BattlegroundActivityType = "POWERCARD"
+	BattlegroundActivityTypeEnded     BattlegroundActivityType = "ENDED"
+)
+
+var AllBattlegroundActivityType = []BattlegroundActivityType{
+	BattlegroundActivityTypeJoined,
+	BattlegroundActivityTypeLeft,
+	BattlegroundActivityTypeReady,
+	BattlegroundActivityTypeStarted,
+	BattlegroundActivityTypeLocked,
+	BattlegroundActivityTypeTimedOut,
+	BattlegroundActivityTypeSettled,
+	BattlegroundActivityTypeEffect,
+	BattlegroundActivityTypePowercard,
+	BattlegroundActivityTypeEnded,
+}
+
+func (e BattlegroundActivityType) IsValid() bool {
+	switch e {
+	case BattlegroundActivityTypeJoined, BattlegroundActivityTypeLeft, BattlegroundActivityTypeReady, BattlegroundActivityTypeStarted, BattlegroundActivityTypeLocked, BattlegroundActivityTypeTimedOut, BattlegroundActivityTypeSettled, BattlegroundActivityTypeEffect, BattlegroundActivityTypePowercard, BattlegroundActivityTypeEnded:
+		return true
+	}
+	return false
+}
+
+func (e BattlegroundActivityType) String() string {
+	return string(e)
+}
+
+func (e *BattlegroundActivityType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BattlegroundActivityType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BattlegroundActivityType", str)
+	}
+	return nil
+}
+
+func (e BattlegroundActivityType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type BattlegroundBotStrategy string
+
+const (
+	BattlegroundBotStrategyRandom   BattlegroundBotStrategy = "RANDOM"
+	BattlegroundBotStrategyCounter  BattlegroundBotStrategy = "COUNTER"
+	BattlegroundBotStrategyScripted BattlegroundBotStrategy = "SCRIPTED"
+)
+
+var AllBattlegroundBotStrategy = []BattlegroundBotStrategy{
+	BattlegroundBotStrategyRandom,
+	BattlegroundBotStrategyCounter,
+	BattlegroundBotStrategyScripted,
+}
+
+func (e BattlegroundBotStrategy) IsValid() bool {
+	switch e {
+	case BattlegroundBotStrategyRandom, BattlegroundBotStrategyCounter, BattlegroundBotStrategyScripted:
+		return true
+	}
+	return false
+}
+
+func (e BattlegroundBotStrategy) String() string {
+	return string(e)
+}
+
+func (e *BattlegroundBotStrategy) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BattlegroundBotStrategy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BattlegroundBotStrategy", str)
+	}
+	return nil
+}
+
+func (e BattlegroundBotStrategy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
 type BattlegroundEffect string
@@ -223,6 +569,47 @@ func (e BattlegroundEffect) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type BattlegroundPairing string
+
+const (
+	BattlegroundPairingRoundRobin BattlegroundPairing = "ROUND_ROBIN"
+	BattlegroundPairingRandom     BattlegroundPairing = "RANDOM"
+)
+
+var AllBattlegroundPairing = []BattlegroundPairing{
+	BattlegroundPairingRoundRobin,
+	BattlegroundPairingRandom,
+}
+
+func (e BattlegroundPairing) IsValid() bool {
+	switch e {
+	case BattlegroundPairingRoundRobin, BattlegroundPairingRandom:
+		return true
+	}
+	return false
+}
+
+func (e BattlegroundPairing) String() string {
+	return string(e)
+}
+
+func (e *BattlegroundPairing) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BattlegroundPairing(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BattlegroundPairing", str)
+	}
+	return nil
+}
+
+func (e BattlegroundPairing) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type BattlegroundSelection string
 
 const (
@@ -266,6 +653,135 @@ func (e BattlegroundSelection) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type BattlegroundTimeoutPolicy string
+
+const (
+	BattlegroundTimeoutPolicyForfeit BattlegroundTimeoutPolicy = "FORFEIT"
+	BattlegroundTimeoutPolicyRandom  BattlegroundTimeoutPolicy = "RANDOM"
+)
+
+var AllBattlegroundTimeoutPolicy = []BattlegroundTimeoutPolicy{
+	BattlegroundTimeoutPolicyForfeit,
+	BattlegroundTimeoutPolicyRandom,
+}
+
+func (e BattlegroundTimeoutPolicy) IsValid() bool {
+	switch e {
+	case BattlegroundTimeoutPolicyForfeit, BattlegroundTimeoutPolicyRandom:
+		return true
+	}
+	return false
+}
+
+func (e BattlegroundTimeoutPolicy) String() string {
+	return string(e)
+}
+
+func (e *BattlegroundTimeoutPolicy) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BattlegroundTimeoutPolicy(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BattlegroundTimeoutPolicy", str)
+	}
+	return nil
+}
+
+func (e BattlegroundTimeoutPolicy) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type BattlegroundWinner string
+
+const (
+	BattlegroundWinnerAttacker BattlegroundWinner = "ATTACKER"
+	BattlegroundWinnerDefender BattlegroundWinner = "DEFENDER"
+	BattlegroundWinnerDraw     BattlegroundWinner = "DRAW"
+)
+
+var AllBattlegroundWinner = []BattlegroundWinner{
+	BattlegroundWinnerAttacker,
+	BattlegroundWinnerDefender,
+	BattlegroundWinnerDraw,
+}
+
+func (e BattlegroundWinner) IsValid() bool {
+	switch e {
+	case BattlegroundWinnerAttacker, BattlegroundWinnerDefender, BattlegroundWinnerDraw:
+		return true
+	}
+	return false
+}
+
+func (e BattlegroundWinner) String() string {
+	return string(e)
+}
+
+func (e *BattlegroundWinner) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BattlegroundWinner(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BattlegroundWinner", str)
+	}
+	return nil
+}
+
+func (e BattlegroundWinner) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type DiscoveryStatus string
+
+const (
+	DiscoveryStatusSubmitted   DiscoveryStatus = "SUBMITTED"
+	DiscoveryStatusUnderReview DiscoveryStatus = "UNDER_REVIEW"
+	DiscoveryStatusAccepted    DiscoveryStatus = "ACCEPTED"
+	DiscoveryStatusRejected    DiscoveryStatus = "REJECTED"
+)
+
+var AllDiscoveryStatus = []DiscoveryStatus{
+	DiscoveryStatusSubmitted,
+	DiscoveryStatusUnderReview,
+	DiscoveryStatusAccepted,
+	DiscoveryStatusRejected,
+}
+
+func (e DiscoveryStatus) IsValid() bool {
+	switch e {
+	case DiscoveryStatusSubmitted, DiscoveryStatusUnderReview, DiscoveryStatusAccepted, DiscoveryStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e DiscoveryStatus) String() string {
+	return string(e)
+}
+
+func (e *DiscoveryStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DiscoveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DiscoveryStatus", str)
+	}
+	return nil
+}
+
+func (e DiscoveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type Gender string
 
 const (
@@ -307,6 +823,49 @@ func (e Gender) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type ImageSize string
+
+const (
+	ImageSizeSmall    ImageSize = "SMALL"
+	ImageSizeMedium   ImageSize = "MEDIUM"
+	ImageSizeOriginal ImageSize = "ORIGINAL"
+)
+
+var AllImageSize = []ImageSize{
+	ImageSizeSmall,
+	ImageSizeMedium,
+	ImageSizeOriginal,
+}
+
+func (e ImageSize) IsValid() bool {
+	switch e {
+	case ImageSizeSmall, ImageSizeMedium, ImageSizeOriginal:
+		return true
+	}
+	return false
+}
+
+func (e ImageSize) String() string {
+	return string(e)
+}
+
+func (e *ImageSize) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ImageSize(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ImageSize", str)
+	}
+	return nil
+}
+
+func (e ImageSize) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type PastoralStatus string
 
 const (
@@ -360,6 +919,92 @@ func (e PastoralStatus) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type PaymentStatus string
+
+const (
+	PaymentStatusPending  PaymentStatus = "PENDING"
+	PaymentStatusVerified PaymentStatus = "VERIFIED"
+	PaymentStatusRejected PaymentStatus = "REJECTED"
+)
+
+var AllPaymentStatus = []PaymentStatus{
+	PaymentStatusPending,
+	PaymentStatusVerified,
+	PaymentStatusRejected,
+}
+
+func (e PaymentStatus) IsValid() bool {
+	switch e {
+	case PaymentStatusPending, PaymentStatusVerified, PaymentStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e PaymentStatus) String() string {
+	return string(e)
+}
+
+func (e *PaymentStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PaymentStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PaymentStatus", str)
+	}
+	return nil
+}
+
+func (e PaymentStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type PostOrder string
+
+const (
+	PostOrderNewest        PostOrder = "NEWEST"
+	PostOrderMostLiked     PostOrder = "MOST_LIKED"
+	PostOrderMostCommented PostOrder = "MOST_COMMENTED"
+)
+
+var AllPostOrder = []PostOrder{
+	PostOrderNewest,
+	PostOrderMostLiked,
+	PostOrderMostCommented,
+}
+
+func (e PostOrder) IsValid() bool {
+	switch e {
+	case PostOrderNewest, PostOrderMostLiked, PostOrderMostCommented:
+		return true
+	}
+	return false
+}
+
+func (e PostOrder) String() string {
+	return string(e)
+}
+
+func (e *PostOrder) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PostOrder(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PostOrder", str)
+	}
+	return nil
+}
+
+func (e PostOrder) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type Powercard string
 
 const (
@@ -403,6 +1048,51 @@ func (e Powercard) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+type PowercardAction string
+
+const (
+	PowercardActionGrant  PowercardAction = "GRANT"
+	PowercardActionRevoke PowercardAction = "REVOKE"
+	PowercardActionEquip  PowercardAction = "EQUIP"
+	PowercardActionUse    PowercardAction = "USE"
+)
+
+var AllPowercardAction = []PowercardAction{
+	PowercardActionGrant,
+	PowercardActionRevoke,
+	PowercardActionEquip,
+	PowercardActionUse,
+}
+
+func (e PowercardAction) IsValid() bool {
+	switch e {
+	case PowercardActionGrant, PowercardActionRevoke, PowercardActionEquip, PowercardActionUse:
+		return true
+	}
+	return false
+}
+
+func (e PowercardAction) String() string {
+	return string(e)
+}
+
+func (e *PowercardAction) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PowercardAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PowercardAction", str)
+	}
+	return nil
+}
+
+func (e PowercardAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
 type Role string
 
 const (
@@ -410,6 +1100,7 @@ const (
 	RoleTeamleader    Role = "TEAMLEADER"
 	RoleClusterleader Role = "CLUSTERLEADER"
 	RoleCrew          Role = "CREW"
+	RoleJudge         Role = "JUDGE"
 )
 
 var AllRole = []Role{
@@ -417,11 +1108,12 @@ var AllRole = []Role{
 	RoleTeamleader,
 	RoleClusterleader,
 	RoleCrew,
+	RoleJudge,
 }
 
 func (e Role) IsValid() bool {
 	switch e {
-	case RolePlayer, RoleTeamleader, RoleClusterleader, RoleCrew:
+	case RolePlayer, RoleTeamleader, RoleClusterleader, RoleCrew, RoleJudge:
 		return true
 	}
 	return false
@@ -537,3 +1229,134 @@ func (e *Satellite) UnmarshalGQL(v interface{}) error {
 func (e Satellite) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
+
+type SearchType string
+
+const (
+	SearchTypeUser SearchType = "USER"
+	SearchTypeTeam SearchType = "TEAM"
+	SearchTypePost SearchType = "POST"
+)
+
+var AllSearchType = []SearchType{
+	SearchTypeUser,
+	SearchTypeTeam,
+	SearchTypePost,
+}
+
+func (e SearchType) IsValid() bool {
+	switch e {
+	case SearchTypeUser, SearchTypeTeam, SearchTypePost:
+		return true
+	}
+	return false
+}
+
+func (e SearchType) String() string {
+	return string(e)
+}
+
+func (e *SearchType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SearchType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SearchType", str)
+	}
+	return nil
+}
+
+func (e SearchType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TournamentFormat string
+
+const (
+	TournamentFormatSingleElimination TournamentFormat = "SINGLE_ELIMINATION"
+	TournamentFormatSwiss             TournamentFormat = "SWISS"
+)
+
+var AllTournamentFormat = []TournamentFormat{
+	TournamentFormatSingleElimination,
+	TournamentFormatSwiss,
+}
+
+func (e TournamentFormat) IsValid() bool {
+	switch e {
+	case TournamentFormatSingleElimination, TournamentFormatSwiss:
+		return true
+	}
+	return false
+}
+
+func (e TournamentFormat) String() string {
+	return string(e)
+}
+
+func (e *TournamentFormat) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TournamentFormat(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TournamentFormat", str)
+	}
+	return nil
+}
+
+func (e TournamentFormat) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type UploadTarget string
+
+const (
+	UploadTargetPostImage         UploadTarget = "POST_IMAGE"
+	UploadTargetProfileAvatar     UploadTarget = "PROFILE_AVATAR"
+	UploadTargetProfileTngReceipt UploadTarget = "PROFILE_TNG_RECEIPT"
+	UploadTargetTeamAvatar        UploadTarget = "TEAM_AVATAR"
+	UploadTargetHumanityPhoto     UploadTarget = "HUMANITY_PHOTO"
+)
+
+var AllUploadTarget = []UploadTarget{
+	UploadTargetPostImage,
+	UploadTargetProfileAvatar,
+	UploadTargetProfileTngReceipt,
+	UploadTargetTeamAvatar,
+	UploadTargetHumanityPhoto,
+}
+
+func (e UploadTarget) IsValid() bool {
+	switch e {
+	case UploadTargetPostImage, UploadTargetProfileAvatar, UploadTargetProfileTngReceipt, UploadTargetTeamAvatar, UploadTargetHumanityPhoto:
+		return true
+	}
+	return false
+}
+
+func (e UploadTarget) String() string {
+	return string(e)
+}
+
+func (e *UploadTarget) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = UploadTarget(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid UploadTarget", str)
+	}
+	return nil
+}
+
+func (e UploadTarget) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+type PointAward struct {
+	ID        string    `json:"id"`
+	TeamID    string    `json:"team"`
+	Points    float64   `json:"points"`
+	Source    string    `json:"source"`
+	Reference string    `json:"reference"`
+	Reason    *string   `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func MapToPointAward(dbPointAward *postgresql.PointAwardModel) (*PointAward, error) {
+	var reason *string
+	if res, ok := dbPointAward.Reason(); ok {
+		reason = &res
+	}
+	pointAward := &PointAward{
+		ID:        dbPointAward.ID,
+		TeamID:    dbPointAward.TeamID,
+		Points:    dbPointAward.Points,
+		Source:    dbPointAward.Source,
+		Reference: dbPointAward.Reference,
+		Reason:    reason,
+		CreatedAt: dbPointAward.CreatedAt,
+	}
+	return pointAward, nil
+}
+
+func MapToPointAwards(dbPointAwards []postgresql.PointAwardModel) ([]*PointAward, error) {
+	var pointAwards []*PointAward
+	for _, dbPointAward := range dbPointAwards {
+		pointAward, err := MapToPointAward(&dbPointAward)
+		if err != nil {
+			return nil, err
+		}
+		pointAwards = append(pointAwards, pointAward)
+	}
+	return pointAwards, nil
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+type PowercardEvent struct {
+	ID        string          `json:"id"`
+	TeamID    string          `json:"team"`
+	Powercard Powercard       `json:"powercard"`
+	Action    PowercardAction `json:"action"`
+	Reason    *string         `json:"reason"`
+	Source    *string         `json:"source"`
+	Reference *string         `json:"reference"`
+	UserID    *string         `json:"user"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+func MapToPowercardEvent(dbPowercardEvent *postgresql.PowercardEventModel) (*PowercardEvent, error) {
+	var reason, source, reference, userID *string
+	if res, ok := dbPowercardEvent.Reason(); ok {
+		reason = &res
+	}
+	if res, ok := dbPowercardEvent.Source(); ok {
+		source = &res
+	}
+	if res, ok := dbPowercardEvent.Reference(); ok {
+		reference = &res
+	}
+	if res, ok := dbPowercardEvent.UserID(); ok {
+		userID = &res
+	}
+
+	powercardEvent := &PowercardEvent{
+		ID:        dbPowercardEvent.ID,
+		TeamID:    dbPowercardEvent.TeamID,
+		Powercard: Powercard(dbPowercardEvent.Powercard),
+		Action:    PowercardAction(dbPowercardEvent.Action),
+		Reason:    reason,
+		Source:    source,
+		Reference: reference,
+		UserID:    userID,
+		CreatedAt: dbPowercardEvent.CreatedAt,
+	}
+
+	return powercardEvent, nil
+}
+
+func MapToPowercardEvents(dbPowercardEvents []postgresql.PowercardEventModel) ([]*PowercardEvent, error) {
+	var powercardEvents []*PowercardEvent
+	for _, dbPowercardEvent := range dbPowercardEvents {
+		powercardEvent, err := MapToPowercardEvent(&dbPowercardEvent)
+		if err != nil {
+			return nil, err
+		}
+		powercardEvents = append(powercardEvents, powercardEvent)
+	}
+	return powercardEvents, nil
+}
@@ -7,21 +7,25 @@ import (
 )
 
 type Profile struct {
-	ID            string          `json:"id" fake:"{uuid}"`
-	Status        *PastoralStatus `json:"status" fake:"{randomstring:[PASTOR,SCGL,CGL,PCGL,ACGL,OM,NB,NF]}"`
-	Gender        Gender          `json:"gender" fake:"{randomstring:[MALE,FEMALE]}"`
-	Satellite     *Satellite      `json:"satellite" fake:"{randomstring:[FGAPUCHONG,FGASETAPAK,FGARAWANG,FGAPJ,FGAUSJ]}"`
-	NameEng       string          `json:"nameEng" fake:"{name}"`
-	NameChi       *string         `json:"nameChi" fake:"{name}"`
-	Contact       string          `json:"contact" fake:"{phone}"`
-	Dob           time.Time       `json:"dob" fake:"{date}"`
-	Bio           *string         `json:"bio" fake:"skip"`
-	TngReceiptURL *string         `json:"tngReceiptUrl" fake:"{url}"`
-	AvatarURL     *string         `json:"avatarUrl" fake:"{url}"`
-	CreatedAt     time.Time       `json:"createdAt" fake:"{date}"`
-	UpdatedAt     time.Time       `json:"updatedAt" fake:"{date}"`
-	AddressID     *string         `json:"address" fake:"skip"`
-	InvitedBy     *string         `json:"invitedBy" fake:"{firstname}"`
+	ID                string          `json:"id" fake:"{uuid}"`
+	Status            *PastoralStatus `json:"status" fake:"{randomstring:[PASTOR,SCGL,CGL,PCGL,ACGL,OM,NB,NF]}"`
+	Gender            Gender          `json:"gender" fake:"{randomstring:[MALE,FEMALE]}"`
+	Satellite         *Satellite      `json:"satellite" fake:"{randomstring:[FGAPUCHONG,FGASETAPAK,FGARAWANG,FGAPJ,FGAUSJ]}"`
+	NameEng           string          `json:"nameEng" fake:"{name}"`
+	NameChi           *string         `json:"nameChi" fake:"{name}"`
+	Contact           string          `json:"contact" fake:"{phone}"`
+	Dob               time.Time       `json:"dob" fake:"{date}"`
+	Bio               *string         `json:"bio" fake:"skip"`
+	TngReceiptURL     *string         `json:"tngReceiptUrl" fake:"{url}"`
+	AvatarURL         *string         `json:"avatarUrl" fake:"{url}"`
+	CreatedAt         time.Time       `json:"createdAt" fake:"{date}"`
+	UpdatedAt         time.Time       `json:"updatedAt" fake:"{date}"`
+	AddressID         *string         `json:"address" fake:"skip"`
+	InvitedBy         *string         `json:"invitedBy" fake:"{firstname}"`
+	PaymentStatus     PaymentStatus   `json:"paymentStatus" fake:"{randomstring:[PENDING,VERIFIED,REJECTED]}"`
+	PaymentNote       *string         `json:"paymentNote" fake:"skip"`
+	PaymentVerifierID *string         `json:"paymentVerifier" fake:"skip"`
+	PaymentVerifiedAt *time.Time      `json:"paymentVerifiedAt" fake:"skip"`
 }
 
 func MapToProfile(dbProfile *postgresql.ProfileModel) (*Profile, error) {
@@ -33,6 +37,9 @@ func MapToProfile(dbProfile *postgresql.ProfileModel) (*Profile, error) {
 	var bio *string
 	var satellite *Satellite
 	var status *PastoralStatus
+	var paymentNote *string
+	var paymentVerifierID *string
+	var paymentVerifiedAt *time.Time
 	if res, ok := dbProfile.NameChi(); ok {
 		nameChi = &res
 	}
@@ -57,22 +64,35 @@ func MapToProfile(dbProfile *postgresql.ProfileModel) (*Profile, error) {
 	if res, ok := dbProfile.Satellite(); ok {
 		satellite = (*Satellite)(&res)
 	}
+	if res, ok := dbProfile.PaymentNote(); ok {
+		paymentNote = &res
+	}
+	if res, ok := dbProfile.PaymentVerifierID(); ok {
+		paymentVerifierID = &res
+	}
+	if res, ok := dbProfile.PaymentVerifiedAt(); ok {
+		paymentVerifiedAt = &res
+	}
 	profile := &Profile{
-		ID:            dbProfile.ID,
-		Status:        status,
-		Gender:        Gender(dbProfile.Gender),
-		Satellite:     satellite,
-		NameEng:       dbProfile.NameEng,
-		NameChi:       nameChi,
-		Contact:       dbProfile.Contact,
-		Dob:           dbProfile.Dob,
-		Bio:           bio,
-		TngReceiptURL: tngReceiptUrl,
-		AvatarURL:     avatarUrl,
-		CreatedAt:     dbProfile.CreatedAt,
-		UpdatedAt:     dbProfile.UpdatedAt,
-		AddressID:     addressID,
-		InvitedBy:     invitedBy,
+		ID:                dbProfile.ID,
+		Status:            status,
+		Gender:            Gender(dbProfile.Gender),
+		Satellite:         satellite,
+		NameEng:           dbProfile.NameEng,
+		NameChi:           nameChi,
+		Contact:           dbProfile.Contact,
+		Dob:               dbProfile.Dob,
+		Bio:               bio,
+		TngReceiptURL:     tngReceiptUrl,
+		AvatarURL:         avatarUrl,
+		CreatedAt:         dbProfile.CreatedAt,
+		UpdatedAt:         dbProfile.UpdatedAt,
+		AddressID:         addressID,
+		InvitedBy:         invitedBy,
+		PaymentStatus:     PaymentStatus(dbProfile.PaymentStatus),
+		PaymentNote:       paymentNote,
+		PaymentVerifierID: paymentVerifierID,
+		PaymentVerifiedAt: paymentVerifiedAt,
 	}
 	return profile, nil
 }
@@ -0,0 +1,10 @@
+package model
+
+// SearchResult is a single hit of a full-text search, ID refers to a user, team
+// or post depending on Type.
+type SearchResult struct {
+	Type    SearchType `json:"type"`
+	ID      string     `json:"id"`
+	Rank    float64    `json:"rank"`
+	Snippet string     `json:"snippet"`
+}
@@ -50,3 +50,92 @@ func MapToSpeeds(dbSpeeds []postgresql.SpeedModel) ([]*Speed, error) {
 	}
 	return speeds, nil
 }
+
+type SpeedAttempt struct {
+	ID        string    `json:"id"`
+	Answer    string    `json:"answer"`
+	Correct   bool      `json:"correct"`
+	UserID    *string   `json:"user"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func MapToSpeedAttempt(dbSpeedAttempt *postgresql.SpeedAttemptModel) (*SpeedAttempt, error) {
+	var userID *string
+	if res, ok := dbSpeedAttempt.UserID(); ok {
+		userID = &res
+	}
+
+	speedAttempt := &SpeedAttempt{
+		ID:        dbSpeedAttempt.ID,
+		Answer:    dbSpeedAttempt.Answer,
+		Correct:   dbSpeedAttempt.Correct,
+		UserID:    userID,
+		CreatedAt: dbSpeedAttempt.CreatedAt,
+	}
+
+	return speedAttempt, nil
+}
+
+func MapToSpeedAttempts(dbSpeedAttempts []postgresql.SpeedAttemptModel) ([]*SpeedAttempt, error) {
+	var speedAttempts []*SpeedAttempt
+	for _, dbSpeedAttempt := range dbSpeedAttempts {
+		speedAttempt, err := MapToSpeedAttempt(&dbSpeedAttempt)
+		if err != nil {
+			return nil, err
+		}
+		speedAttempts = append(speedAttempts, speedAttempt)
+	}
+	return speedAttempts, nil
+}
+
+type SpeedRank struct {
+	Rank        int       `json:"rank"`
+	TeamID      string    `json:"team"`
+	CompletedAt time.Time `json:"completedAt"`
+	Duration    int       `json:"duration"`
+}
+
+type SpeedAwardRule struct {
+	ID        string     `json:"id"`
+	Top       *int       `json:"top"`
+	Before    *time.Time `json:"before"`
+	Points    float64    `json:"points"`
+	Powercard *Powercard `json:"powercard"`
+}
+
+func MapToSpeedAwardRule(dbSpeedAwardRule *postgresql.SpeedAwardRuleModel) (*SpeedAwardRule, error) {
+	var top *int
+	var before *time.Time
+	var powercard *Powercard
+	if res, ok := dbSpeedAwardRule.Top(); ok {
+		top = &res
+	}
+	if res, ok := dbSpeedAwardRule.Before(); ok {
+		before = &res
+	}
+	if res, ok := dbSpeedAwardRule.Powercard(); ok {
+		powercard = (*Powercard)(&res)
+	}
+
+	speedAwardRule := &SpeedAwardRule{
+		ID:        dbSpeedAwardRule.ID,
+		Top:       top,
+		Before:    before,
+		Points:    dbSpeedAwardRule.Points,
+		Powercard: powercard,
+	}
+
+	return speedAwardRule, nil
+}
+
+func MapToSpeedAwardRules(dbSpeedAwardRules []postgresql.SpeedAwardRuleModel) ([]*SpeedAwardRule, error) {
+	var speedAwardRules []*SpeedAwardRule
+	for _, dbSpeedAwardRule := range dbSpeedAwardRules {
+		speedAwardRule, err := MapToSpeedAwardRule(&dbSpeedAwardRule)
+		if err != nil {
+			return nil, err
+		}
+		speedAwardRules = append(speedAwardRules, speedAwardRule)
+	}
+	return speedAwardRules, nil
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+type Tournament struct {
+	ID             string           `json:"id"`
+	Name           string           `json:"name"`
+	Format         TournamentFormat `json:"format"`
+	Status         RoomStatus       `json:"status"`
+	TeamIds        []string         `json:"teams"`
+	RoundCount     *int             `json:"roundCount"`
+	RoomRoundCount *int             `json:"roomRoundCount"`
+	WinnerTeamID   *string          `json:"winner"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+	StartedAt      *time.Time       `json:"startedAt"`
+	EndedAt        *time.Time       `json:"endedAt"`
+}
+
+func MapToTournament(dbTournament *postgresql.TournamentModel) (*Tournament, error) {
+	tournament := &Tournament{
+		ID:        dbTournament.ID,
+		Name:      dbTournament.Name,
+		Format:    TournamentFormat(dbTournament.Format),
+		Status:    RoomStatus(dbTournament.Status),
+		TeamIds:   dbTournament.TeamIDs,
+		CreatedAt: dbTournament.CreatedAt,
+		UpdatedAt: dbTournament.UpdatedAt,
+	}
+	if res, ok := dbTournament.RoundCount(); ok {
+		tournament.RoundCount = &res
+	}
+	if res, ok := dbTournament.RoomRoundCount(); ok {
+		tournament.RoomRoundCount = &res
+	}
+	if res, ok := dbTournament.WinnerTeamID(); ok {
+		tournament.WinnerTeamID = &res
+	}
+	if res, ok := dbTournament.StartedAt(); ok {
+		tournament.StartedAt = &res
+	}
+	if res, ok := dbTournament.EndedAt(); ok {
+		tournament.EndedAt = &res
+	}
+
+	return tournament, nil
+}
+
+type TournamentMatch struct {
+	ID           string    `json:"id"`
+	Round        int       `json:"round"`
+	Position     int       `json:"position"`
+	TeamIds      []string  `json:"teams"`
+	Code         *string   `json:"room"`
+	WinnerTeamID *string   `json:"winner"`
+	Bye          bool      `json:"bye"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func MapToTournamentMatch(dbTournamentMatch *postgresql.TournamentMatchModel) (*TournamentMatch, error) {
+	tournamentMatch := &TournamentMatch{
+		ID:        dbTournamentMatch.ID,
+		Round:     dbTournamentMatch.Round,
+		Position:  dbTournamentMatch.Position,
+		TeamIds:   dbTournamentMatch.TeamIDs,
+		Bye:       len(dbTournamentMatch.TeamIDs) == 1,
+		CreatedAt: dbTournamentMatch.CreatedAt,
+		UpdatedAt: dbTournamentMatch.UpdatedAt,
+	}
+	if res, ok := dbTournamentMatch.Code(); ok {
+		tournamentMatch.Code = &res
+	}
+	if res, ok := dbTournamentMatch.WinnerTeamID(); ok {
+		tournamentMatch.WinnerTeamID = &res
+	}
+
+	return tournamentMatch, nil
+}
+
+func MapToTournamentMatches(dbTournamentMatches []postgresql.TournamentMatchModel) ([]*TournamentMatch, error) {
+	var tournamentMatches []*TournamentMatch
+	for _, dbTournamentMatch := range dbTournamentMatches {
+		tournamentMatch, err := MapToTournamentMatch(&dbTournamentMatch)
+		if err != nil {
+			return nil, err
+		}
+		tournamentMatches = append(tournamentMatches, tournamentMatch)
+	}
+	return tournamentMatches, nil
+}
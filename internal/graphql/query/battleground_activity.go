@@ -0,0 +1,62 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// BattlegroundActivityLimit is how many of the latest activities a spectator sees.
+const BattlegroundActivityLimit = 50
+
+func GetManyBattlegroundActivity(ctx context.Context, db *postgresql.PrismaClient, code string, limit int) ([]*model.BattlegroundActivity, error) {
+	// fetch the latest battlegroundActivities first
+	fetchedBattlegroundActivities, err := db.BattlegroundActivity.FindMany(
+		postgresql.BattlegroundActivity.Code.Equals(code),
+	).OrderBy(
+		postgresql.BattlegroundActivity.CreatedAt.Order(postgresql.DESC),
+	).Take(limit).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse battlegroundActivities to graphql type
+	battlegroundActivities, err := model.MapToBattlegroundActivities(fetchedBattlegroundActivities)
+	if err != nil {
+		return nil, err
+	}
+
+	return battlegroundActivities, nil
+}
+
+// recordBattlegroundActivity adds an entry to the activity log of a room. The log is
+// only there to be watched, so failing to write it does not fail what happened.
+func recordBattlegroundActivity(ctx context.Context, db *postgresql.PrismaClient, code string, round *int, activityType postgresql.BattlegroundActivityType, teamID *string, message string) {
+	_, err := db.BattlegroundActivity.CreateOne(
+		postgresql.BattlegroundActivity.ID.Set(gofakeit.UUID()),
+		postgresql.BattlegroundActivity.Code.Set(code),
+		postgresql.BattlegroundActivity.Type.Set(activityType),
+		postgresql.BattlegroundActivity.Message.Set(message),
+		postgresql.BattlegroundActivity.Round.SetIfPresent(round),
+		postgresql.BattlegroundActivity.TeamID.SetIfPresent(teamID),
+	).Exec(ctx)
+	if err != nil {
+		log.Printf("unable to record %s activity of room %s: %v\n", activityType, code, err)
+	}
+}
+
+// battlegroundTeamName is how a team is called in the activity log.
+func battlegroundTeamName(ctx context.Context, db *postgresql.PrismaClient, teamID string) string {
+	team, err := db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Exec(ctx)
+	if err != nil {
+		return fmt.Sprintf("Team %s", teamID)
+	}
+	if name, ok := team.Name(); ok {
+		return name
+	}
+	return fmt.Sprintf("Team %s", teamID)
+}
@@ -0,0 +1,276 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/battleground"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// OpenBattlegroundEffect opens a box for a settled round and applies its effect to the
+// points of the winner, and of the loser for the effects that move points between them.
+// Each box can only be opened once per room and a draw has no box to open.
+func OpenBattlegroundEffect(ctx context.Context, db *postgresql.PrismaClient, code string, round int, effect model.BattlegroundEffect, now time.Time) (*model.BattlegroundRound, error) {
+	room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, opened := range room.OpenedEffects {
+		if opened == postgresql.BattlegroundEffect(effect) {
+			return nil, fmt.Errorf("box %s was already opened in room %s", effect, code)
+		}
+	}
+
+	fetchedRound, err := db.BattlegroundRound.FindUnique(battlegroundRoundCodeRound(code, round)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	winner, ok := fetchedRound.Winner()
+	if !ok {
+		return nil, fmt.Errorf("round %d is still being played", round)
+	}
+	if winner == postgresql.BattlegroundWinnerDRAW {
+		return nil, fmt.Errorf("round %d was a draw, there is no box to open", round)
+	}
+	if _, ok := fetchedRound.Effect(); ok {
+		return nil, fmt.Errorf("a box was already opened for round %d", round)
+	}
+
+	attackerBefore, defenderBefore, err := getBattlegroundRoundTeamPoints(ctx, db, room, fetchedRound)
+	if err != nil {
+		return nil, err
+	}
+	attackerAfter, defenderAfter := applyToBattlegroundWinner(winner, attackerBefore, defenderBefore, battleground.Effect(effect).Apply)
+	winnerTeamID, _ := fetchedRound.AttackerTeamID()
+	if winner == postgresql.BattlegroundWinnerDEFENDER {
+		winnerTeamID, _ = fetchedRound.DefenderTeamID()
+	}
+
+	// the play claims the box for the room and the turn of the round, so the box is
+	// claimed, the points awarded and the round updated together or not at all
+	playParams := []postgresql.BattlegroundPlaySetParam{
+		postgresql.BattlegroundPlay.Effect.Set(postgresql.BattlegroundEffect(effect)),
+	}
+	if winnerTeamID != "" {
+		playParams = append(playParams, postgresql.BattlegroundPlay.TeamID.Set(winnerTeamID))
+	}
+	createPlay, err := battlegroundPlayTx(ctx, db, code, round, playParams...)
+	if err != nil {
+		return nil, err
+	}
+	txs := []transaction.Param{
+		createPlay,
+		db.Prisma.ExecuteRaw(`
+			UPDATE
+				"BattlegroundRoom"
+			SET
+				"openedEffects" = array_append("openedEffects", $2::"BattlegroundEffect"),
+				"updatedAt" = $3::timestamp
+			WHERE
+				code = $1
+		`, code, string(effect), now.UTC().Format(time.RFC3339Nano)).Tx(),
+	}
+
+	// the points of a practice room only live on its rounds
+	if !room.Practice {
+		reason := fmt.Sprintf("Opened %s in round %d of room %s", effect, round, code)
+		txs = append(txs, battlegroundPointsTxs(db, fetchedRound, string(effect), attackerAfter-attackerBefore, defenderAfter-defenderBefore, reason)...)
+	}
+	txs = append(txs, db.BattlegroundRound.FindUnique(battlegroundRoundCodeRound(code, round)).Update(
+		postgresql.BattlegroundRound.Effect.Set(postgresql.BattlegroundEffect(effect)),
+		postgresql.BattlegroundRound.AttackerPointsBefore.Set(attackerBefore),
+		postgresql.BattlegroundRound.AttackerPointsAfter.Set(attackerAfter),
+		postgresql.BattlegroundRound.DefenderPointsBefore.Set(defenderBefore),
+		postgresql.BattlegroundRound.DefenderPointsAfter.Set(defenderAfter),
+		postgresql.BattlegroundRound.UpdatedAt.Set(now),
+	).Tx())
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, fmt.Errorf("box %s or round %d was taken at the same time, try again", effect, round)
+		}
+		return nil, err
+	}
+	if winnerTeamID == "" {
+		recordBattlegroundActivity(ctx, db, code, &round, postgresql.BattlegroundActivityTypeEFFECT, nil,
+			fmt.Sprintf("%s opened %s in round %d", BattlegroundBotName, effect, round))
+	} else {
+		recordBattlegroundActivity(ctx, db, code, &round, postgresql.BattlegroundActivityTypeEFFECT, &winnerTeamID,
+			fmt.Sprintf("%s opened %s in round %d", battlegroundTeamName(ctx, db, winnerTeamID), effect, round))
+	}
+
+	return GetUniqueBattlegroundRound(ctx, db, battlegroundRoundCodeRound(code, round))
+}
+
+// PlayBattlegroundPowercard plays the equipped powercard of a team against the effect of
+// a round the team played. The powercard is used up, and only one powercard can be
+// played per round.
+func PlayBattlegroundPowercard(ctx context.Context, db *postgresql.PrismaClient, code string, round int, teamID string, userID *string, now time.Time) (*model.BattlegroundRound, error) {
+	room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	team, err := db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	powercard, ok := team.Powercard()
+	if !ok {
+		return nil, fmt.Errorf("team %s has no powercard equipped", teamID)
+	}
+
+	return playBattlegroundPowercard(ctx, db, room, team, round, powercard, userID, now)
+}
+
+// playBattlegroundPowercard plays a powercard of a team in a round. The powercard of a
+// practice room is not used up and the points stay on the round.
+func playBattlegroundPowercard(ctx context.Context, db *postgresql.PrismaClient, room *postgresql.BattlegroundRoomModel, team *postgresql.TeamModel, round int, powercard postgresql.Powercard, userID *string, now time.Time) (*model.BattlegroundRound, error) {
+	code, teamID := room.Code, team.ID
+	fetchedRound, err := db.BattlegroundRound.FindUnique(battlegroundRoundCodeRound(code, round)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	effect, ok := fetchedRound.Effect()
+	if !ok {
+		return nil, fmt.Errorf("a box must be opened for round %d first", round)
+	}
+	if _, ok := fetchedRound.AttackerPowercard(); ok {
+		return nil, fmt.Errorf("a powercard was already played in round %d", round)
+	}
+	if _, ok := fetchedRound.DefenderPowercard(); ok {
+		return nil, fmt.Errorf("a powercard was already played in round %d", round)
+	}
+
+	var set postgresql.BattlegroundRoundSetParam
+	if res, ok := fetchedRound.AttackerTeamID(); ok && res == teamID {
+		set = postgresql.BattlegroundRound.AttackerPowercard.Set(powercard)
+	} else if res, ok := fetchedRound.DefenderTeamID(); ok && res == teamID {
+		set = postgresql.BattlegroundRound.DefenderPowercard.Set(powercard)
+	} else {
+		return nil, fmt.Errorf("team %s did not play round %d", teamID, round)
+	}
+
+	// the powercard works from the points before the effect
+	winner, _ := fetchedRound.Winner()
+	attackerBefore, _ := fetchedRound.AttackerPointsBefore()
+	defenderBefore, _ := fetchedRound.DefenderPointsBefore()
+	attackerCurrent, _ := fetchedRound.AttackerPointsAfter()
+	defenderCurrent, _ := fetchedRound.DefenderPointsAfter()
+	var reopen bool
+	attackerAfter, defenderAfter := applyToBattlegroundWinner(winner, attackerBefore, defenderBefore, func(winner float64, loser float64) (float64, float64) {
+		winner, loser, reopen = battleground.Powercard(powercard).Play(battleground.Effect(effect), winner, loser)
+		return winner, loser
+	})
+
+	// the play claims the turn of the round so a second powercard cannot be played at the
+	// same time, the card is saved together with its points
+	createPlay, err := battlegroundPlayTx(ctx, db, code, round,
+		postgresql.BattlegroundPlay.Powercard.Set(powercard),
+		postgresql.BattlegroundPlay.TeamID.Set(teamID),
+	)
+	if err != nil {
+		return nil, err
+	}
+	txs := []transaction.Param{createPlay}
+	if !room.Practice {
+		reason := fmt.Sprintf("Played %s in round %d of room %s", powercard, round, code)
+		txs = append(txs, battlegroundPointsTxs(db, fetchedRound, string(powercard), attackerAfter-attackerCurrent, defenderAfter-defenderCurrent, reason)...)
+
+		// the powercard is used up
+		eligiblePowercards := []postgresql.Powercard{}
+		for _, eligiblePowercard := range team.EligiblePowercards {
+			if eligiblePowercard != powercard {
+				eligiblePowercards = append(eligiblePowercards, eligiblePowercard)
+			}
+		}
+		reference := fmt.Sprintf("%s/%d", code, round)
+		txs = append(txs,
+			db.PowercardEvent.CreateOne(
+				postgresql.PowercardEvent.ID.Set(gofakeit.UUID()),
+				postgresql.PowercardEvent.Powercard.Set(powercard),
+				postgresql.PowercardEvent.Action.Set(postgresql.PowercardActionUSE),
+				postgresql.PowercardEvent.Team.Link(postgresql.Team.ID.Equals(teamID)),
+				postgresql.PowercardEvent.Reason.Set(reason),
+				postgresql.PowercardEvent.Source.Set(PointAwardSourceBattleground),
+				postgresql.PowercardEvent.Reference.Set(reference),
+				postgresql.PowercardEvent.UserID.SetIfPresent(userID),
+			).Tx(),
+			db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Update(
+				postgresql.Team.EligiblePowercards.Set(eligiblePowercards),
+				postgresql.Team.Powercard.SetOptional(nil),
+			).Tx(),
+		)
+	}
+
+	params := []postgresql.BattlegroundRoundSetParam{
+		set,
+		postgresql.BattlegroundRound.AttackerPointsAfter.Set(attackerAfter),
+		postgresql.BattlegroundRound.DefenderPointsAfter.Set(defenderAfter),
+		postgresql.BattlegroundRound.UpdatedAt.Set(now),
+	}
+	if reopen {
+		// another box can be opened for the round, the opened one stays used up
+		params = append(params, postgresql.BattlegroundRound.Effect.SetOptional(nil))
+	}
+	txs = append(txs, db.BattlegroundRound.FindUnique(battlegroundRoundCodeRound(code, round)).Update(params...).Tx())
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		if isUniqueConstraintError(err) {
+			return nil, fmt.Errorf("a powercard was already played in round %d", round)
+		}
+		return nil, err
+	}
+	recordBattlegroundActivity(ctx, db, code, &round, postgresql.BattlegroundActivityTypePOWERCARD, &teamID,
+		fmt.Sprintf("%s played %s in round %d", battlegroundTeamName(ctx, db, teamID), powercard, round))
+
+	return GetUniqueBattlegroundRound(ctx, db, battlegroundRoundCodeRound(code, round))
+}
+
+// applyToBattlegroundWinner applies f to the points of the winner and the loser of a
+// round, and returns them as the points of the attacker and the defender.
+func applyToBattlegroundWinner(winner postgresql.BattlegroundWinner, attacker float64, defender float64, f func(winner float64, loser float64) (float64, float64)) (float64, float64) {
+	if winner == postgresql.BattlegroundWinnerDEFENDER {
+		defender, attacker = f(defender, attacker)
+		return attacker, defender
+	}
+	return f(attacker, defender)
+}
+
+// battlegroundPointsTxs awards the change in points of both teams of a round, the
+// reference keeps every box and powercard of a round apart in the point awards.
+func battlegroundPointsTxs(db *postgresql.PrismaClient, round *postgresql.BattlegroundRoundModel, cause string, attackerPoints float64, defenderPoints float64, reason string) []transaction.Param {
+	var txs []transaction.Param
+	if teamID, ok := round.AttackerTeamID(); ok && attackerPoints != 0 {
+		reference := fmt.Sprintf("%s/%d/%s/%s", round.Code, round.Round, cause, teamID)
+		txs = append(txs, awardPointsTxs(db, teamID, attackerPoints, PointAwardSourceBattleground, reference, &reason)...)
+	}
+	if teamID, ok := round.DefenderTeamID(); ok && defenderPoints != 0 {
+		reference := fmt.Sprintf("%s/%d/%s/%s", round.Code, round.Round, cause, teamID)
+		txs = append(txs, awardPointsTxs(db, teamID, defenderPoints, PointAwardSourceBattleground, reference, &reason)...)
+	}
+	return txs
+}
+
+// battlegroundPlayTx records a box or powercard played in a round. Every play of a round
+// takes the next turn and every box is only played once per room, so of two plays made
+// at the same time the second one fails on a unique constraint with its transaction.
+func battlegroundPlayTx(ctx context.Context, db *postgresql.PrismaClient, code string, round int, params ...postgresql.BattlegroundPlaySetParam) (transaction.Param, error) {
+	plays, err := db.BattlegroundPlay.FindMany(
+		postgresql.BattlegroundPlay.Code.Equals(code),
+		postgresql.BattlegroundPlay.Round.Equals(round),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.BattlegroundPlay.CreateOne(
+		postgresql.BattlegroundPlay.ID.Set(gofakeit.UUID()),
+		postgresql.BattlegroundPlay.Code.Set(code),
+		postgresql.BattlegroundPlay.Round.Set(round),
+		postgresql.BattlegroundPlay.Sequence.Set(len(plays)+1),
+		params...,
+	).Tx(), nil
+}
@@ -0,0 +1,270 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/battleground"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// The bot of a practice room defends every round, and both sides start from the same
+// points which are only kept on the rounds of the room. A practice is kept short so an
+// abandoned one times out soon, and is deleted a while after it ended to free its code.
+const (
+	BattlegroundBotName                     = "The bot"
+	BattlegroundPracticeRounds              = 5
+	BattlegroundPracticePoints              = 1000
+	BattlegroundPracticeMaxRounds           = 20
+	BattlegroundPracticeMaxSelectionTimeout = 300
+	BattlegroundPracticeRetention           = 24 * time.Hour
+)
+
+// CreatePracticeBattleground starts a practice room for the team of a user, the user
+// attacks every round and the bot defends with the strategy picked. A team plays one
+// practice at a time.
+func CreatePracticeBattleground(ctx context.Context, db *postgresql.PrismaClient, userID string, param *model.NewPracticeBattleground) (*model.BattlegroundRoom, error) {
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teamID, ok := user.TeamID()
+	if !ok {
+		return nil, fmt.Errorf("you are not in a team")
+	}
+
+	ongoing, err := db.BattlegroundRoom.FindMany(
+		postgresql.BattlegroundRoom.Practice.Equals(true),
+		postgresql.BattlegroundRoom.Status.Equals(postgresql.RoomStatusONGOING),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, room := range ongoing {
+		for _, id := range room.TeamIDs {
+			if id == teamID {
+				return nil, fmt.Errorf("your team is already practicing in room %s", room.Code)
+			}
+		}
+	}
+
+	if param.Strategy == model.BattlegroundBotStrategyScripted && len(param.Script) == 0 {
+		return nil, fmt.Errorf("a scripted bot needs a script")
+	}
+	script := []postgresql.BattlegroundSelection{}
+	for _, selection := range param.Script {
+		script = append(script, postgresql.BattlegroundSelection(selection))
+	}
+	roundCount := BattlegroundPracticeRounds
+	if param.RoundCount != nil {
+		roundCount = *param.RoundCount
+	}
+	if roundCount < 1 || roundCount > BattlegroundPracticeMaxRounds {
+		return nil, fmt.Errorf("a practice has between 1 and %d rounds", BattlegroundPracticeMaxRounds)
+	}
+	selectionTimeout := BattlegroundSelectionTimeout
+	if param.SelectionTimeout != nil {
+		selectionTimeout = *param.SelectionTimeout
+	}
+	if selectionTimeout < 1 || selectionTimeout > BattlegroundPracticeMaxSelectionTimeout {
+		return nil, fmt.Errorf("selection timeout of a practice is between 1 and %d seconds", BattlegroundPracticeMaxSelectionTimeout)
+	}
+	// a random seed unless the bot should be reproduced
+	seed := gofakeit.Number(1, math.MaxInt32)
+	if param.Seed != nil {
+		seed = *param.Seed
+	}
+
+	// the room starts right away, there is nobody else to wait for
+	now := time.Now()
+	if err := deleteExpiredPracticeBattlegrounds(ctx, db, now); err != nil {
+		return nil, err
+	}
+	var createdRoom func() *postgresql.BattlegroundRoomModel
+	code, err := createWithBattlegroundRoomCode(func(code string) error {
+		createRoom := db.BattlegroundRoom.CreateOne(
+			postgresql.BattlegroundRoom.Code.Set(code),
+			postgresql.BattlegroundRoom.UpdatedAt.Set(now),
+			postgresql.BattlegroundRoom.TeamIDs.Set([]string{teamID}),
+			postgresql.BattlegroundRoom.ReadyTeamIDs.Set([]string{teamID}),
+			postgresql.BattlegroundRoom.Status.Set(postgresql.RoomStatusONGOING),
+			postgresql.BattlegroundRoom.StartedAt.Set(now),
+			postgresql.BattlegroundRoom.MinTeams.Set(1),
+			postgresql.BattlegroundRoom.MaxTeams.Set(1),
+			postgresql.BattlegroundRoom.Seed.Set(seed),
+			postgresql.BattlegroundRoom.RoundCount.Set(roundCount),
+			postgresql.BattlegroundRoom.SelectionTimeout.Set(selectionTimeout),
+			postgresql.BattlegroundRoom.Practice.Set(true),
+			postgresql.BattlegroundRoom.BotStrategy.Set(postgresql.BattlegroundBotStrategy(param.Strategy)),
+			postgresql.BattlegroundRoom.BotScript.Set(script),
+		).Tx()
+		createdRoom = createRoom.Result
+		txs := []transaction.Param{createRoom}
+		for i := 1; i <= roundCount; i++ {
+			var deadline *time.Time
+			if i == 1 {
+				first := now.Add(time.Duration(selectionTimeout) * time.Second)
+				deadline = &first
+			}
+			txs = append(txs, db.BattlegroundRound.CreateOne(
+				postgresql.BattlegroundRound.Code.Set(code),
+				postgresql.BattlegroundRound.Round.Set(i),
+				postgresql.BattlegroundRound.UpdatedAt.Set(now),
+				postgresql.BattlegroundRound.UserBattlegroundRoundAttackerToUser.Link(postgresql.User.Username.Equals(user.Username)),
+				postgresql.BattlegroundRound.AttackerTeamID.Set(teamID),
+				postgresql.BattlegroundRound.Deadline.SetIfPresent(deadline),
+			).Tx())
+		}
+		return db.Prisma.Transaction(txs...).Exec(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	recordBattlegroundActivity(ctx, db, code, nil, postgresql.BattlegroundActivityTypeSTARTED, &teamID,
+		fmt.Sprintf("%s started a practice against %s", battlegroundTeamName(ctx, db, teamID), BattlegroundBotName))
+
+	return model.MapToBattlegroundRoom(createdRoom())
+}
+
+// deleteExpiredPracticeBattlegrounds deletes the practice rooms that ended longer than
+// the retention ago together with their rounds, plays and activity, so their codes can
+// be used again.
+func deleteExpiredPracticeBattlegrounds(ctx context.Context, db *postgresql.PrismaClient, now time.Time) error {
+	rooms, err := db.BattlegroundRoom.FindMany(
+		postgresql.BattlegroundRoom.Practice.Equals(true),
+		postgresql.BattlegroundRoom.Status.Equals(postgresql.RoomStatusENDED),
+		postgresql.BattlegroundRoom.EndedAt.Lt(now.Add(-BattlegroundPracticeRetention)),
+	).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rooms) == 0 {
+		return nil
+	}
+
+	var codes []string
+	for _, room := range rooms {
+		codes = append(codes, room.Code)
+	}
+	return db.Prisma.Transaction(
+		db.BattlegroundPlay.FindMany(postgresql.BattlegroundPlay.Code.In(codes)).Delete().Tx(),
+		db.BattlegroundActivity.FindMany(postgresql.BattlegroundActivity.Code.In(codes)).Delete().Tx(),
+		db.BattlegroundRound.FindMany(postgresql.BattlegroundRound.Code.In(codes)).Delete().Tx(),
+		db.BattlegroundRoom.FindMany(
+			postgresql.BattlegroundRoom.Code.In(codes),
+			postgresql.BattlegroundRoom.Practice.Equals(true),
+		).Delete().Tx(),
+	).Exec(ctx)
+}
+
+// OpenPracticeBattlegroundEffect opens a box for a round of a practice room the team of
+// the user plays in.
+func OpenPracticeBattlegroundEffect(ctx context.Context, db *postgresql.PrismaClient, userID string, code string, round int, effect model.BattlegroundEffect, now time.Time) (*model.BattlegroundRound, error) {
+	if _, _, err := getUserPracticeBattleground(ctx, db, userID, code); err != nil {
+		return nil, err
+	}
+	return OpenBattlegroundEffect(ctx, db, code, round, effect, now)
+}
+
+// PlayPracticeBattlegroundPowercard plays any powercard in a round of a practice room, so
+// a team can try out a powercard before owning it.
+func PlayPracticeBattlegroundPowercard(ctx context.Context, db *postgresql.PrismaClient, userID string, code string, round int, powercard model.Powercard, now time.Time) (*model.BattlegroundRound, error) {
+	room, team, err := getUserPracticeBattleground(ctx, db, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	return playBattlegroundPowercard(ctx, db, room, team, round, postgresql.Powercard(powercard), &userID, now)
+}
+
+// getBattlegroundBotSelection picks the selection of the bot for a round. The bot is
+// played again from the first round with the seed of the room, so it picks the same
+// selection however many times it is asked.
+func getBattlegroundBotSelection(ctx context.Context, db *postgresql.PrismaClient, room *postgresql.BattlegroundRoomModel, round int) (battleground.Selection, error) {
+	rounds, err := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Code.Equals(room.Code),
+		postgresql.BattlegroundRound.Round.Lt(round),
+	).OrderBy(
+		postgresql.BattlegroundRound.Round.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var strategy battleground.Strategy = battleground.RandomStrategy{}
+	if botStrategy, ok := room.BotStrategy(); ok {
+		switch botStrategy {
+		case postgresql.BattlegroundBotStrategyCOUNTER:
+			strategy = battleground.CounterStrategy{}
+		case postgresql.BattlegroundBotStrategySCRIPTED:
+			var script []battleground.Selection
+			for _, selection := range room.BotScript {
+				script = append(script, battleground.Selection(selection))
+			}
+			if len(script) == 0 {
+				return "", fmt.Errorf("room %s has a scripted bot without a script", room.Code)
+			}
+			strategy = &battleground.ScriptedStrategy{Script: script}
+		}
+	}
+
+	// the bot only knows what the player selected in the rounds before
+	rng := rand.New(rand.NewSource(int64(room.Seed)))
+	var player []battleground.Selection
+	for _, r := range rounds {
+		strategy.Select(player, rng)
+		if selection, ok := r.AttackerSelection(); ok {
+			player = append(player, battleground.Selection(selection))
+		}
+	}
+	return strategy.Select(player, rng), nil
+}
+
+// getPracticeBattlegroundPoints adds up the points the player and the bot made in the
+// rounds of a practice room.
+func getPracticeBattlegroundPoints(ctx context.Context, db *postgresql.PrismaClient, code string) (float64, float64, error) {
+	rounds, err := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Code.Equals(code),
+	).Exec(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	attackerPoints, defenderPoints := float64(BattlegroundPracticePoints), float64(BattlegroundPracticePoints)
+	for _, round := range rounds {
+		attackerBefore, _ := round.AttackerPointsBefore()
+		attackerAfter, _ := round.AttackerPointsAfter()
+		defenderBefore, _ := round.DefenderPointsBefore()
+		defenderAfter, _ := round.DefenderPointsAfter()
+		attackerPoints += attackerAfter - attackerBefore
+		defenderPoints += defenderAfter - defenderBefore
+	}
+	return attackerPoints, defenderPoints, nil
+}
+
+func getUserPracticeBattleground(ctx context.Context, db *postgresql.PrismaClient, userID string, code string) (*postgresql.BattlegroundRoomModel, *postgresql.TeamModel, error) {
+	teamID, err := getUserTeamID(ctx, db, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !room.Practice {
+		return nil, nil, fmt.Errorf("room %s is not a practice", code)
+	}
+	if !containsTeamID(room.TeamIDs, teamID) {
+		return nil, nil, fmt.Errorf("your team is not practicing in room %s", code)
+	}
+	team, err := db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Exec(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return room, team, nil
+}
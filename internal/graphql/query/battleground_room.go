@@ -2,13 +2,41 @@ package query
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/battleground"
 	"github.com/marcustut/thebox/internal/graphql/model"
 	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
 )
 
+// Limits on the number of teams in a room and the seconds a player has to select, when
+// the room does not set its own.
+const (
+	BattlegroundMinTeams         = 2
+	BattlegroundMaxTeams         = 8
+	BattlegroundSelectionTimeout = 60
+)
+
+// Room codes are 4 characters from an alphabet without the look-alike 0, O, 1 and I,
+// about a million codes. Codes are picked at random and a code that is taken already
+// fails the create on its unique constraint, the create is retried with a new code.
+const (
+	battlegroundRoomCodePattern  = "[2-9A-HJ-NP-Z]{4}"
+	battlegroundRoomCodeAttempts = 20
+)
+
+// battlegroundRoomTransitions lists the statuses a room can move to, a room is prepared,
+// played and ended once. A room that never started can be ended to cancel it.
+var battlegroundRoomTransitions = map[postgresql.RoomStatus][]postgresql.RoomStatus{
+	postgresql.RoomStatusPREPARING: {postgresql.RoomStatusONGOING, postgresql.RoomStatusENDED},
+	postgresql.RoomStatusONGOING:   {postgresql.RoomStatusENDED},
+}
+
 func GetUniqueBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, param postgresql.BattlegroundRoomEqualsUniqueWhereParam) (*model.BattlegroundRoom, error) {
 	// fetch the battlegroundRoom
 	fetchedBattlegrounRoom, err := db.BattlegroundRoom.FindUnique(param).Exec(ctx)
@@ -49,11 +77,68 @@ func GetManyBattlegrounRoom(ctx context.Context, db *postgresql.PrismaClient, pa
 }
 
 func CreateBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, param *model.NewBattlegroundRoom) (*model.BattlegroundRoom, error) {
-	createdBattlegroundRoom, err := db.BattlegroundRoom.CreateOne(
-		postgresql.BattlegroundRoom.Code.Set(gofakeit.Regex("[0-9]{4}")),
-		postgresql.BattlegroundRoom.UpdatedAt.Set(time.Now()),
-		postgresql.BattlegroundRoom.TeamIDs.Set(param.TeamIds),
-	).Exec(ctx)
+	minTeams, maxTeams := BattlegroundMinTeams, BattlegroundMaxTeams
+	if param.MinTeams != nil {
+		minTeams = *param.MinTeams
+	}
+	if param.MaxTeams != nil {
+		maxTeams = *param.MaxTeams
+	}
+	if minTeams < BattlegroundMinTeams {
+		return nil, fmt.Errorf("a room needs at least %d teams", BattlegroundMinTeams)
+	}
+	if maxTeams < minTeams {
+		return nil, fmt.Errorf("maximum of %d teams is lesser than the minimum of %d", maxTeams, minTeams)
+	}
+	teamIDs := []string{}
+	if param.TeamIds != nil {
+		teamIDs = param.TeamIds
+	}
+	if err := validateBattlegroundRoomTeams(ctx, db, teamIDs, maxTeams); err != nil {
+		return nil, err
+	}
+
+	if param.RoundCount != nil && (*param.RoundCount < 0 || *param.RoundCount > battleground.MaxRounds) {
+		return nil, fmt.Errorf("number of rounds must be between 0 and %d", battleground.MaxRounds)
+	}
+	pairing := model.BattlegroundPairingRoundRobin
+	if param.Pairing != nil {
+		pairing = *param.Pairing
+	}
+	selectionTimeout := BattlegroundSelectionTimeout
+	if param.SelectionTimeout != nil {
+		selectionTimeout = *param.SelectionTimeout
+	}
+	if selectionTimeout < 1 {
+		return nil, fmt.Errorf("selection timeout must be at least a second")
+	}
+	timeoutPolicy := model.BattlegroundTimeoutPolicyForfeit
+	if param.TimeoutPolicy != nil {
+		timeoutPolicy = *param.TimeoutPolicy
+	}
+	// a random seed unless the schedule should be reproduced
+	seed := gofakeit.Number(1, math.MaxInt32)
+	if param.Seed != nil {
+		seed = *param.Seed
+	}
+
+	var createdBattlegroundRoom *postgresql.BattlegroundRoomModel
+	_, err := createWithBattlegroundRoomCode(func(code string) (err error) {
+		createdBattlegroundRoom, err = db.BattlegroundRoom.CreateOne(
+			postgresql.BattlegroundRoom.Code.Set(code),
+			postgresql.BattlegroundRoom.UpdatedAt.Set(time.Now()),
+			postgresql.BattlegroundRoom.TeamIDs.Set(teamIDs),
+			postgresql.BattlegroundRoom.ReadyTeamIDs.Set([]string{}),
+			postgresql.BattlegroundRoom.MinTeams.Set(minTeams),
+			postgresql.BattlegroundRoom.MaxTeams.Set(maxTeams),
+			postgresql.BattlegroundRoom.Pairing.Set(postgresql.BattlegroundPairing(pairing)),
+			postgresql.BattlegroundRoom.Seed.Set(seed),
+			postgresql.BattlegroundRoom.RoundCount.SetIfPresent(param.RoundCount),
+			postgresql.BattlegroundRoom.SelectionTimeout.Set(selectionTimeout),
+			postgresql.BattlegroundRoom.TimeoutPolicy.Set(postgresql.BattlegroundTimeoutPolicy(timeoutPolicy)),
+		).Exec(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -67,28 +152,383 @@ func CreateBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, pa
 	return battlegroundRoom, nil
 }
 
+// UpdateUniqueBattlegroundRoom lets the crew set the teams of a room while it is being
+// prepared, a status change goes through the same rules as starting or ending it.
 func UpdateUniqueBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, param postgresql.BattlegroundRoomEqualsUniqueWhereParam, updateParam *model.UpdateBattlegroundRoomInput) (*model.BattlegroundRoom, error) {
-	params := []postgresql.BattlegroundRoomSetParam{
-		postgresql.BattlegroundRoom.UpdatedAt.Set(time.Now()),
-		postgresql.BattlegroundRoom.Status.SetIfPresent((*postgresql.RoomStatus)(updateParam.Status)),
+	room, err := db.BattlegroundRoom.FindUnique(param).Exec(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	var params []postgresql.BattlegroundRoomSetParam
 	if updateParam.TeamIds != nil {
-		params = append(params, postgresql.BattlegroundRoom.TeamIDs.Set(updateParam.TeamIds))
+		if room.Status != postgresql.RoomStatusPREPARING {
+			return nil, fmt.Errorf("teams of room %s can only be changed while preparing", room.Code)
+		}
+		if err := validateBattlegroundRoomTeams(ctx, db, updateParam.TeamIds, room.MaxTeams); err != nil {
+			return nil, err
+		}
+		room.TeamIDs = updateParam.TeamIds
+		room.ReadyTeamIDs = intersectTeamIDs(room.ReadyTeamIDs, room.TeamIDs)
+		params = append(params,
+			postgresql.BattlegroundRoom.TeamIDs.Set(room.TeamIDs),
+			postgresql.BattlegroundRoom.ReadyTeamIDs.Set(room.ReadyTeamIDs),
+		)
 	}
+	if updateParam.Status != nil && postgresql.RoomStatus(*updateParam.Status) != room.Status {
+		transitionParams, err := transitionBattlegroundRoom(room, postgresql.RoomStatus(*updateParam.Status))
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, transitionParams...)
 
-	updatedBattlegroundRoom, err := db.BattlegroundRoom.FindUnique(param).Update(
-		params...,
-	).Exec(ctx)
+		// the schedule is created along with the start of the room, the rounds of a
+		// room are unique so a room cannot be started twice
+		if postgresql.RoomStatus(*updateParam.Status) == postgresql.RoomStatusONGOING {
+			createRounds, err := scheduleBattlegroundRounds(ctx, db, room)
+			if err != nil {
+				return nil, err
+			}
+			startingPoints, err := getBattlegroundStartingPoints(ctx, db, room.TeamIDs)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, postgresql.BattlegroundRoom.StartingPoints.Set(startingPoints))
+			updateRoom := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(room.Code)).Update(
+				append(params, postgresql.BattlegroundRoom.UpdatedAt.Set(time.Now()))...,
+			).Tx()
+			if err := db.Prisma.Transaction(append([]transaction.Param{updateRoom}, createRounds...)...).Exec(ctx); err != nil {
+				return nil, err
+			}
+			recordBattlegroundActivity(ctx, db, room.Code, nil, postgresql.BattlegroundActivityTypeSTARTED, nil, "The battle has started")
+			return model.MapToBattlegroundRoom(updateRoom.Result())
+		}
+	}
+
+	battlegroundRoom, err := updateBattlegroundRoom(ctx, db, room, params...)
 	if err != nil {
 		return nil, err
 	}
+	if updateParam.Status != nil && *updateParam.Status == model.RoomStatusEnded && room.Status != postgresql.RoomStatusENDED {
+		recordBattlegroundActivity(ctx, db, room.Code, nil, postgresql.BattlegroundActivityTypeENDED, nil, "The battle has ended")
+		// the room has ended either way, a tournament that cannot advance is advanced again
+		// by the crew
+		if err := advanceTournament(ctx, db, room.Code); err != nil {
+			return nil, fmt.Errorf("room %s has ended but its tournament could not advance, advance it to retry: %v", room.Code, err)
+		}
+	}
 
-	// parse battlegroundRoom to graphql type
-	battlegroundRoom, err := model.MapToBattlegroundRoom(updatedBattlegroundRoom)
+	return battlegroundRoom, nil
+}
+
+// scheduleBattlegroundRounds plans the rounds of a room from its teams, pairing and seed.
+func scheduleBattlegroundRounds(ctx context.Context, db *postgresql.PrismaClient, room *postgresql.BattlegroundRoomModel) ([]transaction.Param, error) {
+	users, err := db.User.FindMany(postgresql.User.TeamID.In(room.TeamIDs)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	members := make(map[string][]string)
+	for _, user := range users {
+		teamID, _ := user.TeamID()
+		members[teamID] = append(members[teamID], user.Username)
+	}
+
+	roundCount, _ := room.RoundCount()
+	rounds, err := battleground.Plan(room.TeamIDs, members, battleground.Pairing(room.Pairing), roundCount, int64(room.Seed))
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []transaction.Param
+	for i, round := range rounds {
+		// the clock of the first round starts with the room
+		var deadline *time.Time
+		if i == 0 {
+			first := time.Now().Add(time.Duration(room.SelectionTimeout) * time.Second)
+			deadline = &first
+		}
+		txs = append(txs, db.BattlegroundRound.CreateOne(
+			postgresql.BattlegroundRound.Code.Set(room.Code),
+			postgresql.BattlegroundRound.Round.Set(i+1),
+			postgresql.BattlegroundRound.UpdatedAt.Set(time.Now()),
+			postgresql.BattlegroundRound.UserBattlegroundRoundAttackerToUser.Link(postgresql.User.Username.Equals(round.Attacker)),
+			postgresql.BattlegroundRound.UserBattlegroundRoundDefenderToUser.Link(postgresql.User.Username.Equals(round.Defender)),
+			postgresql.BattlegroundRound.AttackerTeamID.Set(round.AttackerTeam),
+			postgresql.BattlegroundRound.DefenderTeamID.Set(round.DefenderTeam),
+			postgresql.BattlegroundRound.Deadline.SetIfPresent(deadline),
+		).Tx())
+	}
+	return txs, nil
+}
+
+// JoinBattlegroundRoom adds the team of a user to a room that is being prepared.
+func JoinBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, userID string, code string) (*model.BattlegroundRoom, error) {
+	teamID, err := getUserTeamID(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	room, err := getPreparingBattlegroundRoom(ctx, db, code)
+	if err != nil {
+		return nil, err
+	}
+	if containsTeamID(room.TeamIDs, teamID) {
+		return nil, fmt.Errorf("your team has already joined room %s", code)
+	}
+	if len(room.TeamIDs) >= room.MaxTeams {
+		return nil, fmt.Errorf("room %s is full", code)
+	}
+
+	battlegroundRoom, err := updateBattlegroundRoom(ctx, db, room,
+		postgresql.BattlegroundRoom.TeamIDs.Set(append(room.TeamIDs, teamID)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	recordBattlegroundActivity(ctx, db, code, nil, postgresql.BattlegroundActivityTypeJOINED, &teamID,
+		fmt.Sprintf("%s joined the room", battlegroundTeamName(ctx, db, teamID)))
+
+	return battlegroundRoom, nil
+}
+
+// LeaveBattlegroundRoom removes the team of a user from a room that is being prepared.
+func LeaveBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, userID string, code string) (*model.BattlegroundRoom, error) {
+	teamID, err := getUserTeamID(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	room, err := getPreparingBattlegroundRoom(ctx, db, code)
+	if err != nil {
+		return nil, err
+	}
+	if !containsTeamID(room.TeamIDs, teamID) {
+		return nil, fmt.Errorf("your team is not in room %s", code)
+	}
+
+	battlegroundRoom, err := updateBattlegroundRoom(ctx, db, room,
+		postgresql.BattlegroundRoom.TeamIDs.Set(removeTeamID(room.TeamIDs, teamID)),
+		postgresql.BattlegroundRoom.ReadyTeamIDs.Set(removeTeamID(room.ReadyTeamIDs, teamID)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	recordBattlegroundActivity(ctx, db, code, nil, postgresql.BattlegroundActivityTypeLEFT, &teamID,
+		fmt.Sprintf("%s left the room", battlegroundTeamName(ctx, db, teamID)))
+
+	return battlegroundRoom, nil
+}
+
+// SetBattlegroundReady marks the team of a user as ready or not in a room that is
+// being prepared, the room can only start once every team is ready.
+func SetBattlegroundReady(ctx context.Context, db *postgresql.PrismaClient, userID string, code string, ready bool) (*model.BattlegroundRoom, error) {
+	teamID, err := getUserTeamID(ctx, db, userID)
+	if err != nil {
+		return nil, err
+	}
+	room, err := getPreparingBattlegroundRoom(ctx, db, code)
+	if err != nil {
+		return nil, err
+	}
+	if !containsTeamID(room.TeamIDs, teamID) {
+		return nil, fmt.Errorf("your team is not in room %s", code)
+	}
+
+	readyTeamIDs := removeTeamID(room.ReadyTeamIDs, teamID)
+	if ready {
+		readyTeamIDs = append(readyTeamIDs, teamID)
+	}
+
+	battlegroundRoom, err := updateBattlegroundRoom(ctx, db, room,
+		postgresql.BattlegroundRoom.ReadyTeamIDs.Set(readyTeamIDs),
+	)
 	if err != nil {
 		return nil, err
 	}
+	message := "%s is ready"
+	if !ready {
+		message = "%s is not ready anymore"
+	}
+	recordBattlegroundActivity(ctx, db, code, nil, postgresql.BattlegroundActivityTypeREADY, &teamID,
+		fmt.Sprintf(message, battlegroundTeamName(ctx, db, teamID)))
 
 	return battlegroundRoom, nil
 }
+
+func StartBattleground(ctx context.Context, db *postgresql.PrismaClient, code string) (*model.BattlegroundRoom, error) {
+	status := model.RoomStatusOngoing
+	return UpdateUniqueBattlegroundRoom(ctx, db, postgresql.BattlegroundRoom.Code.Equals(code), &model.UpdateBattlegroundRoomInput{Status: &status})
+}
+
+func EndBattleground(ctx context.Context, db *postgresql.PrismaClient, code string) (*model.BattlegroundRoom, error) {
+	status := model.RoomStatusEnded
+	return UpdateUniqueBattlegroundRoom(ctx, db, postgresql.BattlegroundRoom.Code.Equals(code), &model.UpdateBattlegroundRoomInput{Status: &status})
+}
+
+// transitionBattlegroundRoom checks that a room can move to the given status and returns
+// the fields to update, a room only starts with enough teams that are all ready.
+func transitionBattlegroundRoom(room *postgresql.BattlegroundRoomModel, status postgresql.RoomStatus) ([]postgresql.BattlegroundRoomSetParam, error) {
+	allowed := false
+	for _, next := range battlegroundRoomTransitions[room.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("room %s cannot go from %s to %s", room.Code, room.Status, status)
+	}
+
+	params := []postgresql.BattlegroundRoomSetParam{postgresql.BattlegroundRoom.Status.Set(status)}
+	switch status {
+	case postgresql.RoomStatusONGOING:
+		if len(room.TeamIDs) < room.MinTeams {
+			return nil, fmt.Errorf("room %s needs at least %d teams to start", room.Code, room.MinTeams)
+		}
+		for _, teamID := range room.TeamIDs {
+			if !containsTeamID(room.ReadyTeamIDs, teamID) {
+				return nil, fmt.Errorf("team %s in room %s is not ready", teamID, room.Code)
+			}
+		}
+		params = append(params, postgresql.BattlegroundRoom.StartedAt.Set(time.Now()))
+	case postgresql.RoomStatusENDED:
+		params = append(params, postgresql.BattlegroundRoom.EndedAt.Set(time.Now()))
+	}
+
+	return params, nil
+}
+
+// updateBattlegroundRoom applies the update only when the room was not modified since it
+// was fetched, so concurrent joins and ready checks cannot overwrite each other.
+func updateBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, room *postgresql.BattlegroundRoomModel, params ...postgresql.BattlegroundRoomSetParam) (*model.BattlegroundRoom, error) {
+	params = append(params, postgresql.BattlegroundRoom.UpdatedAt.Set(time.Now()))
+	result, err := db.BattlegroundRoom.FindMany(
+		postgresql.BattlegroundRoom.Code.Equals(room.Code),
+		postgresql.BattlegroundRoom.UpdatedAt.Equals(room.UpdatedAt),
+	).Update(params...).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Count == 0 {
+		return nil, fmt.Errorf("room %s was changed by someone else, try again", room.Code)
+	}
+
+	return GetUniqueBattlegroundRoom(ctx, db, postgresql.BattlegroundRoom.Code.Equals(room.Code))
+}
+
+// createWithBattlegroundRoomCode runs create with random room codes until one is not
+// taken by another room, it returns the code that was used.
+func createWithBattlegroundRoomCode(create func(code string) error) (string, error) {
+	codes, err := createWithBattlegroundRoomCodes(1, func(codes []string) error {
+		return create(codes[0])
+	})
+	if err != nil {
+		return "", err
+	}
+	return codes[0], nil
+}
+
+// createWithBattlegroundRoomCodes picks a number of different codes for rooms created
+// together, all of the codes are picked again when one of them is taken.
+func createWithBattlegroundRoomCodes(n int, create func(codes []string) error) ([]string, error) {
+	for i := 0; i < battlegroundRoomCodeAttempts; i++ {
+		picked := make(map[string]bool)
+		var codes []string
+		for len(codes) < n {
+			code := gofakeit.Regex(battlegroundRoomCodePattern)
+			if !picked[code] {
+				picked[code] = true
+				codes = append(codes, code)
+			}
+		}
+		err := create(codes)
+		if err == nil {
+			return codes, nil
+		}
+		if !isUniqueConstraintError(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("unable to find an unused room code after %d attempts", battlegroundRoomCodeAttempts)
+}
+
+// isUniqueConstraintError reports whether a write failed because it would duplicate a
+// unique field, the client only reports it in the message.
+func isUniqueConstraintError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "Unique constraint failed")
+}
+
+func validateBattlegroundRoomTeams(ctx context.Context, db *postgresql.PrismaClient, teamIDs []string, maxTeams int) error {
+	if len(teamIDs) > maxTeams {
+		return fmt.Errorf("a room takes at most %d teams", maxTeams)
+	}
+	seen := make(map[string]bool)
+	for _, teamID := range teamIDs {
+		if seen[teamID] {
+			return fmt.Errorf("team %s is listed more than once", teamID)
+		}
+		seen[teamID] = true
+	}
+	if len(teamIDs) == 0 {
+		return nil
+	}
+
+	teams, err := db.Team.FindMany(postgresql.Team.ID.In(teamIDs)).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if len(teams) != len(teamIDs) {
+		return fmt.Errorf("some of the teams do not exist")
+	}
+	return nil
+}
+
+func getPreparingBattlegroundRoom(ctx context.Context, db *postgresql.PrismaClient, code string) (*postgresql.BattlegroundRoomModel, error) {
+	room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if room.Status != postgresql.RoomStatusPREPARING {
+		return nil, fmt.Errorf("room %s is %s", code, room.Status)
+	}
+	return room, nil
+}
+
+func getUserTeamID(ctx context.Context, db *postgresql.PrismaClient, userID string) (string, error) {
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+	if err != nil {
+		return "", err
+	}
+	teamID, ok := user.TeamID()
+	if !ok {
+		return "", fmt.Errorf("you are not in a team")
+	}
+	return teamID, nil
+}
+
+func containsTeamID(teamIDs []string, teamID string) bool {
+	for _, id := range teamIDs {
+		if id == teamID {
+			return true
+		}
+	}
+	return false
+}
+
+func removeTeamID(teamIDs []string, teamID string) []string {
+	result := []string{}
+	for _, id := range teamIDs {
+		if id != teamID {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func intersectTeamIDs(teamIDs []string, others []string) []string {
+	result := []string{}
+	for _, id := range teamIDs {
+		if containsTeamID(others, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
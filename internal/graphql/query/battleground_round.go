@@ -0,0 +1,562 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/marcustut/thebox/internal/battleground"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+func GetUniqueBattlegroundRound(ctx context.Context, db *postgresql.PrismaClient, param postgresql.BattlegroundRoundEqualsUniqueWhereParam) (*model.BattlegroundRound, error) {
+	// fetch the battlegroundRound
+	fetchedBattlegroundRound, err := db.BattlegroundRound.FindUnique(param).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse battlegroundRound to graphql type
+	battlegroundRound, err := model.MapToBattlegroundRound(fetchedBattlegroundRound)
+	if err != nil {
+		return nil, err
+	}
+
+	return battlegroundRound, nil
+}
+
+func GetManyBattlegroundRound(ctx context.Context, db *postgresql.PrismaClient, params ...postgresql.BattlegroundRoundWhereParam) ([]*model.BattlegroundRound, error) {
+	// fetch the battlegroundRounds in order
+	fetchedBattlegroundRounds, err := db.BattlegroundRound.FindMany(params...).OrderBy(
+		postgresql.BattlegroundRound.Round.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse battlegroundRounds to graphql type
+	battlegroundRounds, err := model.MapToBattlegroundRounds(fetchedBattlegroundRounds)
+	if err != nil {
+		return nil, err
+	}
+
+	return battlegroundRounds, nil
+}
+
+// GetBattlegroundHistory returns every round a team played, oldest first. Rounds
+// against the bot of a practice room are left out.
+func GetBattlegroundHistory(ctx context.Context, db *postgresql.PrismaClient, teamID string) ([]*model.BattlegroundRound, error) {
+	// fetch the battlegroundRounds of the team in order
+	fetchedBattlegroundRounds, err := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Or(
+			postgresql.BattlegroundRound.AttackerTeamID.Equals(teamID),
+			postgresql.BattlegroundRound.DefenderTeamID.Equals(teamID),
+		),
+	).OrderBy(
+		postgresql.BattlegroundRound.CreatedAt.Order(postgresql.ASC),
+		postgresql.BattlegroundRound.Round.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// drop the rounds of practice rooms
+	var codes []string
+	for _, fetchedBattlegroundRound := range fetchedBattlegroundRounds {
+		codes = append(codes, fetchedBattlegroundRound.Code)
+	}
+	practiceRooms, err := db.BattlegroundRoom.FindMany(
+		postgresql.BattlegroundRoom.Code.In(codes),
+		postgresql.BattlegroundRoom.Practice.Equals(true),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	practice := make(map[string]bool)
+	for _, practiceRoom := range practiceRooms {
+		practice[practiceRoom.Code] = true
+	}
+	var playedRounds []postgresql.BattlegroundRoundModel
+	for _, fetchedBattlegroundRound := range fetchedBattlegroundRounds {
+		if !practice[fetchedBattlegroundRound.Code] {
+			playedRounds = append(playedRounds, fetchedBattlegroundRound)
+		}
+	}
+	fetchedBattlegroundRounds = playedRounds
+
+	// parse battlegroundRounds to graphql type
+	battlegroundRounds, err := model.MapToBattlegroundRounds(fetchedBattlegroundRounds)
+	if err != nil {
+		return nil, err
+	}
+
+	return battlegroundRounds, nil
+}
+
+// GetBattlegroundReplay returns a room together with every one of its rounds in order.
+func GetBattlegroundReplay(ctx context.Context, db *postgresql.PrismaClient, code string) (*model.BattlegroundReplay, error) {
+	room, err := GetUniqueBattlegroundRoom(ctx, db, postgresql.BattlegroundRoom.Code.Equals(code))
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := GetManyBattlegroundRound(ctx, db, postgresql.BattlegroundRound.Code.Equals(code))
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BattlegroundReplay{Room: room, Rounds: rounds}, nil
+}
+
+// GetCurrentBattlegroundRound returns the round being played in an ongoing room, that
+// is the first round with a deadline that is not settled yet.
+func GetCurrentBattlegroundRound(ctx context.Context, db *postgresql.PrismaClient, code string) (*model.BattlegroundRound, error) {
+	room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if room.Status != postgresql.RoomStatusONGOING {
+		return nil, postgresql.ErrNotFound
+	}
+
+	fetchedBattlegroundRound, err := db.BattlegroundRound.FindFirst(
+		postgresql.BattlegroundRound.Code.Equals(code),
+		postgresql.BattlegroundRound.SettledAt.IsNull(),
+		postgresql.BattlegroundRound.Not(postgresql.BattlegroundRound.Deadline.IsNull()),
+	).OrderBy(
+		postgresql.BattlegroundRound.Round.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse battlegroundRound to graphql type
+	battlegroundRound, err := model.MapToBattlegroundRound(fetchedBattlegroundRound)
+	if err != nil {
+		return nil, err
+	}
+
+	return battlegroundRound, nil
+}
+
+// GetBattlegroundTimer returns the deadline of the round being played in a room, for
+// clients that poll instead of subscribing. A round past its deadline is settled first,
+// so the deadlines hold without a timer running. Nil is returned when no round is
+// being played.
+func GetBattlegroundTimer(ctx context.Context, db *postgresql.PrismaClient, code string, now time.Time) (*model.BattlegroundTimer, error) {
+	round, err := GetCurrentBattlegroundRound(ctx, db, code)
+	if err == nil && !now.Before(*round.Deadline) {
+		if _, err := SettleBattlegroundRound(ctx, db, code, round.Round, now); err != nil {
+			return nil, err
+		}
+		round, err = GetCurrentBattlegroundRound(ctx, db, code)
+	}
+	if err == postgresql.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := round.Deadline.Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &model.BattlegroundTimer{
+		Code:      code,
+		Round:     round.Round,
+		Deadline:  *round.Deadline,
+		Remaining: int(math.Ceil(remaining.Seconds())),
+	}, nil
+}
+
+// SettleExpiredBattlegroundRounds settles the rounds of the ongoing rooms that are past
+// their deadline, for when no timer is running to settle them. It returns the codes of
+// the rooms a round was settled in.
+func SettleExpiredBattlegroundRounds(ctx context.Context, db *postgresql.PrismaClient, now time.Time) ([]string, error) {
+	rooms, err := db.BattlegroundRoom.FindMany(
+		postgresql.BattlegroundRoom.Status.Equals(postgresql.RoomStatusONGOING),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rooms) == 0 {
+		return nil, nil
+	}
+	codes := make([]string, 0, len(rooms))
+	for _, room := range rooms {
+		codes = append(codes, room.Code)
+	}
+
+	expiredRounds, err := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Code.In(codes),
+		postgresql.BattlegroundRound.SettledAt.IsNull(),
+		postgresql.BattlegroundRound.Deadline.BeforeEquals(now),
+	).OrderBy(
+		postgresql.BattlegroundRound.Round.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var settled []string
+	for _, round := range expiredRounds {
+		if _, err := SettleBattlegroundRound(ctx, db, round.Code, round.Round, now); err != nil {
+			return settled, fmt.Errorf("unable to settle round %d of room %s: %w", round.Round, round.Code, err)
+		}
+		settled = append(settled, round.Code)
+	}
+
+	return settled, nil
+}
+
+// SubmitBattlegroundSelection records the selection of the attacker or defender of the
+// round being played, the round is settled as soon as both have selected.
+func SubmitBattlegroundSelection(ctx context.Context, db *postgresql.PrismaClient, userID string, code string, round int, selection model.BattlegroundSelection, now time.Time) (*model.BattlegroundRound, error) {
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	room, fetchedRound, err := getPlayingBattlegroundRound(ctx, db, code, round)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, _ := fetchedRound.Deadline(); !now.Before(deadline) {
+		return nil, fmt.Errorf("time is up for round %d", round)
+	}
+
+	// only the players of the round can select, and only once
+	var where postgresql.BattlegroundRoundWhereParam
+	var set postgresql.BattlegroundRoundSetParam
+	var teamID string
+	if attacker, ok := fetchedRound.Attacker(); ok && attacker == user.Username {
+		where = postgresql.BattlegroundRound.AttackerSelection.IsNull()
+		set = postgresql.BattlegroundRound.AttackerSelection.Set(postgresql.BattlegroundSelection(selection))
+		teamID, _ = fetchedRound.AttackerTeamID()
+	} else if defender, ok := fetchedRound.Defender(); ok && defender == user.Username {
+		where = postgresql.BattlegroundRound.DefenderSelection.IsNull()
+		set = postgresql.BattlegroundRound.DefenderSelection.Set(postgresql.BattlegroundSelection(selection))
+		teamID, _ = fetchedRound.DefenderTeamID()
+	} else {
+		return nil, fmt.Errorf("you are not playing round %d", round)
+	}
+
+	result, err := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Code.Equals(code),
+		postgresql.BattlegroundRound.Round.Equals(round),
+		postgresql.BattlegroundRound.SettledAt.IsNull(),
+		where,
+	).Update(
+		set,
+		postgresql.BattlegroundRound.UpdatedAt.Set(now),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Count == 0 {
+		return nil, fmt.Errorf("you have already selected for round %d", round)
+	}
+	recordBattlegroundActivity(ctx, db, code, &round, postgresql.BattlegroundActivityTypeLOCKED, &teamID,
+		fmt.Sprintf("%s locked in for round %d", battlegroundTeamName(ctx, db, teamID), round))
+
+	// settle right away once both have selected
+	fetchedRound, err = db.BattlegroundRound.FindUnique(battlegroundRoundCodeRound(code, round)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	attackerSelection, attackerSelected := fetchedRound.AttackerSelection()
+	defenderSelection, defenderSelected := fetchedRound.DefenderSelection()
+	if room.Practice {
+		// the bot only picks once the player has locked in
+		bot, err := getBattlegroundBotSelection(ctx, db, room, round)
+		if err != nil {
+			return nil, err
+		}
+		defenderSelection, defenderSelected = postgresql.BattlegroundSelection(bot), true
+	}
+	if attackerSelected && defenderSelected {
+		a, d := battleground.Selection(attackerSelection), battleground.Selection(defenderSelection)
+		if err := settleBattlegroundRound(ctx, db, room, fetchedRound, &a, &d, false, false, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return GetUniqueBattlegroundRound(ctx, db, battlegroundRoundCodeRound(code, round))
+}
+
+// SettleBattlegroundRound settles a round once its deadline has passed. Depending on the
+// timeout policy of the room a player without a selection forfeits the round or gets a
+// random selection. A round that is settled already is returned as it is.
+func SettleBattlegroundRound(ctx context.Context, db *postgresql.PrismaClient, code string, round int, now time.Time) (*model.BattlegroundRound, error) {
+	settled, err := GetUniqueBattlegroundRound(ctx, db, battlegroundRoundCodeRound(code, round))
+	if err != nil {
+		return nil, err
+	}
+	if settled.SettledAt != nil {
+		return settled, nil
+	}
+
+	room, fetchedRound, err := getPlayingBattlegroundRound(ctx, db, code, round)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, _ := fetchedRound.Deadline(); now.Before(deadline) {
+		return nil, fmt.Errorf("round %d is still being played", round)
+	}
+
+	// the seed of the room keeps random selections reproducible
+	rng := rand.New(rand.NewSource(int64(room.Seed) + int64(round)))
+	fill := func(selection postgresql.BattlegroundSelection, ok bool) (*battleground.Selection, bool) {
+		if ok {
+			s := battleground.Selection(selection)
+			return &s, false
+		}
+		if room.TimeoutPolicy == postgresql.BattlegroundTimeoutPolicyRANDOM {
+			s := battleground.RandomSelection(rng)
+			return &s, true
+		}
+		return nil, true
+	}
+	attackerSelection, attackerTimedOut := fill(fetchedRound.AttackerSelection())
+	defenderSelection, defenderTimedOut := fill(fetchedRound.DefenderSelection())
+	if room.Practice {
+		bot, err := getBattlegroundBotSelection(ctx, db, room, round)
+		if err != nil {
+			return nil, err
+		}
+		defenderSelection, defenderTimedOut = &bot, false
+	}
+
+	if err := settleBattlegroundRound(ctx, db, room, fetchedRound, attackerSelection, defenderSelection, attackerTimedOut, defenderTimedOut, now); err != nil {
+		return nil, err
+	}
+
+	return GetUniqueBattlegroundRound(ctx, db, battlegroundRoundCodeRound(code, round))
+}
+
+// settleBattlegroundRound records the outcome of a round and starts the clock of the
+// next one in a single transaction. Only the first settlement of a round counts, so the
+// timer and the last selection cannot both settle it.
+func settleBattlegroundRound(ctx context.Context, db *postgresql.PrismaClient, room *postgresql.BattlegroundRoomModel, round *postgresql.BattlegroundRoundModel, attackerSelection *battleground.Selection, defenderSelection *battleground.Selection, attackerTimedOut bool, defenderTimedOut bool, now time.Time) error {
+	winner := battleground.Outcome(attackerSelection, defenderSelection)
+
+	// the points stay as they are until a box is opened for the round
+	attackerPoints, defenderPoints, err := getBattlegroundRoundTeamPoints(ctx, db, room, round)
+	if err != nil {
+		return err
+	}
+
+	params := []postgresql.BattlegroundRoundSetParam{
+		postgresql.BattlegroundRound.Winner.Set(postgresql.BattlegroundWinner(winner)),
+		postgresql.BattlegroundRound.AttackerPointsBefore.Set(attackerPoints),
+		postgresql.BattlegroundRound.AttackerPointsAfter.Set(attackerPoints),
+		postgresql.BattlegroundRound.DefenderPointsBefore.Set(defenderPoints),
+		postgresql.BattlegroundRound.DefenderPointsAfter.Set(defenderPoints),
+		postgresql.BattlegroundRound.AttackerTimedOut.Set(attackerTimedOut),
+		postgresql.BattlegroundRound.DefenderTimedOut.Set(defenderTimedOut),
+		postgresql.BattlegroundRound.SettledAt.Set(now),
+		postgresql.BattlegroundRound.UpdatedAt.Set(now),
+	}
+	if attackerSelection != nil {
+		params = append(params, postgresql.BattlegroundRound.AttackerSelection.Set(postgresql.BattlegroundSelection(*attackerSelection)))
+	}
+	if defenderSelection != nil {
+		params = append(params, postgresql.BattlegroundRound.DefenderSelection.Set(postgresql.BattlegroundSelection(*defenderSelection)))
+	}
+
+	// nobody ends a practice room, it is over after its last round
+	_, err = db.BattlegroundRound.FindUnique(battlegroundRoundCodeRound(round.Code, round.Round+1)).Exec(ctx)
+	if err != nil && err != postgresql.ErrNotFound {
+		return err
+	}
+	last := err == postgresql.ErrNotFound
+
+	// the round is settled, the clock of the next round started and a finished practice
+	// ended together, a round someone else settled first leaves the rest alone
+	settleRound := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Code.Equals(round.Code),
+		postgresql.BattlegroundRound.Round.Equals(round.Round),
+		postgresql.BattlegroundRound.SettledAt.IsNull(),
+	).Update(params...).Tx()
+	startNext := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Code.Equals(round.Code),
+		postgresql.BattlegroundRound.Round.Equals(round.Round+1),
+		postgresql.BattlegroundRound.Deadline.IsNull(),
+	).Update(
+		postgresql.BattlegroundRound.Deadline.Set(now.Add(time.Duration(room.SelectionTimeout)*time.Second)),
+		postgresql.BattlegroundRound.UpdatedAt.Set(now),
+	).Tx()
+	txs := []transaction.Param{settleRound, startNext}
+	endPractice := room.Practice && last
+	if endPractice {
+		txs = append(txs, db.BattlegroundRoom.FindMany(
+			postgresql.BattlegroundRoom.Code.Equals(round.Code),
+			postgresql.BattlegroundRoom.Status.Equals(postgresql.RoomStatusONGOING),
+		).Update(
+			postgresql.BattlegroundRoom.Status.Set(postgresql.RoomStatusENDED),
+			postgresql.BattlegroundRoom.EndedAt.Set(now),
+			postgresql.BattlegroundRoom.UpdatedAt.Set(now),
+		).Tx())
+	}
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return err
+	}
+	if settleRound.Result().Count == 0 {
+		return nil
+	}
+
+	recordBattlegroundSettlement(ctx, db, round, winner, attackerTimedOut, defenderTimedOut)
+	if endPractice {
+		recordBattlegroundActivity(ctx, db, round.Code, nil, postgresql.BattlegroundActivityTypeENDED, nil, "The practice has ended")
+	}
+	return nil
+}
+
+func getPlayingBattlegroundRound(ctx context.Context, db *postgresql.PrismaClient, code string, round int) (*postgresql.BattlegroundRoomModel, *postgresql.BattlegroundRoundModel, error) {
+	room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if room.Status != postgresql.RoomStatusONGOING {
+		return nil, nil, fmt.Errorf("room %s is %s", code, room.Status)
+	}
+
+	fetchedRound, err := db.BattlegroundRound.FindUnique(battlegroundRoundCodeRound(code, round)).Exec(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := fetchedRound.SettledAt(); ok {
+		return nil, nil, fmt.Errorf("round %d is over", round)
+	}
+	if _, ok := fetchedRound.Deadline(); !ok {
+		return nil, nil, fmt.Errorf("round %d has not started", round)
+	}
+
+	return room, fetchedRound, nil
+}
+
+// recordBattlegroundSettlement adds the outcome of a round to the activity log of the room.
+func recordBattlegroundSettlement(ctx context.Context, db *postgresql.PrismaClient, round *postgresql.BattlegroundRoundModel, winner battleground.Winner, attackerTimedOut bool, defenderTimedOut bool) {
+	attackerTeamID, _ := round.AttackerTeamID()
+	attacker := battlegroundTeamName(ctx, db, attackerTeamID)
+	// the bot of a practice room has no team
+	var defenderTeamID *string
+	defender := BattlegroundBotName
+	if res, ok := round.DefenderTeamID(); ok {
+		defenderTeamID = &res
+		defender = battlegroundTeamName(ctx, db, res)
+	}
+
+	if attackerTimedOut {
+		recordBattlegroundActivity(ctx, db, round.Code, &round.Round, postgresql.BattlegroundActivityTypeTIMEDOUT, &attackerTeamID,
+			fmt.Sprintf("%s ran out of time in round %d", attacker, round.Round))
+	}
+	if defenderTimedOut {
+		recordBattlegroundActivity(ctx, db, round.Code, &round.Round, postgresql.BattlegroundActivityTypeTIMEDOUT, defenderTeamID,
+			fmt.Sprintf("%s ran out of time in round %d", defender, round.Round))
+	}
+
+	switch winner {
+	case battleground.WinnerAttacker:
+		recordBattlegroundActivity(ctx, db, round.Code, &round.Round, postgresql.BattlegroundActivityTypeSETTLED, &attackerTeamID,
+			fmt.Sprintf("%s beat %s in round %d", attacker, defender, round.Round))
+	case battleground.WinnerDefender:
+		recordBattlegroundActivity(ctx, db, round.Code, &round.Round, postgresql.BattlegroundActivityTypeSETTLED, defenderTeamID,
+			fmt.Sprintf("%s beat %s in round %d", defender, attacker, round.Round))
+	default:
+		recordBattlegroundActivity(ctx, db, round.Code, &round.Round, postgresql.BattlegroundActivityTypeSETTLED, nil,
+			fmt.Sprintf("%s and %s drew round %d", attacker, defender, round.Round))
+	}
+}
+
+// getBattlegroundRoundTeamPoints fetches the points of the attacking and the defending
+// team of a round, the points of a practice room are kept apart from the teams.
+func getBattlegroundRoundTeamPoints(ctx context.Context, db *postgresql.PrismaClient, room *postgresql.BattlegroundRoomModel, round *postgresql.BattlegroundRoundModel) (float64, float64, error) {
+	if room.Practice {
+		return getPracticeBattlegroundPoints(ctx, db, room.Code)
+	}
+
+	attackerTeamID, attackerOk := round.AttackerTeamID()
+	defenderTeamID, defenderOk := round.DefenderTeamID()
+	if !attackerOk || !defenderOk {
+		return 0, 0, fmt.Errorf("round %d has no teams", round.Round)
+	}
+
+	points, err := getBattlegroundRoomPoints(ctx, db, room)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return points[attackerTeamID], points[defenderTeamID], nil
+}
+
+// getBattlegroundRoomPoints gives the points of each team in a room, which are the points
+// the team had when the room started plus what it made in the settled rounds. Points the
+// team makes elsewhere while the room is running do not change the outcome of a round.
+// Rooms started before the points were kept fall back to the points of the teams.
+func getBattlegroundRoomPoints(ctx context.Context, db *postgresql.PrismaClient, room *postgresql.BattlegroundRoomModel) (map[string]float64, error) {
+	points := make(map[string]float64)
+	if len(room.StartingPoints) != len(room.TeamIDs) {
+		teams, err := db.Team.FindMany(postgresql.Team.ID.In(room.TeamIDs)).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, team := range teams {
+			points[team.ID] = team.Points
+		}
+		return points, nil
+	}
+
+	for i, teamID := range room.TeamIDs {
+		points[teamID] = room.StartingPoints[i]
+	}
+	rounds, err := db.BattlegroundRound.FindMany(
+		postgresql.BattlegroundRound.Code.Equals(room.Code),
+		postgresql.BattlegroundRound.Not(postgresql.BattlegroundRound.SettledAt.IsNull()),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, round := range rounds {
+		if teamID, ok := round.AttackerTeamID(); ok {
+			before, _ := round.AttackerPointsBefore()
+			after, _ := round.AttackerPointsAfter()
+			points[teamID] += after - before
+		}
+		if teamID, ok := round.DefenderTeamID(); ok {
+			before, _ := round.DefenderPointsBefore()
+			after, _ := round.DefenderPointsAfter()
+			points[teamID] += after - before
+		}
+	}
+	return points, nil
+}
+
+// getBattlegroundStartingPoints takes the points of the teams of a room in the order of
+// its teams, to be kept on the room when it starts.
+func getBattlegroundStartingPoints(ctx context.Context, db *postgresql.PrismaClient, teamIDs []string) ([]float64, error) {
+	teams, err := db.Team.FindMany(postgresql.Team.ID.In(teamIDs)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	points := make(map[string]float64)
+	for _, team := range teams {
+		points[team.ID] = team.Points
+	}
+
+	startingPoints := make([]float64, len(teamIDs))
+	for i, teamID := range teamIDs {
+		startingPoints[i] = points[teamID]
+	}
+	return startingPoints, nil
+}
+
+func battlegroundRoundCodeRound(code string, round int) postgresql.BattlegroundRoundEqualsUniqueWhereParam {
+	return postgresql.BattlegroundRound.CodeRound(
+		postgresql.BattlegroundRound.Code.Equals(code),
+		postgresql.BattlegroundRound.Round.Equals(round),
+	)
+}
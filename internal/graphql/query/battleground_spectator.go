@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"sort"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// GetBattlegroundSpectatorView returns what spectators of a room get to see.
+func GetBattlegroundSpectatorView(ctx context.Context, db *postgresql.PrismaClient, code string) (*model.BattlegroundSpectatorView, error) {
+	room, err := GetUniqueBattlegroundRoom(ctx, db, postgresql.BattlegroundRoom.Code.Equals(code))
+	if err != nil {
+		return nil, err
+	}
+	rounds, err := GetManyBattlegroundRound(ctx, db, postgresql.BattlegroundRound.Code.Equals(code))
+	if err != nil {
+		return nil, err
+	}
+	activity, err := GetManyBattlegroundActivity(ctx, db, code, BattlegroundActivityLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	var currentRound *model.BattlegroundRound
+	for _, round := range rounds {
+		if currentRound == nil && round.SettledAt == nil && round.Deadline != nil && room.Status == model.RoomStatusOngoing {
+			currentRound = round
+		}
+	}
+
+	standings, err := getBattlegroundStandings(ctx, db, room.Code, rounds)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.BattlegroundSpectatorView{
+		Code:          room.Code,
+		Status:        room.Status,
+		StartedAt:     room.StartedAt,
+		EndedAt:       room.EndedAt,
+		OpenedEffects: room.OpenedEffects,
+		CurrentRound:  currentRound,
+		Rounds:        rounds,
+		Standings:     standings,
+		Activity:      activity,
+	}, nil
+}
+
+// getBattlegroundStandings ranks the teams of a room by their points in the room, teams
+// with the same points share a rank. The change is how many points a team made in the room.
+func getBattlegroundStandings(ctx context.Context, db *postgresql.PrismaClient, code string, rounds []*model.BattlegroundRound) ([]*model.BattlegroundStanding, error) {
+	room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	points, err := getBattlegroundRoomPoints(ctx, db, room)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := make(map[string]*model.BattlegroundStanding)
+	var result []*model.BattlegroundStanding
+	for _, teamID := range room.TeamIDs {
+		teamPoints, ok := points[teamID]
+		if !ok {
+			continue
+		}
+		standing := &model.BattlegroundStanding{TeamID: teamID, Points: teamPoints}
+		standings[teamID] = standing
+		result = append(result, standing)
+	}
+
+	for _, round := range rounds {
+		if round.Winner == nil || round.AttackerTeamID == nil || round.DefenderTeamID == nil {
+			continue
+		}
+		attacker, defender := standings[*round.AttackerTeamID], standings[*round.DefenderTeamID]
+		if attacker == nil || defender == nil {
+			continue
+		}
+		switch *round.Winner {
+		case model.BattlegroundWinnerAttacker:
+			attacker.Wins++
+			defender.Losses++
+		case model.BattlegroundWinnerDefender:
+			defender.Wins++
+			attacker.Losses++
+		default:
+			attacker.Draws++
+			defender.Draws++
+		}
+		if round.AttackerPointsBefore != nil && round.AttackerPointsAfter != nil {
+			attacker.Change += *round.AttackerPointsAfter - *round.AttackerPointsBefore
+		}
+		if round.DefenderPointsBefore != nil && round.DefenderPointsAfter != nil {
+			defender.Change += *round.DefenderPointsAfter - *round.DefenderPointsBefore
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Points > result[j].Points })
+	for i, standing := range result {
+		standing.Rank = i + 1
+		if i > 0 && standing.Points == result[i-1].Points {
+			standing.Rank = result[i-1].Rank
+		}
+	}
+
+	return result, nil
+}
@@ -2,11 +2,14 @@ package query
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/marcustut/thebox/internal/graphql/model"
 	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/marcustut/thebox/internal/rubric"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
 )
 
 func GetUniqueDiscovery(ctx context.Context, db *postgresql.PrismaClient, param postgresql.DiscoveryEqualsUniqueWhereParam) (*model.Discovery, error) {
@@ -48,7 +51,17 @@ func GetManyDiscovery(ctx context.Context, db *postgresql.PrismaClient, page mod
 	return discoveries, nil
 }
 
+// UpsertUniqueDiscovery creates or updates the discovery of a team, a video url is never
+// overwritten in place but recorded as a new revision through SubmitDiscovery.
 func UpsertUniqueDiscovery(ctx context.Context, db *postgresql.PrismaClient, param *model.UpsertDiscoveryInput) (*model.Discovery, error) {
+	if param.VideoURL != nil {
+		return SubmitDiscovery(ctx, db, &model.SubmitDiscoveryInput{
+			TeamID:    param.TeamID,
+			MissionID: param.MissionID,
+			VideoURL:  *param.VideoURL,
+		})
+	}
+
 	upsertedDiscovery, err := db.Discovery.UpsertOne(
 		postgresql.Discovery.TeamID.Equals(param.TeamID),
 	).Create(
@@ -56,12 +69,8 @@ func UpsertUniqueDiscovery(ctx context.Context, db *postgresql.PrismaClient, par
 		postgresql.Discovery.UpdatedAt.Set(time.Now()),
 		postgresql.Discovery.Mission.Link(postgresql.Mission.ID.Equals(param.MissionID)),
 		postgresql.Discovery.Team.Link(postgresql.Team.ID.Equals(param.TeamID)),
-		postgresql.Discovery.SubmittedAt.SetIfPresent(param.SubmittedAt),
-		postgresql.Discovery.VideoURL.SetIfPresent(param.VideoURL),
 	).Update(
 		postgresql.Discovery.UpdatedAt.Set(time.Now()),
-		postgresql.Discovery.SubmittedAt.SetIfPresent(param.SubmittedAt),
-		postgresql.Discovery.VideoURL.SetIfPresent(param.VideoURL),
 	).Update().Exec(ctx)
 	if err != nil {
 		return nil, err
@@ -75,3 +84,215 @@ func UpsertUniqueDiscovery(ctx context.Context, db *postgresql.PrismaClient, par
 
 	return discovery, nil
 }
+
+func GetManyDiscoveryRevision(ctx context.Context, db *postgresql.PrismaClient, params ...postgresql.DiscoveryRevisionWhereParam) ([]*model.DiscoveryRevision, error) {
+	// fetch the revisions along with their scores
+	fetchedRevisions, err := db.DiscoveryRevision.FindMany(params...).With(
+		postgresql.DiscoveryRevision.Scores.Fetch(),
+	).OrderBy(
+		postgresql.DiscoveryRevision.Revision.Order(postgresql.DESC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse revisions to graphql type
+	revisions, err := model.MapToDiscoveryRevisions(fetchedRevisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// SubmitDiscovery records a new revision of the video of a team. A team can resubmit
+// as long as their latest revision is not being reviewed or has not been accepted.
+func SubmitDiscovery(ctx context.Context, db *postgresql.PrismaClient, param *model.SubmitDiscoveryInput) (*model.Discovery, error) {
+	fetchedDiscovery, err := db.Discovery.UpsertOne(
+		postgresql.Discovery.TeamID.Equals(param.TeamID),
+	).Create(
+		postgresql.Discovery.ID.Set(gofakeit.UUID()),
+		postgresql.Discovery.UpdatedAt.Set(time.Now()),
+		postgresql.Discovery.Mission.Link(postgresql.Mission.ID.Equals(param.MissionID)),
+		postgresql.Discovery.Team.Link(postgresql.Team.ID.Equals(param.TeamID)),
+	).Update().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if status, ok := fetchedDiscovery.Status(); ok && (status == postgresql.DiscoveryStatusUNDERREVIEW || status == postgresql.DiscoveryStatusACCEPTED) {
+		return nil, fmt.Errorf("discovery cannot be resubmitted while it is %s", status)
+	}
+
+	// the new revision replaces every revision before it
+	now := time.Now()
+	supersedeRevisions := db.DiscoveryRevision.FindMany(
+		postgresql.DiscoveryRevision.DiscoveryID.Equals(fetchedDiscovery.ID),
+		postgresql.DiscoveryRevision.SupersededAt.IsNull(),
+	).Update(
+		postgresql.DiscoveryRevision.SupersededAt.Set(now),
+	).Tx()
+	revision := fetchedDiscovery.Revision + 1
+	createRevision := db.DiscoveryRevision.CreateOne(
+		postgresql.DiscoveryRevision.ID.Set(gofakeit.UUID()),
+		postgresql.DiscoveryRevision.Revision.Set(revision),
+		postgresql.DiscoveryRevision.VideoURL.Set(param.VideoURL),
+		postgresql.DiscoveryRevision.SubmittedAt.Set(now),
+		postgresql.DiscoveryRevision.Status.Set(postgresql.DiscoveryStatusSUBMITTED),
+		postgresql.DiscoveryRevision.Discovery.Link(postgresql.Discovery.ID.Equals(fetchedDiscovery.ID)),
+	).Tx()
+	updateDiscovery := db.Discovery.FindUnique(postgresql.Discovery.ID.Equals(fetchedDiscovery.ID)).Update(
+		postgresql.Discovery.VideoURL.Set(param.VideoURL),
+		postgresql.Discovery.SubmittedAt.Set(now),
+		postgresql.Discovery.Status.Set(postgresql.DiscoveryStatusSUBMITTED),
+		postgresql.Discovery.Revision.Set(revision),
+		postgresql.Discovery.UpdatedAt.Set(now),
+	).Tx()
+	if err := db.Prisma.Transaction(supersedeRevisions, createRevision, updateDiscovery).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// parse discovery to graphql type
+	discovery, err := model.MapToDiscovery(updateDiscovery.Result())
+	if err != nil {
+		return nil, err
+	}
+
+	return discovery, nil
+}
+
+// StartDiscoveryReview marks the latest revision of a discovery as being reviewed so the
+// team can no longer replace it.
+func StartDiscoveryReview(ctx context.Context, db *postgresql.PrismaClient, reviewerID string, discoveryID string) (*model.Discovery, error) {
+	return reviewDiscovery(ctx, db, discoveryID, postgresql.DiscoveryStatusSUBMITTED, postgresql.DiscoveryStatusUNDERREVIEW,
+		postgresql.DiscoveryRevision.Reviewer.Link(postgresql.User.ID.Equals(reviewerID)),
+	)
+}
+
+// AcceptDiscovery scores the latest revision of a discovery and awards the team a share
+// of the mission points proportional to its score.
+func AcceptDiscovery(ctx context.Context, db *postgresql.PrismaClient, reviewerID string, param *model.AcceptDiscoveryInput) (*model.Discovery, error) {
+	// every criterion must be scored exactly once
+	var total float64
+	scored := map[string]bool{}
+	for _, score := range param.Scores {
+		if err := rubric.Discovery.Validate(score.Criterion, score.Score); err != nil {
+			return nil, err
+		}
+		if scored[score.Criterion] {
+			return nil, fmt.Errorf("criterion %s is scored more than once", score.Criterion)
+		}
+		scored[score.Criterion] = true
+		total += score.Score
+	}
+	if len(scored) != len(rubric.Discovery.Criteria) {
+		return nil, fmt.Errorf("all %d criteria of the discovery rubric must be scored", len(rubric.Discovery.Criteria))
+	}
+
+	fetchedDiscovery, err := db.Discovery.FindUnique(postgresql.Discovery.ID.Equals(param.DiscoveryID)).With(
+		postgresql.Discovery.Mission.Fetch(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	updatedDiscovery, txs, err := reviewDiscoveryTxs(db, fetchedDiscovery, postgresql.DiscoveryStatusUNDERREVIEW, postgresql.DiscoveryStatusACCEPTED,
+		postgresql.DiscoveryRevision.Reviewer.Link(postgresql.User.ID.Equals(reviewerID)),
+		postgresql.DiscoveryRevision.Feedback.SetIfPresent(param.Feedback),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// save the scores on the accepted revision
+	revision, err := db.DiscoveryRevision.FindUnique(
+		postgresql.DiscoveryRevision.DiscoveryIDRevision(
+			postgresql.DiscoveryRevision.DiscoveryID.Equals(fetchedDiscovery.ID),
+			postgresql.DiscoveryRevision.Revision.Equals(fetchedDiscovery.Revision),
+		),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, score := range param.Scores {
+		txs = append(txs, db.DiscoveryScore.CreateOne(
+			postgresql.DiscoveryScore.ID.Set(gofakeit.UUID()),
+			postgresql.DiscoveryScore.Criterion.Set(score.Criterion),
+			postgresql.DiscoveryScore.Score.Set(score.Score),
+			postgresql.DiscoveryScore.Revision.Link(postgresql.DiscoveryRevision.ID.Equals(revision.ID)),
+		).Tx())
+	}
+
+	// the discovery is only accepted along with its points
+	reason := fmt.Sprintf("Discovery revision %d accepted", fetchedDiscovery.Revision)
+	points := fetchedDiscovery.Mission().Points * total / rubric.Discovery.Max()
+	txs = append(txs, awardPointsTxs(db, fetchedDiscovery.TeamID, points, PointAwardSourceDiscovery, fetchedDiscovery.ID, &reason)...)
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// parse discovery to graphql type
+	discovery, err := model.MapToDiscovery(updatedDiscovery())
+	if err != nil {
+		return nil, err
+	}
+
+	return discovery, nil
+}
+
+// RejectDiscovery sends the latest revision of a discovery back to the team with feedback
+// on what to change before resubmitting.
+func RejectDiscovery(ctx context.Context, db *postgresql.PrismaClient, reviewerID string, discoveryID string, feedback string) (*model.Discovery, error) {
+	return reviewDiscovery(ctx, db, discoveryID, postgresql.DiscoveryStatusUNDERREVIEW, postgresql.DiscoveryStatusREJECTED,
+		postgresql.DiscoveryRevision.Reviewer.Link(postgresql.User.ID.Equals(reviewerID)),
+		postgresql.DiscoveryRevision.Feedback.Set(feedback),
+	)
+}
+
+// reviewDiscovery moves the latest revision of a discovery from one status to another.
+func reviewDiscovery(ctx context.Context, db *postgresql.PrismaClient, discoveryID string, from postgresql.DiscoveryStatus, to postgresql.DiscoveryStatus, params ...postgresql.DiscoveryRevisionSetParam) (*model.Discovery, error) {
+	fetchedDiscovery, err := db.Discovery.FindUnique(postgresql.Discovery.ID.Equals(discoveryID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	updatedDiscovery, txs, err := reviewDiscoveryTxs(db, fetchedDiscovery, from, to, params...)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// parse discovery to graphql type
+	discovery, err := model.MapToDiscovery(updatedDiscovery())
+	if err != nil {
+		return nil, err
+	}
+
+	return discovery, nil
+}
+
+// reviewDiscoveryTxs builds the writes of a review without executing them, so a review
+// can be saved along with what comes with it. The updated discovery can be read once
+// the transaction is executed.
+func reviewDiscoveryTxs(db *postgresql.PrismaClient, fetchedDiscovery *postgresql.DiscoveryModel, from postgresql.DiscoveryStatus, to postgresql.DiscoveryStatus, params ...postgresql.DiscoveryRevisionSetParam) (func() *postgresql.DiscoveryModel, []transaction.Param, error) {
+	if status, ok := fetchedDiscovery.Status(); !ok || status != from {
+		return nil, nil, fmt.Errorf("discovery must be %s to become %s", from, to)
+	}
+
+	now := time.Now()
+	params = append(params,
+		postgresql.DiscoveryRevision.Status.Set(to),
+		postgresql.DiscoveryRevision.ReviewedAt.Set(now),
+	)
+	updateRevision := db.DiscoveryRevision.FindUnique(
+		postgresql.DiscoveryRevision.DiscoveryIDRevision(
+			postgresql.DiscoveryRevision.DiscoveryID.Equals(fetchedDiscovery.ID),
+			postgresql.DiscoveryRevision.Revision.Equals(fetchedDiscovery.Revision),
+		),
+	).Update(params...).Tx()
+	updateDiscovery := db.Discovery.FindUnique(postgresql.Discovery.ID.Equals(fetchedDiscovery.ID)).Update(
+		postgresql.Discovery.Status.Set(to),
+		postgresql.Discovery.UpdatedAt.Set(now),
+	).Tx()
+
+	return updateDiscovery.Result, []transaction.Param{updateRevision, updateDiscovery}, nil
+}
@@ -2,10 +2,14 @@ package query
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/answer"
 	"github.com/marcustut/thebox/internal/graphql/model"
 	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
 )
 
 func GetUniqueEscape(ctx context.Context, db *postgresql.PrismaClient, param postgresql.EscapeEqualsUniqueWhereParam) (*model.Escape, error) {
@@ -50,3 +54,230 @@ func UpsertUniqueEscape(ctx context.Context, db *postgresql.PrismaClient, param
 
 	return escape, nil
 }
+
+func GetManyEscapeStage(ctx context.Context, db *postgresql.PrismaClient, params ...postgresql.EscapeStageWhereParam) ([]*model.EscapeStage, error) {
+	// fetch the escape stages
+	fetchedEscapeStages, err := db.EscapeStage.FindMany(params...).OrderBy(
+		postgresql.EscapeStage.Position.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse escape stages to graphql type
+	escapeStages, err := model.MapToEscapeStages(fetchedEscapeStages)
+	if err != nil {
+		return nil, err
+	}
+
+	return escapeStages, nil
+}
+
+// SetEscapeStages configures the stages of an escape room in the given order. Stages are
+// matched by position so solves of a stage that is kept survive the update. Stages past
+// the new last position are removed, unless a team has solved them since their points
+// are awarded already.
+func SetEscapeStages(ctx context.Context, db *postgresql.PrismaClient, param *model.SetEscapeStagesInput) ([]*model.EscapeStage, error) {
+	if len(param.Stages) == 0 {
+		return nil, fmt.Errorf("at least one stage is required")
+	}
+
+	existingStages, err := db.EscapeStage.FindMany(
+		postgresql.EscapeStage.MissionID.Equals(param.MissionID),
+	).With(
+		postgresql.EscapeStage.Solves.Fetch().Take(1),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stageIDs := make(map[int]string)
+	for _, stage := range existingStages {
+		stageIDs[stage.Position] = stage.ID
+
+		// the points of a solve are awarded already, removing the stage would leave them behind
+		if stage.Position > len(param.Stages) && len(stage.Solves()) > 0 {
+			return nil, fmt.Errorf("stage %d has been solved and cannot be removed", stage.Position)
+		}
+	}
+
+	var txs []transaction.Param
+	for i, stage := range param.Stages {
+		if answer.Normalize(stage.Answer) == "" {
+			return nil, fmt.Errorf("answer of stage %d is empty once normalized", i+1)
+		}
+		if stage.Points < 0 {
+			return nil, fmt.Errorf("points of stage %d must not be negative", i+1)
+		}
+		salt, err := answer.NewSalt()
+		if err != nil {
+			return nil, err
+		}
+
+		// update the stage at this position or create a new one
+		if id, ok := stageIDs[i+1]; ok {
+			txs = append(txs, db.EscapeStage.FindUnique(postgresql.EscapeStage.ID.Equals(id)).Update(
+				postgresql.EscapeStage.Title.Set(stage.Title),
+				postgresql.EscapeStage.Hash.Set(answer.Hash(stage.Answer, salt)),
+				postgresql.EscapeStage.Salt.Set(salt),
+				postgresql.EscapeStage.Points.Set(stage.Points),
+				postgresql.EscapeStage.UpdatedAt.Set(time.Now()),
+			).Tx())
+			continue
+		}
+		txs = append(txs, db.EscapeStage.CreateOne(
+			postgresql.EscapeStage.ID.Set(gofakeit.UUID()),
+			postgresql.EscapeStage.Position.Set(i+1),
+			postgresql.EscapeStage.Title.Set(stage.Title),
+			postgresql.EscapeStage.Hash.Set(answer.Hash(stage.Answer, salt)),
+			postgresql.EscapeStage.Salt.Set(salt),
+			postgresql.EscapeStage.Points.Set(stage.Points),
+			postgresql.EscapeStage.UpdatedAt.Set(time.Now()),
+			postgresql.EscapeStage.Mission.Link(postgresql.Mission.ID.Equals(param.MissionID)),
+		).Tx())
+	}
+	txs = append(txs, db.EscapeStage.FindMany(
+		postgresql.EscapeStage.MissionID.Equals(param.MissionID),
+		postgresql.EscapeStage.Position.GT(len(param.Stages)),
+		postgresql.EscapeStage.Not(postgresql.EscapeStage.Solves.Some()),
+	).Delete().Tx())
+
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return GetManyEscapeStage(ctx, db, postgresql.EscapeStage.MissionID.Equals(param.MissionID))
+}
+
+// GetEscapeProgress returns how far a team is in an escape room. The solve time of a
+// stage is counted from the solve of the stage before it, or from the start of the
+// mission for the first stage.
+func GetEscapeProgress(ctx context.Context, db *postgresql.PrismaClient, teamID string, missionID string) (*model.EscapeProgress, error) {
+	mission, err := db.Mission.FindUnique(postgresql.Mission.ID.Equals(missionID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch the stages along with the solve of the team
+	fetchedEscapeStages, err := db.EscapeStage.FindMany(
+		postgresql.EscapeStage.MissionID.Equals(missionID),
+	).OrderBy(
+		postgresql.EscapeStage.Position.Order(postgresql.ASC),
+	).With(
+		postgresql.EscapeStage.Solves.Fetch(postgresql.EscapeStageSolve.TeamID.Equals(teamID)),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := &model.EscapeProgress{TeamID: teamID, MissionID: missionID, Completed: len(fetchedEscapeStages) > 0}
+	previous := &mission.StartAt
+	for _, fetchedEscapeStage := range fetchedEscapeStages {
+		stage, err := model.MapToEscapeStage(&fetchedEscapeStage)
+		if err != nil {
+			return nil, err
+		}
+		stageProgress := &model.EscapeStageProgress{Stage: stage}
+		if solves := fetchedEscapeStage.Solves(); len(solves) > 0 {
+			solvedAt := solves[0].SolvedAt
+			stageProgress.SolvedAt = &solvedAt
+			if previous != nil {
+				solveTime := int(solvedAt.Sub(*previous).Seconds())
+				stageProgress.SolveTime = &solveTime
+			}
+			progress.Points += stage.Points
+		} else {
+			progress.Completed = false
+		}
+		previous = stageProgress.SolvedAt
+		progress.Stages = append(progress.Stages, stageProgress)
+	}
+
+	return progress, nil
+}
+
+// GetManyEscapeProgress returns the progress of a team in every mission that has escape
+// stages configured.
+func GetManyEscapeProgress(ctx context.Context, db *postgresql.PrismaClient, teamID string) ([]*model.EscapeProgress, error) {
+	missions, err := db.Mission.FindMany(
+		postgresql.Mission.EscapeStages.Some(),
+	).OrderBy(
+		postgresql.Mission.StartAt.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var progresses []*model.EscapeProgress
+	for _, mission := range missions {
+		progress, err := GetEscapeProgress(ctx, db, teamID, mission.ID)
+		if err != nil {
+			return nil, err
+		}
+		progresses = append(progresses, progress)
+	}
+
+	return progresses, nil
+}
+
+// SubmitEscapeAnswer checks the answer of a team for a stage. Stages are solved in order,
+// the stage is solved at the time the server receives the correct answer and its points
+// are awarded right away.
+func SubmitEscapeAnswer(ctx context.Context, db *postgresql.PrismaClient, userID string, param *model.SubmitEscapeAnswerInput) (*model.EscapeAnswerResult, error) {
+	// only members of the team can answer for it
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if teamID, ok := user.TeamID(); !ok || teamID != param.TeamID {
+		return nil, fmt.Errorf("you are not a member of team %s", param.TeamID)
+	}
+
+	stage, err := db.EscapeStage.FindUnique(postgresql.EscapeStage.ID.Equals(param.StageID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stages before this one must be solved first
+	solves, err := db.EscapeStageSolve.FindMany(
+		postgresql.EscapeStageSolve.TeamID.Equals(param.TeamID),
+		postgresql.EscapeStageSolve.Stage.Where(
+			postgresql.EscapeStage.MissionID.Equals(stage.MissionID),
+			postgresql.EscapeStage.Position.LTE(stage.Position),
+		),
+	).With(
+		postgresql.EscapeStageSolve.Stage.Fetch(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, solve := range solves {
+		if solve.StageID == stage.ID {
+			return nil, fmt.Errorf("team %s has already solved stage %d", param.TeamID, stage.Position)
+		}
+	}
+	if len(solves) < stage.Position-1 {
+		return nil, fmt.Errorf("stage %d is not unlocked yet", stage.Position)
+	}
+
+	result := &model.EscapeAnswerResult{Correct: answer.Matches(param.Answer, stage.Hash, stage.Salt)}
+	if result.Correct {
+		solveID := gofakeit.UUID()
+		createSolve := db.EscapeStageSolve.CreateOne(
+			postgresql.EscapeStageSolve.ID.Set(solveID),
+			postgresql.EscapeStageSolve.Stage.Link(postgresql.EscapeStage.ID.Equals(stage.ID)),
+			postgresql.EscapeStageSolve.Team.Link(postgresql.Team.ID.Equals(param.TeamID)),
+			postgresql.EscapeStageSolve.SolvedAt.Set(time.Now()),
+		).Tx()
+		reason := fmt.Sprintf("Escape stage %d solved", stage.Position)
+		if _, err := AwardPoints(ctx, db, param.TeamID, stage.Points, PointAwardSourceEscape, solveID, &reason, createSolve); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Progress, err = GetEscapeProgress(ctx, db, param.TeamID, stage.MissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,179 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marcustut/thebox/internal/export"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// ExportBatchSize is how many rows of an export are fetched at a time.
+const ExportBatchSize = 500
+
+// Export is a table that can be exported. Its query selects every column as text along
+// with a cursor that orders the rows, so the rows can be fetched in batches.
+type Export struct {
+	Name    string
+	Columns []string
+	query   string
+}
+
+// exportTimestamp formats a timestamp of prisma, which is stored in UTC.
+const exportTimestamp = `'YYYY-MM-DD"T"HH24:MI:SS"Z"'`
+
+var UserExport = Export{
+	Name: "users",
+	Columns: []string{
+		"id", "username", "email", "registeredAt", "nameEng", "nameChi", "gender", "dob", "contact",
+		"status", "satellite", "invitedBy", "paymentStatus", "teamId", "teamName", "cluster",
+		"line1", "line2", "city", "state", "postalCode", "country",
+	},
+	query: `
+		SELECT
+			U.id::text AS cursor,
+			U.id::text AS id,
+			U.username,
+			U.email,
+			to_char(U."createdAt", ` + exportTimestamp + `) AS "registeredAt",
+			P."nameEng",
+			P."nameChi",
+			P.gender::text AS gender,
+			to_char(P.dob, 'YYYY-MM-DD') AS dob,
+			P.contact,
+			P.status::text AS status,
+			P.satellite::text AS satellite,
+			P."invitedBy",
+			P."paymentStatus"::text AS "paymentStatus",
+			T.id::text AS "teamId",
+			T.name AS "teamName",
+			C.name AS cluster,
+			A.line1,
+			A.line2,
+			A.city,
+			A.state,
+			A."postalCode",
+			A.country
+		FROM
+			"User" U
+			INNER JOIN "Profile" P ON U."profileId" = P.id
+			LEFT JOIN "Address" A ON P."addressId" = A.id
+			LEFT JOIN "Team" T ON U."teamId" = T.id
+			LEFT JOIN "Cluster" C ON T."clusterId" = C.id
+	`,
+}
+
+var TeamExport = Export{
+	Name: "teams",
+	Columns: []string{
+		"id", "name", "cluster", "points", "members", "memberUsernames", "memberNames",
+		"powercard", "eligiblePowercards",
+	},
+	query: `
+		SELECT
+			T.id::text AS cursor,
+			T.id::text AS id,
+			T.name,
+			C.name AS cluster,
+			T.points::text AS points,
+			COUNT(U.id)::text AS members,
+			string_agg(U.username, '; ' ORDER BY U.username) AS "memberUsernames",
+			string_agg(COALESCE(P."nameChi", P."nameEng"), '; ' ORDER BY U.username) AS "memberNames",
+			T.powercard::text AS powercard,
+			array_to_string(T."eligiblePowercards", '; ') AS "eligiblePowercards"
+		FROM
+			"Team" T
+			LEFT JOIN "Cluster" C ON T."clusterId" = C.id
+			LEFT JOIN "User" U ON U."teamId" = T.id
+			LEFT JOIN "Profile" P ON U."profileId" = P.id
+		GROUP BY
+			T.id, C.name
+	`,
+}
+
+// ScoreExport has a row for the points a team got from each mission, points that were
+// not given by a mission such as the battleground are listed under their source.
+var ScoreExport = Export{
+	Name: "scores",
+	Columns: []string{
+		"teamId", "teamName", "cluster", "mission", "source", "points", "awards", "lastAwardedAt", "teamPoints",
+	},
+	query: `
+		SELECT
+			T.id::text || '/' || COALESCE(M.id::text, '') || '/' || PA.source AS cursor,
+			T.id::text AS "teamId",
+			T.name AS "teamName",
+			C.name AS cluster,
+			M.title AS mission,
+			PA.source,
+			SUM(PA.points)::text AS points,
+			COUNT(*)::text AS awards,
+			to_char(MAX(PA."createdAt"), ` + exportTimestamp + `) AS "lastAwardedAt",
+			T.points::text AS "teamPoints"
+		FROM
+			"PointAward" PA
+			INNER JOIN "Team" T ON PA."teamId" = T.id
+			LEFT JOIN "Cluster" C ON T."clusterId" = C.id
+			LEFT JOIN "Humanity" H ON PA.source = '` + PointAwardSourceHumanity + `' AND H.id::text = PA.reference
+			LEFT JOIN "Discovery" D ON PA.source = '` + PointAwardSourceDiscovery + `' AND D.id::text = PA.reference
+			LEFT JOIN "Speed" S ON PA.source = '` + PointAwardSourceSpeed + `' AND S.id::text = PA.reference
+			LEFT JOIN "EscapeStageSolve" ES ON PA.source = '` + PointAwardSourceEscape + `' AND ES.id::text = PA.reference
+			LEFT JOIN "EscapeStage" E ON ES."stageId" = E.id
+			LEFT JOIN "Mission" M ON M.id = COALESCE(H."missionId", D."missionId", S."missionId", E."missionId")
+		GROUP BY
+			T.id, C.name, M.id, PA.source
+	`,
+}
+
+// Exports are the tables that can be exported by their name.
+var Exports = map[string]Export{
+	UserExport.Name:  UserExport,
+	TeamExport.Name:  TeamExport,
+	ScoreExport.Name: ScoreExport,
+}
+
+// StreamExport writes the header and the rows of an export, the rows are fetched and
+// flushed in batches. A column without a value is an empty string.
+func StreamExport(ctx context.Context, db *postgresql.PrismaClient, table Export, columns []string, w export.Writer) error {
+	if err := w.Write(columns); err != nil {
+		return err
+	}
+
+	cursor := ""
+	for {
+		var rows []map[string]*string
+		err := db.Prisma.QueryRaw(fmt.Sprintf(`
+			SELECT
+				*
+			FROM (%s) X
+			WHERE
+				X.cursor > $1
+			ORDER BY
+				X.cursor
+			LIMIT $2
+		`, table.query), cursor, ExportBatchSize).Exec(ctx, &rows)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			values := make([]string, len(columns))
+			for i, column := range columns {
+				if value := row[column]; value != nil {
+					values[i] = *value
+				}
+			}
+			if err := w.Write(values); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if len(rows) < ExportBatchSize {
+			return w.Close()
+		}
+		cursor = *rows[len(rows)-1]["cursor"]
+	}
+}
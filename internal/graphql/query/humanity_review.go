@@ -0,0 +1,331 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/marcustut/thebox/internal/rubric"
+)
+
+func GetRubricCriteria(r rubric.Rubric) []*model.RubricCriterion {
+	var criteria []*model.RubricCriterion
+	for _, c := range r.Criteria {
+		criteria = append(criteria, &model.RubricCriterion{Key: c.Key, Description: c.Description, Max: c.Max})
+	}
+	return criteria
+}
+
+func GetManyHumanityReview(ctx context.Context, db *postgresql.PrismaClient, params ...postgresql.HumanityAssignmentWhereParam) ([]*model.HumanityReview, error) {
+	// fetch the assignments along with their scores
+	fetchedAssignments, err := db.HumanityAssignment.FindMany(params...).With(
+		postgresql.HumanityAssignment.Scores.Fetch(),
+	).OrderBy(
+		postgresql.HumanityAssignment.CreatedAt.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse assignments to graphql type
+	reviews, err := model.MapToHumanityReviews(fetchedAssignments)
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// GetPendingHumanityReviews returns the reviews assigned to a judge that are not done yet.
+func GetPendingHumanityReviews(ctx context.Context, db *postgresql.PrismaClient, judgeID string, page model.PaginationInput) ([]*model.HumanityReview, error) {
+	// build query
+	query := db.HumanityAssignment.FindMany(
+		postgresql.HumanityAssignment.JudgeID.Equals(judgeID),
+		postgresql.HumanityAssignment.CompletedAt.IsNull(),
+		postgresql.HumanityAssignment.Humanity.Where(
+			postgresql.Humanity.PublishedAt.IsNull(),
+		),
+	).With(
+		postgresql.HumanityAssignment.Scores.Fetch(),
+	).OrderBy(
+		postgresql.HumanityAssignment.CreatedAt.Order(postgresql.ASC),
+	)
+
+	// apply pagination
+	query = query.Take(page.Limit)
+	query = query.Skip(page.Offset)
+
+	// fetch the assignments
+	fetchedAssignments, err := query.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse assignments to graphql type
+	reviews, err := model.MapToHumanityReviews(fetchedAssignments)
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+// GetHumanityAverageScore averages the totals of every completed review, it is nil
+// until at least one judge has completed their review.
+func GetHumanityAverageScore(ctx context.Context, db *postgresql.PrismaClient, humanityID string) (*float64, error) {
+	reviews, err := GetManyHumanityReview(ctx, db,
+		postgresql.HumanityAssignment.HumanityID.Equals(humanityID),
+		postgresql.HumanityAssignment.Not(postgresql.HumanityAssignment.CompletedAt.IsNull()),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return averageHumanityScore(reviews), nil
+}
+
+// GetHumanityFinalScore averages the totals of the reviews once every judge assigned to
+// the submission has completed their review, it is nil until then.
+func GetHumanityFinalScore(ctx context.Context, db *postgresql.PrismaClient, humanityID string) (*float64, error) {
+	reviews, err := GetManyHumanityReview(ctx, db, postgresql.HumanityAssignment.HumanityID.Equals(humanityID))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, review := range reviews {
+		if review.CompletedAt == nil {
+			return nil, nil
+		}
+	}
+
+	return averageHumanityScore(reviews), nil
+}
+
+// AssignHumanityReviews makes sure every submitted humanity of a mission is reviewed by
+// judgesPerSubmission judges, submissions go to the judges with the fewest assignments.
+func AssignHumanityReviews(ctx context.Context, db *postgresql.PrismaClient, missionID string, judgesPerSubmission int) ([]*model.HumanityReview, error) {
+	// fetch the judges
+	judgeRoles, err := db.UserRole.FindMany(postgresql.UserRole.Role.Equals(postgresql.RoleJUDGE)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(judgeRoles) < judgesPerSubmission {
+		return nil, fmt.Errorf("%d judges are needed but only %d are available", judgesPerSubmission, len(judgeRoles))
+	}
+
+	// fetch the submissions that still need judging
+	humanities, err := db.Humanity.FindMany(
+		postgresql.Humanity.MissionID.Equals(missionID),
+		postgresql.Humanity.Not(postgresql.Humanity.SubmittedAt.IsNull()),
+		postgresql.Humanity.PublishedAt.IsNull(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// count what every judge has been assigned so far
+	assignments, err := db.HumanityAssignment.FindMany().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	load := map[string]int{}
+	assigned := map[string]map[string]bool{}
+	for _, judgeRole := range judgeRoles {
+		load[judgeRole.UserID] = 0
+	}
+	for _, assignment := range assignments {
+		load[assignment.JudgeID]++
+		if assigned[assignment.HumanityID] == nil {
+			assigned[assignment.HumanityID] = map[string]bool{}
+		}
+		assigned[assignment.HumanityID][assignment.JudgeID] = true
+	}
+
+	var created []*model.HumanityReview
+	for _, humanity := range humanities {
+		for len(assigned[humanity.ID]) < judgesPerSubmission {
+			// pick the least loaded judge who is not reviewing this submission yet
+			var candidates []string
+			for _, judgeRole := range judgeRoles {
+				if !assigned[humanity.ID][judgeRole.UserID] {
+					candidates = append(candidates, judgeRole.UserID)
+				}
+			}
+			sort.SliceStable(candidates, func(i, j int) bool { return load[candidates[i]] < load[candidates[j]] })
+			judgeID := candidates[0]
+
+			createdAssignment, err := db.HumanityAssignment.CreateOne(
+				postgresql.HumanityAssignment.ID.Set(gofakeit.UUID()),
+				postgresql.HumanityAssignment.Humanity.Link(postgresql.Humanity.ID.Equals(humanity.ID)),
+				postgresql.HumanityAssignment.Judge.Link(postgresql.User.ID.Equals(judgeID)),
+			).With(
+				postgresql.HumanityAssignment.Scores.Fetch(),
+			).Exec(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			review, err := model.MapToHumanityReview(createdAssignment)
+			if err != nil {
+				return nil, err
+			}
+			created = append(created, review)
+
+			load[judgeID]++
+			if assigned[humanity.ID] == nil {
+				assigned[humanity.ID] = map[string]bool{}
+			}
+			assigned[humanity.ID][judgeID] = true
+		}
+	}
+
+	return created, nil
+}
+
+// ScoreHumanity saves the scores a judge gave to the photos of a submission, the review
+// is completed once every submitted photo is scored on every criterion of the rubric.
+func ScoreHumanity(ctx context.Context, db *postgresql.PrismaClient, judgeID string, param *model.ScoreHumanityInput) (*model.HumanityReview, error) {
+	// fetch the submission and the assignment of the judge
+	fetchedHumanity, err := db.Humanity.FindUnique(postgresql.Humanity.ID.Equals(param.HumanityID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := fetchedHumanity.PublishedAt(); ok {
+		return nil, fmt.Errorf("results of humanity %s are already published", param.HumanityID)
+	}
+	humanity, err := model.MapToHumanity(fetchedHumanity)
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := db.HumanityAssignment.FindUnique(
+		postgresql.HumanityAssignment.HumanityIDJudgeID(
+			postgresql.HumanityAssignment.HumanityID.Equals(param.HumanityID),
+			postgresql.HumanityAssignment.JudgeID.Equals(judgeID),
+		),
+	).Exec(ctx)
+	if err == postgresql.ErrNotFound {
+		return nil, fmt.Errorf("humanity %s is not assigned to you", param.HumanityID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// validate the scores before saving any of them
+	photos := len(humanity.Photos())
+	for _, score := range param.Scores {
+		if score.Photo < 1 || score.Photo > photos {
+			return nil, fmt.Errorf("photo %d has not been submitted", score.Photo)
+		}
+		if err := rubric.Humanity.Validate(score.Criterion, score.Score); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, score := range param.Scores {
+		_, err := db.HumanityScore.UpsertOne(
+			postgresql.HumanityScore.AssignmentIDPhotoCriterion(
+				postgresql.HumanityScore.AssignmentID.Equals(assignment.ID),
+				postgresql.HumanityScore.Photo.Equals(score.Photo),
+				postgresql.HumanityScore.Criterion.Equals(score.Criterion),
+			),
+		).Create(
+			postgresql.HumanityScore.ID.Set(gofakeit.UUID()),
+			postgresql.HumanityScore.Photo.Set(score.Photo),
+			postgresql.HumanityScore.Criterion.Set(score.Criterion),
+			postgresql.HumanityScore.Score.Set(score.Score),
+			postgresql.HumanityScore.UpdatedAt.Set(time.Now()),
+			postgresql.HumanityScore.Assignment.Link(postgresql.HumanityAssignment.ID.Equals(assignment.ID)),
+		).Update(
+			postgresql.HumanityScore.Score.Set(score.Score),
+			postgresql.HumanityScore.UpdatedAt.Set(time.Now()),
+		).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// complete the review once everything is scored
+	scores, err := db.HumanityScore.FindMany(postgresql.HumanityScore.AssignmentID.Equals(assignment.ID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var completedAt *time.Time
+	if len(scores) >= photos*len(rubric.Humanity.Criteria) {
+		now := time.Now()
+		completedAt = &now
+	}
+	updatedAssignment, err := db.HumanityAssignment.FindUnique(
+		postgresql.HumanityAssignment.ID.Equals(assignment.ID),
+	).With(
+		postgresql.HumanityAssignment.Scores.Fetch(),
+	).Update(
+		postgresql.HumanityAssignment.CompletedAt.SetOptional(completedAt),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse assignment to graphql type
+	review, err := model.MapToHumanityReview(updatedAssignment)
+	if err != nil {
+		return nil, err
+	}
+
+	return review, nil
+}
+
+// PublishHumanityResults awards every reviewed submission of a mission the average score
+// of its judges. A submission is only published once every judge assigned to it is done,
+// so a judge still scoring is not locked out, and calling this again publishes those
+// that have been reviewed since.
+func PublishHumanityResults(ctx context.Context, db *postgresql.PrismaClient, missionID string) ([]*model.PointAward, error) {
+	humanities, err := db.Humanity.FindMany(
+		postgresql.Humanity.MissionID.Equals(missionID),
+		postgresql.Humanity.Not(postgresql.Humanity.SubmittedAt.IsNull()),
+		postgresql.Humanity.PublishedAt.IsNull(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := "Humanity mission results"
+	pointAwards := []*model.PointAward{}
+	for _, humanity := range humanities {
+		average, err := GetHumanityFinalScore(ctx, db, humanity.ID)
+		if err != nil {
+			return nil, err
+		}
+		if average == nil {
+			continue
+		}
+
+		publishHumanity := db.Humanity.FindUnique(postgresql.Humanity.ID.Equals(humanity.ID)).Update(
+			postgresql.Humanity.PublishedAt.Set(time.Now()),
+		).Tx()
+		pointAward, err := AwardPoints(ctx, db, humanity.TeamID, *average, PointAwardSourceHumanity, humanity.ID, &reason, publishHumanity)
+		if err != nil {
+			return nil, err
+		}
+		pointAwards = append(pointAwards, pointAward)
+	}
+
+	return pointAwards, nil
+}
+
+func averageHumanityScore(reviews []*model.HumanityReview) *float64 {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	var sum float64
+	for _, review := range reviews {
+		sum += review.Total
+	}
+	average := sum / float64(len(reviews))
+
+	return &average
+}
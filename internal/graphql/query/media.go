@@ -0,0 +1,222 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/imaging"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/marcustut/thebox/internal/storage"
+	"github.com/prisma/prisma-client-go/runtime/types"
+)
+
+// mediaContentTypes are the content types accepted for uploads, the content type is
+// sniffed from the file itself instead of trusting what the client claims.
+var mediaContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// imageVariants maps each graphql image size to its processed variant.
+var imageVariants = map[model.ImageSize]imaging.Variant{
+	model.ImageSizeSmall:    imaging.Small,
+	model.ImageSizeMedium:   imaging.Medium,
+	model.ImageSizeOriginal: imaging.Original,
+}
+
+func UploadMedia(ctx context.Context, db *postgresql.PrismaClient, store storage.Storage, config storage.Config, param *model.UploadMediaInput) (*model.Media, error) {
+	if param.File.Size > config.MaxUploadSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", config.MaxUploadSize)
+	}
+	if param.Target == model.UploadTargetHumanityPhoto && (param.Slot == nil || *param.Slot < 1 || *param.Slot > 3) {
+		return nil, fmt.Errorf("slot must be between 1 and 3 for %s", param.Target)
+	}
+
+	// read the whole file, the reader is limited in case the declared size is wrong
+	data, err := io.ReadAll(io.LimitReader(param.File.File, config.MaxUploadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > config.MaxUploadSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", config.MaxUploadSize)
+	}
+
+	// sniff the content type from the content itself
+	contentType := http.DetectContentType(data)
+	if !mediaContentTypes[contentType] {
+		return nil, fmt.Errorf("unsupported content type %s", contentType)
+	}
+
+	// strip metadata and create the thumbnails
+	processed, err := imaging.Process(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// store every variant of the file
+	key := fmt.Sprintf("%s/%s/%s%s", strings.ToLower(string(param.Target)), param.TargetID, gofakeit.UUID(), processed.Ext)
+	for variant, content := range processed.Variants {
+		err := store.Put(ctx, imaging.VariantKey(key, variant), bytes.NewReader(content), int64(len(content)), processed.ContentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// save the key on the target, signed URLs expire so they are only made when read
+	if err := setMediaKey(ctx, db, param.Target, param.TargetID, param.Slot, key); err != nil {
+		return nil, err
+	}
+	url, err := store.SignedURL(ctx, key, config.URLExpiry)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Media{
+		Key:         key,
+		URL:         url,
+		ContentType: processed.ContentType,
+		Size:        len(processed.Variants[imaging.Original]),
+	}, nil
+}
+
+// CheckMediaTarget makes sure the user can upload for a target, a profile and its receipt
+// must be the user's own, a team and its Humanity photos must be of the user's team and
+// a post must be written by the user.
+func CheckMediaTarget(ctx context.Context, db *postgresql.PrismaClient, userID string, target model.UploadTarget, targetID string) error {
+	switch target {
+	case model.UploadTargetProfileAvatar, model.UploadTargetProfileTngReceipt:
+		return CheckProfileOwner(ctx, db, userID, targetID)
+	case model.UploadTargetTeamAvatar, model.UploadTargetHumanityPhoto:
+		user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if teamID, ok := user.TeamID(); !ok || teamID != targetID {
+			return fmt.Errorf("you are not a member of team %s", targetID)
+		}
+	case model.UploadTargetPostImage:
+		post, err := db.Post.FindUnique(postgresql.Post.ID.Equals(targetID)).Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if post.UserID != userID {
+			return fmt.Errorf("post %s is not yours", targetID)
+		}
+	default:
+		return fmt.Errorf("unsupported upload target %s", target)
+	}
+
+	return nil
+}
+
+func setMediaKey(ctx context.Context, db *postgresql.PrismaClient, target model.UploadTarget, targetID string, slot *int, key string) error {
+	var err error
+	switch target {
+	case model.UploadTargetPostImage:
+		// append in the database so uploads made at the same time do not overwrite each other
+		var res *types.BatchResult
+		res, err = db.Prisma.ExecuteRaw(`
+			UPDATE
+				"Post"
+			SET
+				images = array_append(images, $1::text), updated_at = $2::timestamp
+			WHERE
+				id = $3::uuid
+		`, key, time.Now().UTC().Format(time.RFC3339Nano), targetID).Exec(ctx)
+		if err == nil && res.Count == 0 {
+			err = postgresql.ErrNotFound
+		}
+	case model.UploadTargetProfileAvatar:
+		_, err = db.Profile.FindUnique(postgresql.Profile.ID.Equals(targetID)).Update(
+			postgresql.Profile.AvatarURL.Set(key),
+			postgresql.Profile.UpdatedAt.Set(time.Now()),
+		).Exec(ctx)
+	case model.UploadTargetProfileTngReceipt:
+		// a new receipt goes back into the queue, a verification was of the old receipt
+		_, err = db.Profile.FindUnique(postgresql.Profile.ID.Equals(targetID)).Update(
+			postgresql.Profile.TngReceiptURL.Set(key),
+			postgresql.Profile.PaymentStatus.Set(postgresql.PaymentStatusPENDING),
+			postgresql.Profile.UpdatedAt.Set(time.Now()),
+		).Exec(ctx)
+	case model.UploadTargetTeamAvatar:
+		_, err = db.Team.FindUnique(postgresql.Team.ID.Equals(targetID)).Update(
+			postgresql.Team.AvatarURL.Set(key),
+		).Exec(ctx)
+	case model.UploadTargetHumanityPhoto:
+		photos := map[int]postgresql.HumanitySetParam{
+			1: postgresql.Humanity.Photo1.Set(key),
+			2: postgresql.Humanity.Photo2.Set(key),
+			3: postgresql.Humanity.Photo3.Set(key),
+		}
+		_, err = db.Humanity.FindUnique(postgresql.Humanity.TeamID.Equals(targetID)).Update(
+			photos[*slot],
+			postgresql.Humanity.UpdatedAt.Set(time.Now()),
+		).Exec(ctx)
+	default:
+		err = fmt.Errorf("unsupported upload target %s", target)
+	}
+
+	return err
+}
+
+// GetImageURLs returns freshly signed URLs of the given size for images kept in our
+// storage, URLs pointing elsewhere are returned as they are.
+func GetImageURLs(ctx context.Context, store storage.Storage, config storage.Config, urls []string, size *model.ImageSize) ([]string, error) {
+	variant := imaging.Original
+	if size != nil {
+		v, ok := imageVariants[*size]
+		if !ok {
+			return nil, fmt.Errorf("unsupported image size %s", *size)
+		}
+		variant = v
+	}
+
+	signed := make([]string, 0, len(urls))
+	for _, url := range urls {
+		key, ok := mediaKey(store, url)
+		if !ok {
+			signed = append(signed, url)
+			continue
+		}
+
+		url, err := store.SignedURL(ctx, imaging.VariantKey(key, variant), config.URLExpiry)
+		if err != nil {
+			return nil, err
+		}
+		signed = append(signed, url)
+	}
+
+	return signed, nil
+}
+
+// GetMediaURL signs a single media field the same way GetImageURLs does, nil stays nil.
+func GetMediaURL(ctx context.Context, store storage.Storage, config storage.Config, url *string) (*string, error) {
+	if url == nil {
+		return nil, nil
+	}
+
+	signed, err := GetImageURLs(ctx, store, config, []string{*url}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signed[0], nil
+}
+
+// mediaKey returns the storage key of a media field. Uploads save the key itself, while
+// fields saved before that hold a URL signed by the storage.
+func mediaKey(store storage.Storage, url string) (string, bool) {
+	if !strings.Contains(url, "://") {
+		return url, url != ""
+	}
+	return store.KeyFromURL(url)
+}
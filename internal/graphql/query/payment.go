@@ -0,0 +1,111 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// GetPendingPayments is the queue of users whose receipt is waiting to be checked, in
+// the order they registered. A user who has not uploaded a receipt is not in the queue.
+func GetPendingPayments(ctx context.Context, db *postgresql.PrismaClient, page model.PaginationInput) ([]*model.User, error) {
+	// fetch the users
+	fetchedUsers, err := db.User.FindMany(
+		postgresql.User.Profile.Where(
+			postgresql.Profile.PaymentStatus.Equals(postgresql.PaymentStatusPENDING),
+			postgresql.Profile.Not(postgresql.Profile.TngReceiptURL.IsNull()),
+		),
+	).OrderBy(
+		postgresql.User.CreatedAt.Order(postgresql.ASC),
+	).Take(page.Limit).Skip(page.Offset).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse users to graphql type
+	users, err := model.MapToUsers(fetchedUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// VerifyPayment marks the payment of a user as verified by a crew, a payment that was
+// rejected can still be verified.
+func VerifyPayment(ctx context.Context, db *postgresql.PrismaClient, verifierID string, userID string, note *string) (*model.User, error) {
+	return reviewPayment(ctx, db, verifierID, userID, postgresql.PaymentStatusVERIFIED, note)
+}
+
+// RejectPayment marks the payment of a user as rejected, the note tells the user what
+// was wrong with the receipt.
+func RejectPayment(ctx context.Context, db *postgresql.PrismaClient, verifierID string, userID string, note string) (*model.User, error) {
+	return reviewPayment(ctx, db, verifierID, userID, postgresql.PaymentStatusREJECTED, &note)
+}
+
+// reviewPayment only updates a payment that is not already in the status, so two crew
+// reviewing the same payment at once cannot both go through.
+func reviewPayment(ctx context.Context, db *postgresql.PrismaClient, verifierID string, userID string, status postgresql.PaymentStatus, note *string) (*model.User, error) {
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.Profile.FindMany(
+		postgresql.Profile.ID.Equals(user.ProfileID),
+		postgresql.Profile.PaymentStatus.Not(status),
+	).Update(
+		postgresql.Profile.PaymentStatus.Set(status),
+		postgresql.Profile.PaymentNote.SetOptional(note),
+		postgresql.Profile.PaymentVerifierID.Set(verifierID),
+		postgresql.Profile.PaymentVerifiedAt.Set(time.Now()),
+		postgresql.Profile.UpdatedAt.Set(time.Now()),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Count == 0 {
+		return nil, fmt.Errorf("payment is already %s", status)
+	}
+
+	// fetch the user again to return the reviewed payment
+	user, err = db.User.FindUnique(postgresql.User.ID.Equals(userID)).With(
+		postgresql.User.Profile.Fetch(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.MapToUser(user)
+}
+
+// CheckPaymentVerified fails unless the payment of the user has been verified, it is
+// checked before a user joins a team when the event requires it.
+func CheckPaymentVerified(ctx context.Context, db *postgresql.PrismaClient, userID string) error {
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).With(
+		postgresql.User.Profile.Fetch(),
+	).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if status := user.Profile().PaymentStatus; status != postgresql.PaymentStatusVERIFIED {
+		return fmt.Errorf("payment must be %s to join a team but it is %s", postgresql.PaymentStatusVERIFIED, status)
+	}
+	return nil
+}
+
+// CheckProfileOwner makes sure a profile belongs to the user, such as before a receipt
+// is uploaded for it.
+func CheckProfileOwner(ctx context.Context, db *postgresql.PrismaClient, userID string, profileID string) error {
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if user.ProfileID != profileID {
+		return fmt.Errorf("profile %s is not yours", profileID)
+	}
+	return nil
+}
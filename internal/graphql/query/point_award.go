@@ -0,0 +1,110 @@
+package query
+
+import (
+	"context"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// Sources of point awards, together with the reference of an award they make sure
+// the same thing is never awarded twice.
+const (
+	PointAwardSourceHumanity     = "HUMANITY"
+	PointAwardSourceDiscovery    = "DISCOVERY"
+	PointAwardSourceEscape       = "ESCAPE"
+	PointAwardSourceSpeed        = "SPEED"
+	PointAwardSourceBattleground = "BATTLEGROUND"
+	PointAwardSourceAdjustment   = "ADJUSTMENT"
+)
+
+func GetManyPointAward(ctx context.Context, db *postgresql.PrismaClient, page model.PaginationInput, params ...postgresql.PointAwardWhereParam) ([]*model.PointAward, error) {
+	// build query
+	query := db.PointAward.FindMany(params...).OrderBy(postgresql.PointAward.CreatedAt.Order(postgresql.DESC))
+
+	// apply pagination
+	query = query.Take(page.Limit)
+	query = query.Skip(page.Offset)
+
+	// fetch the point awards
+	fetchedPointAwards, err := query.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse point awards to graphql type
+	pointAwards, err := model.MapToPointAwards(fetchedPointAwards)
+	if err != nil {
+		return nil, err
+	}
+
+	return pointAwards, nil
+}
+
+// AwardPoints records an award and adds its points to the team in a single transaction,
+// extra transactions are executed along with it.
+func AwardPoints(ctx context.Context, db *postgresql.PrismaClient, teamID string, points float64, source string, reference string, reason *string, extra ...transaction.Param) (*model.PointAward, error) {
+	createPointAward := db.PointAward.CreateOne(
+		postgresql.PointAward.ID.Set(gofakeit.UUID()),
+		postgresql.PointAward.Points.Set(points),
+		postgresql.PointAward.Source.Set(source),
+		postgresql.PointAward.Reference.Set(reference),
+		postgresql.PointAward.Team.Link(postgresql.Team.ID.Equals(teamID)),
+		postgresql.PointAward.Reason.SetIfPresent(reason),
+	).Tx()
+	updateTeam := db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Update(
+		postgresql.Team.Points.Increment(points),
+	).Tx()
+
+	txs := append([]transaction.Param{createPointAward, updateTeam}, extra...)
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// parse point award to graphql type
+	pointAward, err := model.MapToPointAward(createPointAward.Result())
+	if err != nil {
+		return nil, err
+	}
+
+	return pointAward, nil
+}
+
+// RecordPointsAdjustment records an adjustment award without changing the points of the
+// team, for points a team was given without an award so its awards add up again.
+func RecordPointsAdjustment(ctx context.Context, db *postgresql.PrismaClient, teamID string, points float64, reason string) (*model.PointAward, error) {
+	createdPointAward, err := db.PointAward.CreateOne(
+		postgresql.PointAward.ID.Set(gofakeit.UUID()),
+		postgresql.PointAward.Points.Set(points),
+		postgresql.PointAward.Source.Set(PointAwardSourceAdjustment),
+		postgresql.PointAward.Reference.Set(gofakeit.UUID()),
+		postgresql.PointAward.Team.Link(postgresql.Team.ID.Equals(teamID)),
+		postgresql.PointAward.Reason.Set(reason),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse point award to graphql type
+	return model.MapToPointAward(createdPointAward)
+}
+
+// awardPointsTxs builds the transactions of an award without executing them, for
+// callers that award several teams in a single transaction.
+func awardPointsTxs(db *postgresql.PrismaClient, teamID string, points float64, source string, reference string, reason *string) []transaction.Param {
+	return []transaction.Param{
+		db.PointAward.CreateOne(
+			postgresql.PointAward.ID.Set(gofakeit.UUID()),
+			postgresql.PointAward.Points.Set(points),
+			postgresql.PointAward.Source.Set(source),
+			postgresql.PointAward.Reference.Set(reference),
+			postgresql.PointAward.Team.Link(postgresql.Team.ID.Equals(teamID)),
+			postgresql.PointAward.Reason.SetIfPresent(reason),
+		).Tx(),
+		db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Update(
+			postgresql.Team.Points.Increment(points),
+		).Tx(),
+	}
+}
@@ -2,6 +2,10 @@ package query
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -67,3 +71,226 @@ func CreatePost(ctx context.Context, db *postgresql.PrismaClient, param *model.N
 
 	return post, nil
 }
+
+// postOrderScores maps each post ordering to the SQL expression used to rank posts,
+// ties are always broken by the newest post first.
+var postOrderScores = map[model.PostOrder]string{
+	model.PostOrderNewest:        `0`,
+	model.PostOrderMostLiked:     `(SELECT COUNT(*) FROM "PostLike" PL WHERE PL."postId" = P.id)::int`,
+	model.PostOrderMostCommented: `(SELECT COUNT(*) FROM "Comment" C WHERE C."postId" = P.id)::int`,
+}
+
+// postFeedScope* are the SQL conditions used to narrow down a feed, each
+// condition expects the id of the user, team or cluster as $1.
+const (
+	postFeedScopeAll     = `TRUE`
+	postFeedScopeUser    = `P."userId" = $1`
+	postFeedScopeTeam    = `U."teamId" = $1`
+	postFeedScopeCluster = `T."clusterId" = $1`
+)
+
+// maxPageLimit is the most items a page can hold, so a client cannot ask for a whole
+// table at once.
+const maxPageLimit = 100
+
+// checkPage makes sure a page is within bounds before it reaches the database.
+func checkPage(offset int, limit int) error {
+	if limit < 1 || limit > maxPageLimit {
+		return fmt.Errorf("limit must be between 1 and %d, got %d", maxPageLimit, limit)
+	}
+	if offset < 0 {
+		return fmt.Errorf("offset must not be negative, got %d", offset)
+	}
+	return nil
+}
+
+type postFeedRow struct {
+	ID        string    `json:"id"`
+	Score     int       `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// postCursor points at the last post of a page, it is encoded as an opaque string
+// so clients can only pass back what they were given.
+type postCursor struct {
+	Score     int
+	CreatedAt time.Time
+	ID        string
+}
+
+func (c postCursor) encode() string {
+	raw := fmt.Sprintf("%d|%s|%s", c.Score, c.CreatedAt.UTC().Format(time.RFC3339Nano), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodePostCursor(cursor string) (*postCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %s", cursor)
+	}
+
+	parts := strings.SplitN(string(raw), "|", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid cursor %s", cursor)
+	}
+
+	score, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %s", cursor)
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %s", cursor)
+	}
+
+	return &postCursor{Score: score, CreatedAt: createdAt, ID: parts[2]}, nil
+}
+
+func GetManyOrderedPost(ctx context.Context, db *postgresql.PrismaClient, page model.PaginationInput, order *model.PostOrder) ([]*model.Post, error) {
+	if err := checkPage(page.Offset, page.Limit); err != nil {
+		return nil, err
+	}
+
+	rows, err := queryPostFeed(ctx, db, postFeedScopeAll, nil, order, nil, page.Offset, page.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPostsInOrder(ctx, db, rows)
+}
+
+func GetUserPostFeed(ctx context.Context, db *postgresql.PrismaClient, userID string, page model.CursorPaginationInput, order *model.PostOrder) (*model.PostFeed, error) {
+	return getPostFeed(ctx, db, postFeedScopeUser, userID, page, order)
+}
+
+func GetTeamPostFeed(ctx context.Context, db *postgresql.PrismaClient, teamID string, page model.CursorPaginationInput, order *model.PostOrder) (*model.PostFeed, error) {
+	return getPostFeed(ctx, db, postFeedScopeTeam, teamID, page, order)
+}
+
+func GetClusterPostFeed(ctx context.Context, db *postgresql.PrismaClient, clusterID string, page model.CursorPaginationInput, order *model.PostOrder) (*model.PostFeed, error) {
+	return getPostFeed(ctx, db, postFeedScopeCluster, clusterID, page, order)
+}
+
+func getPostFeed(ctx context.Context, db *postgresql.PrismaClient, scope string, scopeID string, page model.CursorPaginationInput, order *model.PostOrder) (*model.PostFeed, error) {
+	if err := checkPage(0, page.Limit); err != nil {
+		return nil, err
+	}
+
+	var after *postCursor
+	if page.After != nil {
+		cursor, err := decodePostCursor(*page.After)
+		if err != nil {
+			return nil, err
+		}
+		after = cursor
+	}
+
+	// fetch one extra row to know whether there is a next page
+	rows, err := queryPostFeed(ctx, db, scope, &scopeID, order, after, 0, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &model.PostFeed{}
+	if len(rows) > page.Limit {
+		rows = rows[:page.Limit]
+		feed.HasMore = true
+	}
+	if len(rows) > 0 {
+		last := rows[len(rows)-1]
+		cursor := postCursor{Score: last.Score, CreatedAt: last.CreatedAt, ID: last.ID}.encode()
+		feed.NextCursor = &cursor
+	}
+
+	feed.Posts, err = getPostsInOrder(ctx, db, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func queryPostFeed(ctx context.Context, db *postgresql.PrismaClient, scope string, scopeID *string, order *model.PostOrder, after *postCursor, offset int, limit int) ([]postFeedRow, error) {
+	score := postOrderScores[model.PostOrderNewest]
+	if order != nil {
+		score = postOrderScores[*order]
+	}
+
+	// positional parameters are appended in the same order they appear in the query
+	var params []interface{}
+	if scopeID != nil {
+		params = append(params, *scopeID)
+	}
+
+	keyset := `TRUE`
+	if after != nil {
+		n := len(params)
+		keyset = fmt.Sprintf(`(F.score, F.created_at, F.id) < ($%d::int, $%d::timestamp, $%d::uuid)`, n+1, n+2, n+3)
+		params = append(params, after.Score, after.CreatedAt.UTC().Format(time.RFC3339Nano), after.ID)
+	}
+
+	n := len(params)
+	params = append(params, limit, offset)
+
+	var rows []postFeedRow
+	err := db.Prisma.QueryRaw(fmt.Sprintf(`
+		SELECT
+			F.id, F.score, F.created_at
+		FROM (
+			SELECT
+				P.id, P.created_at, %s AS score
+			FROM
+				"Post" P
+				INNER JOIN "User" U ON P."userId" = U.id
+				LEFT JOIN "Team" T ON U."teamId" = T.id
+			WHERE
+				%s
+		) F
+		WHERE
+			%s
+		ORDER BY
+			F.score DESC, F.created_at DESC, F.id DESC
+		LIMIT $%d OFFSET $%d;
+	`, score, scope, keyset, n+1, n+2), params...).Exec(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// getPostsInOrder fetches the posts of the given feed rows while keeping the order of the rows.
+func getPostsInOrder(ctx context.Context, db *postgresql.PrismaClient, rows []postFeedRow) ([]*model.Post, error) {
+	if len(rows) == 0 {
+		return []*model.Post{}, nil
+	}
+
+	var ids []string
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	fetchedPosts, err := db.Post.FindMany(postgresql.Post.ID.In(ids)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := model.MapToPosts(fetchedPosts)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	ordered := make([]*model.Post, 0, len(rows))
+	for _, row := range rows {
+		if post, ok := byID[row.ID]; ok {
+			ordered = append(ordered, post)
+		}
+	}
+
+	return ordered, nil
+}
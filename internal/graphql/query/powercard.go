@@ -0,0 +1,186 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+var (
+	// ErrPowercardHeld is returned when granting a powercard the team already holds.
+	ErrPowercardHeld = errors.New("team already holds the powercard")
+	// ErrPowercardGranted is returned when the source and reference already granted the powercard.
+	ErrPowercardGranted = errors.New("powercard was already granted for the reference")
+)
+
+func GetManyPowercardEvent(ctx context.Context, db *postgresql.PrismaClient, params ...postgresql.PowercardEventWhereParam) ([]*model.PowercardEvent, error) {
+	// fetch the powercard events
+	fetchedPowercardEvents, err := db.PowercardEvent.FindMany(params...).OrderBy(
+		postgresql.PowercardEvent.CreatedAt.Order(postgresql.DESC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse powercard events to graphql type
+	powercardEvents, err := model.MapToPowercardEvents(fetchedPowercardEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	return powercardEvents, nil
+}
+
+// GrantPowercard adds a powercard to the eligible powercards of a team and records why.
+// A team holds at most one of each powercard, and a source and reference grant a
+// powercard only once so scripts can safely run again.
+func GrantPowercard(ctx context.Context, db *postgresql.PrismaClient, param *model.GrantPowercardInput, userID *string) (*model.Team, error) {
+	team, err := db.Team.FindUnique(postgresql.Team.ID.Equals(param.TeamID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	powercard := postgresql.Powercard(param.Powercard)
+	if hasPowercard(team.EligiblePowercards, powercard) {
+		return nil, ErrPowercardHeld
+	}
+	if param.Source != nil && param.Reference != nil {
+		granted, err := db.PowercardEvent.FindFirst(
+			postgresql.PowercardEvent.TeamID.Equals(team.ID),
+			postgresql.PowercardEvent.Powercard.Equals(powercard),
+			postgresql.PowercardEvent.Action.Equals(postgresql.PowercardActionGRANT),
+			postgresql.PowercardEvent.Source.Equals(*param.Source),
+			postgresql.PowercardEvent.Reference.Equals(*param.Reference),
+		).Exec(ctx)
+		if err != nil && err != postgresql.ErrNotFound {
+			return nil, err
+		}
+		if granted != nil {
+			return nil, ErrPowercardGranted
+		}
+	}
+
+	// the powercard is appended and its grant recorded in one statement that only goes
+	// through when the team does not hold it yet, so grants made at the same time
+	// cannot add it twice or lose one another
+	result, err := db.Prisma.ExecuteRaw(`
+		WITH granted AS (
+			UPDATE
+				"Team"
+			SET
+				"eligiblePowercards" = array_append("eligiblePowercards", $2::"Powercard")
+			WHERE
+				id = $1::uuid AND
+				NOT ($2::"Powercard" = ANY(COALESCE("eligiblePowercards", '{}')))
+			RETURNING
+				id
+		)
+		INSERT INTO "PowercardEvent"
+			(id, "teamId", powercard, action, reason, source, reference, "userId", "createdAt")
+		SELECT
+			$3::uuid, G.id, $2::"Powercard", $4::"PowercardAction", $5, $6, $7, $8::uuid, $9::timestamp
+		FROM
+			granted G
+	`, team.ID, string(powercard), gofakeit.UUID(), string(postgresql.PowercardActionGRANT), param.Reason, param.Source, param.Reference, userID, time.Now().UTC().Format(time.RFC3339Nano)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Count == 0 {
+		return nil, ErrPowercardHeld
+	}
+
+	return GetUniqueTeam(ctx, db, postgresql.Team.ID.Equals(team.ID))
+}
+
+// RevokePowercard removes a powercard from the eligible powercards of a team, the
+// powercard is unequipped when the team has it equipped.
+func RevokePowercard(ctx context.Context, db *postgresql.PrismaClient, param *model.RevokePowercardInput, userID *string) (*model.Team, error) {
+	team, err := db.Team.FindUnique(postgresql.Team.ID.Equals(param.TeamID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	powercard := postgresql.Powercard(param.Powercard)
+	if !hasPowercard(team.EligiblePowercards, powercard) {
+		return nil, fmt.Errorf("team %s does not hold %s", team.ID, powercard)
+	}
+
+	eligiblePowercards := []postgresql.Powercard{}
+	for _, eligiblePowercard := range team.EligiblePowercards {
+		if eligiblePowercard != powercard {
+			eligiblePowercards = append(eligiblePowercards, eligiblePowercard)
+		}
+	}
+	var equipped *postgresql.Powercard
+	if res, ok := team.Powercard(); ok && res != powercard {
+		equipped = &res
+	}
+
+	createPowercardEvent := db.PowercardEvent.CreateOne(
+		postgresql.PowercardEvent.ID.Set(gofakeit.UUID()),
+		postgresql.PowercardEvent.Powercard.Set(powercard),
+		postgresql.PowercardEvent.Action.Set(postgresql.PowercardActionREVOKE),
+		postgresql.PowercardEvent.Team.Link(postgresql.Team.ID.Equals(team.ID)),
+		postgresql.PowercardEvent.Reason.Set(param.Reason),
+		postgresql.PowercardEvent.UserID.SetIfPresent(userID),
+	).Tx()
+	updateTeam := db.Team.FindUnique(postgresql.Team.ID.Equals(team.ID)).Update(
+		postgresql.Team.EligiblePowercards.Set(eligiblePowercards),
+		postgresql.Team.Powercard.SetOptional(equipped),
+	).Tx()
+	if err := db.Prisma.Transaction(createPowercardEvent, updateTeam).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return model.MapToTeam(updateTeam.Result())
+}
+
+// EquipPowercard sets the powercard of a team to one of its eligible powercards. When a
+// user equips it, the user must be a member of the team.
+func EquipPowercard(ctx context.Context, db *postgresql.PrismaClient, teamID string, powercard model.Powercard, userID *string) (*model.Team, error) {
+	if userID != nil {
+		user, err := db.User.FindUnique(postgresql.User.ID.Equals(*userID)).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if res, ok := user.TeamID(); !ok || res != teamID {
+			return nil, fmt.Errorf("you are not a member of team %s", teamID)
+		}
+	}
+
+	team, err := db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPowercard(team.EligiblePowercards, postgresql.Powercard(powercard)) {
+		return nil, fmt.Errorf("team %s is not eligible for %s", teamID, powercard)
+	}
+
+	createPowercardEvent := db.PowercardEvent.CreateOne(
+		postgresql.PowercardEvent.ID.Set(gofakeit.UUID()),
+		postgresql.PowercardEvent.Powercard.Set(postgresql.Powercard(powercard)),
+		postgresql.PowercardEvent.Action.Set(postgresql.PowercardActionEQUIP),
+		postgresql.PowercardEvent.Team.Link(postgresql.Team.ID.Equals(teamID)),
+		postgresql.PowercardEvent.UserID.SetIfPresent(userID),
+	).Tx()
+	updateTeam := db.Team.FindUnique(postgresql.Team.ID.Equals(teamID)).Update(
+		postgresql.Team.Powercard.Set(postgresql.Powercard(powercard)),
+	).Tx()
+	if err := db.Prisma.Transaction(createPowercardEvent, updateTeam).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return model.MapToTeam(updateTeam.Result())
+}
+
+func hasPowercard(powercards []postgresql.Powercard, powercard postgresql.Powercard) bool {
+	for _, p := range powercards {
+		if p == powercard {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// RegistrationStatsLocation is the time zone registrations are counted per day in.
+var RegistrationStatsLocation = mustLoadLocation("Asia/Kuala_Lumpur")
+
+// registrationAgeBand buckets the age of a user at the time of the query, the bands
+// are ordered by the youngest age in them.
+const registrationAgeBand = `
+	CASE
+		WHEN date_part('year', age(P.dob)) < 18 THEN 'UNDER_18'
+		WHEN date_part('year', age(P.dob)) < 25 THEN '18_TO_24'
+		WHEN date_part('year', age(P.dob)) < 35 THEN '25_TO_34'
+		WHEN date_part('year', age(P.dob)) < 45 THEN '35_TO_44'
+		ELSE '45_AND_ABOVE'
+	END
+`
+
+// GetRegistrationStats counts the registered users by their profile, per day and the
+// teams by how many members they have. A user without a satellite or pastoral status
+// is counted under a null key.
+func GetRegistrationStats(ctx context.Context, db *postgresql.PrismaClient) (*model.RegistrationStats, error) {
+	total, err := GetTotalUserCount(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	bySatellite, err := getRegistrationCounts(ctx, db, `P.satellite::text`, `count DESC, key`)
+	if err != nil {
+		return nil, err
+	}
+	byGender, err := getRegistrationCounts(ctx, db, `P.gender::text`, `count DESC, key`)
+	if err != nil {
+		return nil, err
+	}
+	byStatus, err := getRegistrationCounts(ctx, db, `P.status::text`, `count DESC, key`)
+	if err != nil {
+		return nil, err
+	}
+	byAgeBand, err := getRegistrationCounts(ctx, db, registrationAgeBand, `MIN(date_part('year', age(P.dob)))`)
+	if err != nil {
+		return nil, err
+	}
+
+	perDay := []*model.RegistrationDay{}
+	err = db.Prisma.QueryRaw(`
+		SELECT
+			to_char(U."createdAt" AT TIME ZONE 'UTC' AT TIME ZONE $1, 'YYYY-MM-DD') AS date,
+			COUNT(*) AS count
+		FROM
+			"User" U
+		GROUP BY
+			1
+		ORDER BY
+			1
+	`, RegistrationStatsLocation.String()).Exec(ctx, &perDay)
+	if err != nil {
+		return nil, err
+	}
+
+	teamsByFill := []*model.TeamFillCount{}
+	err = db.Prisma.QueryRaw(`
+		SELECT
+			F.members, COUNT(*) AS teams
+		FROM (
+			SELECT
+				T.id, COUNT(U.id) AS members
+			FROM
+				"Team" T LEFT JOIN "User" U ON U."teamId" = T.id
+			GROUP BY
+				T.id
+		) F
+		GROUP BY
+			F.members
+		ORDER BY
+			F.members
+	`).Exec(ctx, &teamsByFill)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.RegistrationStats{
+		Total:       total,
+		BySatellite: bySatellite,
+		ByGender:    byGender,
+		ByStatus:    byStatus,
+		ByAgeBand:   byAgeBand,
+		PerDay:      perDay,
+		TeamsByFill: teamsByFill,
+	}, nil
+}
+
+// getRegistrationCounts counts the users grouped by an expression on their profile, the
+// expressions are fixed in this file and never come from the request.
+func getRegistrationCounts(ctx context.Context, db *postgresql.PrismaClient, key string, orderBy string) ([]*model.RegistrationCount, error) {
+	counts := []*model.RegistrationCount{}
+	err := db.Prisma.QueryRaw(fmt.Sprintf(`
+		SELECT
+			%s AS key,
+			COUNT(*) AS count
+		FROM
+			"User" U INNER JOIN "Profile" P ON U."profileId" = P.id
+		GROUP BY
+			1
+		ORDER BY
+			%s
+	`, key, orderBy)).Exec(ctx, &counts)
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,141 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+)
+
+// searchHeadlineOptions wraps matched words with <b></b> and trims long text down
+// to the fragment around the match.
+const searchHeadlineOptions = `StartSel=<b>, StopSel=</b>, MaxWords=20, MinWords=8`
+
+// searchQueries holds the query of each search type, every query returns the columns
+// of model.SearchResult and expects the tsquery as $1, the queries in
+// searchPatternTypes also expect a LIKE pattern of the keyword as $2. The tsvector
+// expressions must match the indexes created in the added_search_indexes migration.
+// Every type is ranked with ts_rank so the ranks can be ordered together, a user only
+// found by their Chinese name has nothing for ts_rank to score and ranks last.
+var searchQueries = map[model.SearchType]string{
+	model.SearchTypeUser: `
+		SELECT
+			'USER' AS type,
+			U.id,
+			ts_rank(
+				to_tsvector('simple', U.username) || to_tsvector('simple', P."nameEng"),
+				to_tsquery('simple', $1)
+			)::float8 AS rank,
+			ts_headline(
+				'simple',
+				P."nameEng" || COALESCE(' ' || P."nameChi", '') || ' @' || U.username,
+				to_tsquery('simple', $1),
+				'` + searchHeadlineOptions + `'
+			) AS snippet
+		FROM
+			"User" U INNER JOIN "Profile" P ON U."profileId" = P.id
+		WHERE
+			to_tsvector('simple', U.username) @@ to_tsquery('simple', $1) OR
+			to_tsvector('simple', P."nameEng") @@ to_tsquery('simple', $1) OR
+			P."nameChi" LIKE $2::text
+	`,
+	model.SearchTypeTeam: `
+		SELECT
+			'TEAM' AS type,
+			T.id,
+			ts_rank(to_tsvector('simple', COALESCE(T.name, '')), to_tsquery('simple', $1))::float8 AS rank,
+			ts_headline('simple', COALESCE(T.name, ''), to_tsquery('simple', $1), '` + searchHeadlineOptions + `') AS snippet
+		FROM
+			"Team" T
+		WHERE
+			to_tsvector('simple', COALESCE(T.name, '')) @@ to_tsquery('simple', $1)
+	`,
+	model.SearchTypePost: `
+		SELECT
+			'POST' AS type,
+			P.id,
+			ts_rank(to_tsvector('simple', P.content), to_tsquery('simple', $1))::float8 AS rank,
+			ts_headline('simple', P.content, to_tsquery('simple', $1), '` + searchHeadlineOptions + `') AS snippet
+		FROM
+			"Post" P
+		WHERE
+			to_tsvector('simple', P.content) @@ to_tsquery('simple', $1)
+	`,
+}
+
+// searchPatternTypes are the search types whose query matches the keyword with LIKE,
+// the pattern is only bound when one of them is searched as Postgres cannot tell the
+// type of a parameter that no query uses.
+var searchPatternTypes = map[model.SearchType]bool{
+	model.SearchTypeUser: true,
+}
+
+func Search(ctx context.Context, db *postgresql.PrismaClient, keyword string, types []model.SearchType, page model.PaginationInput) ([]*model.SearchResult, error) {
+	if err := checkPage(page.Offset, page.Limit); err != nil {
+		return nil, err
+	}
+
+	tsQuery := toPrefixTsQuery(keyword)
+	if tsQuery == "" {
+		return []*model.SearchResult{}, nil
+	}
+
+	// search everything when no type is given
+	if len(types) == 0 {
+		types = model.AllSearchType
+	}
+
+	// build query
+	var queries []string
+	args := []interface{}{tsQuery}
+	for _, searchType := range types {
+		q, ok := searchQueries[searchType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported search type %s", searchType)
+		}
+		queries = append(queries, q)
+		if searchPatternTypes[searchType] && len(args) == 1 {
+			args = append(args, "%"+escapeLike(strings.TrimSpace(keyword))+"%")
+		}
+	}
+	sql := fmt.Sprintf(`
+		SELECT
+			*
+		FROM (%s) R
+		ORDER BY
+			R.rank DESC, R.id
+		LIMIT $%d OFFSET $%d;
+	`, strings.Join(queries, "UNION ALL"), len(args)+1, len(args)+2)
+	args = append(args, page.Limit, page.Offset)
+
+	// fetch the results
+	results := []*model.SearchResult{}
+	err := db.Prisma.QueryRaw(sql, args...).Exec(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// toPrefixTsQuery turns free text into a tsquery where every word has to match
+// as a prefix, so that partial words like "marc" still find "marcus". Anything
+// other than letters and digits is dropped to keep the tsquery syntax valid.
+func toPrefixTsQuery(keyword string) string {
+	words := strings.FieldsFunc(keyword, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = strings.ToLower(word) + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -2,11 +2,14 @@ package query
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/answer"
 	"github.com/marcustut/thebox/internal/graphql/model"
 	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
 )
 
 func GetUniqueSpeed(ctx context.Context, db *postgresql.PrismaClient, param postgresql.SpeedEqualsUniqueWhereParam) (*model.Speed, error) {
@@ -56,12 +59,8 @@ func UpsertUniqueSpeed(ctx context.Context, db *postgresql.PrismaClient, param *
 		postgresql.Speed.UpdatedAt.Set(time.Now()),
 		postgresql.Speed.Mission.Link(postgresql.Mission.ID.Equals(param.MissionID)),
 		postgresql.Speed.Team.Link(postgresql.Team.ID.Equals(param.TeamID)),
-		postgresql.Speed.CompletedAt.SetIfPresent(param.CompletedAt),
-		postgresql.Speed.Answer.SetIfPresent(param.Answer),
 	).Update(
 		postgresql.Speed.UpdatedAt.Set(time.Now()),
-		postgresql.Speed.CompletedAt.SetIfPresent(param.CompletedAt),
-		postgresql.Speed.Answer.SetIfPresent(param.Answer),
 	).Update().Exec(ctx)
 	if err != nil {
 		return nil, err
@@ -75,3 +74,240 @@ func UpsertUniqueSpeed(ctx context.Context, db *postgresql.PrismaClient, param *
 
 	return speed, nil
 }
+
+func GetManySpeedAttempt(ctx context.Context, db *postgresql.PrismaClient, params ...postgresql.SpeedAttemptWhereParam) ([]*model.SpeedAttempt, error) {
+	// fetch the attempts
+	fetchedSpeedAttempts, err := db.SpeedAttempt.FindMany(params...).OrderBy(
+		postgresql.SpeedAttempt.CreatedAt.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse attempts to graphql type
+	speedAttempts, err := model.MapToSpeedAttempts(fetchedSpeedAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	return speedAttempts, nil
+}
+
+// SetSpeedAnswers replaces the expected answers of a mission, every answer is accepted
+// so aliases are simply passed along with the main answer. Only hashes of the
+// normalized answers are kept.
+func SetSpeedAnswers(ctx context.Context, db *postgresql.PrismaClient, param *model.SetSpeedAnswersInput) (*model.Mission, error) {
+	if len(param.Answers) == 0 {
+		return nil, fmt.Errorf("at least one answer is required")
+	}
+	if param.MaxAttempts != nil && *param.MaxAttempts < 1 {
+		return nil, fmt.Errorf("maxAttempts must be at least 1, got %d", *param.MaxAttempts)
+	}
+	if param.AttemptCooldown != nil && *param.AttemptCooldown < 1 {
+		return nil, fmt.Errorf("attemptCooldown must be at least 1 second, got %d", *param.AttemptCooldown)
+	}
+
+	var txs []transaction.Param
+	txs = append(txs, db.SpeedAnswer.FindMany(postgresql.SpeedAnswer.MissionID.Equals(param.MissionID)).Delete().Tx())
+	for _, expected := range param.Answers {
+		if answer.Normalize(expected) == "" {
+			return nil, fmt.Errorf("answer %q is empty once normalized", expected)
+		}
+		salt, err := answer.NewSalt()
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, db.SpeedAnswer.CreateOne(
+			postgresql.SpeedAnswer.ID.Set(gofakeit.UUID()),
+			postgresql.SpeedAnswer.Hash.Set(answer.Hash(expected, salt)),
+			postgresql.SpeedAnswer.Salt.Set(salt),
+			postgresql.SpeedAnswer.Mission.Link(postgresql.Mission.ID.Equals(param.MissionID)),
+		).Tx())
+	}
+	updateMission := db.Mission.FindUnique(postgresql.Mission.ID.Equals(param.MissionID)).Update(
+		postgresql.Mission.MaxAttempts.SetOptional(param.MaxAttempts),
+		postgresql.Mission.AttemptCooldown.SetOptional(param.AttemptCooldown),
+		postgresql.Mission.UpdatedAt.Set(time.Now()),
+	).Tx()
+	txs = append(txs, updateMission)
+
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// parse mission to graphql type
+	mission, err := model.MapToMission(updateMission.Result())
+	if err != nil {
+		return nil, err
+	}
+
+	return mission, nil
+}
+
+// SubmitSpeedAnswer checks the answer of a team and records the attempt. The mission is
+// completed at the time the server receives the first correct answer. When the mission
+// limits attempts, a team gets maxAttempts tries within every attemptCooldown seconds,
+// or maxAttempts tries in total when there is no cooldown.
+func SubmitSpeedAnswer(ctx context.Context, db *postgresql.PrismaClient, userID string, param *model.SubmitSpeedAnswerInput) (*model.SpeedAnswerResult, error) {
+	now := time.Now()
+
+	// only members of the team can answer for it
+	user, err := db.User.FindUnique(postgresql.User.ID.Equals(userID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if teamID, ok := user.TeamID(); !ok || teamID != param.TeamID {
+		return nil, fmt.Errorf("you are not a member of team %s", param.TeamID)
+	}
+
+	mission, err := db.Mission.FindUnique(postgresql.Mission.ID.Equals(param.MissionID)).With(
+		postgresql.Mission.SpeedAnswers.Fetch(),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(mission.SpeedAnswers()) == 0 {
+		return nil, fmt.Errorf("mission %s has no answers yet", param.MissionID)
+	}
+
+	speed, err := db.Speed.UpsertOne(
+		postgresql.Speed.TeamID.Equals(param.TeamID),
+	).Create(
+		postgresql.Speed.ID.Set(gofakeit.UUID()),
+		postgresql.Speed.UpdatedAt.Set(now),
+		postgresql.Speed.Mission.Link(postgresql.Mission.ID.Equals(param.MissionID)),
+		postgresql.Speed.Team.Link(postgresql.Team.ID.Equals(param.TeamID)),
+	).Update().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := speed.CompletedAt(); ok {
+		return nil, fmt.Errorf("team %s has already completed the mission", param.TeamID)
+	}
+
+	// enforce the attempt limit
+	maxAttempts, limited := mission.MaxAttempts()
+	var windowStart time.Time
+	if cooldown, ok := mission.AttemptCooldown(); ok {
+		windowStart = now.Add(-time.Duration(cooldown) * time.Second)
+	}
+	var attempts []postgresql.SpeedAttemptModel
+	if limited {
+		attempts, err = db.SpeedAttempt.FindMany(
+			postgresql.SpeedAttempt.SpeedID.Equals(speed.ID),
+			postgresql.SpeedAttempt.CreatedAt.After(windowStart),
+		).OrderBy(
+			postgresql.SpeedAttempt.CreatedAt.Order(postgresql.ASC),
+		).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(attempts) >= maxAttempts {
+			if windowStart.IsZero() {
+				return nil, fmt.Errorf("no attempts left")
+			}
+			retryAt := attempts[len(attempts)-maxAttempts].CreatedAt.Add(now.Sub(windowStart))
+			return nil, fmt.Errorf("no attempts left, try again at %s", retryAt.Format(time.RFC3339))
+		}
+	}
+
+	// check the answer against every accepted answer
+	correct := false
+	for _, expected := range mission.SpeedAnswers() {
+		if answer.Matches(param.Answer, expected.Hash, expected.Salt) {
+			correct = true
+			break
+		}
+	}
+
+	recorded, err := recordSpeedAttempt(ctx, db, speed.ID, userID, param.Answer, correct, now, windowStart, maxAttempts, limited)
+	if err != nil {
+		return nil, err
+	}
+	updatedSpeed, err := db.Speed.FindUnique(postgresql.Speed.ID.Equals(speed.ID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !recorded {
+		// another attempt of the team got in first
+		if _, ok := updatedSpeed.CompletedAt(); ok {
+			return nil, fmt.Errorf("team %s has already completed the mission", param.TeamID)
+		}
+		return nil, fmt.Errorf("no attempts left")
+	}
+
+	// parse speed to graphql type
+	result := &model.SpeedAnswerResult{Correct: correct}
+	result.Speed, err = model.MapToSpeed(updatedSpeed)
+	if err != nil {
+		return nil, err
+	}
+
+	// let the team know how many tries they have left
+	if limited && !correct {
+		attemptsLeft := maxAttempts - len(attempts) - 1
+		result.AttemptsLeft = &attemptsLeft
+		if attemptsLeft == 0 && !windowStart.IsZero() {
+			first := now
+			if len(attempts) > 0 {
+				first = attempts[len(attempts)-maxAttempts+1].CreatedAt
+			}
+			retryAt := first.Add(now.Sub(windowStart))
+			result.RetryAt = &retryAt
+		}
+	}
+
+	return result, nil
+}
+
+// recordSpeedAttempt saves an attempt and completes the speed when it is correct. The
+// attempts of a team are counted and the attempt is saved in a single transaction that
+// first locks the speed of the team, so attempts made at the same time cannot go over
+// the limit or complete the mission twice. recorded is false when the attempt was
+// refused for either reason.
+func recordSpeedAttempt(ctx context.Context, db *postgresql.PrismaClient, speedID string, userID string, attempt string, correct bool, now time.Time, windowStart time.Time, maxAttempts int, limited bool) (recorded bool, err error) {
+	at := now.UTC().Format(time.RFC3339Nano)
+	attemptID := gofakeit.UUID()
+
+	lockSpeed := db.Prisma.ExecuteRaw(`
+		UPDATE
+			"Speed"
+		SET
+			"updatedAt" = $2::timestamp
+		WHERE
+			id = $1::uuid
+	`, speedID, at).Tx()
+
+	params := []interface{}{attemptID, speedID, userID, attempt, correct, at}
+	limit := `TRUE`
+	if limited {
+		limit = `(SELECT COUNT(*) FROM "SpeedAttempt" A WHERE A."speedId" = $2::uuid AND A."createdAt" > $7::timestamp) < $8`
+		params = append(params, windowStart.UTC().Format(time.RFC3339Nano), maxAttempts)
+	}
+	createAttempt := db.Prisma.ExecuteRaw(fmt.Sprintf(`
+		INSERT INTO "SpeedAttempt"
+			(id, "speedId", "userId", answer, correct, "createdAt")
+		SELECT
+			$1::uuid, $2::uuid, $3::uuid, $4, $5::boolean, $6::timestamp
+		WHERE
+			(SELECT S."completedAt" FROM "Speed" S WHERE S.id = $2::uuid) IS NULL AND
+			%s
+	`, limit), params...).Tx()
+
+	updateSpeed := db.Prisma.ExecuteRaw(`
+		UPDATE
+			"Speed"
+		SET
+			answer = $2,
+			"completedAt" = CASE WHEN $3::boolean THEN $4::timestamp ELSE "completedAt" END
+		WHERE
+			id = $1::uuid AND
+			EXISTS (SELECT 1 FROM "SpeedAttempt" A WHERE A.id = $5::uuid)
+	`, speedID, attempt, correct, at, attemptID).Tx()
+
+	if err := db.Prisma.Transaction(lockSpeed, createAttempt, updateSpeed).Exec(ctx); err != nil {
+		return false, err
+	}
+
+	return createAttempt.Result().Count > 0, nil
+}
@@ -0,0 +1,217 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// SpeedRankingLocation is the time zone the ranking is reported in, the tzdata is
+// embedded so it loads on hosts without a zoneinfo database.
+var SpeedRankingLocation = mustLoadLocation("Asia/Kuala_Lumpur")
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// rankedSpeed is a completed speed together with its rank, teams completing at the
+// same instant share a rank. The place breaks the tie so every team has its own, the
+// team that started the mission first goes first, so tied teams cannot all take the
+// last of the places that are awarded.
+type rankedSpeed struct {
+	rank  int
+	place int
+	speed postgresql.SpeedModel
+}
+
+func rankSpeeds(ctx context.Context, db *postgresql.PrismaClient, missionID string) ([]rankedSpeed, error) {
+	// fetch the completed speeds, earliest first
+	fetchedSpeeds, err := db.Speed.FindMany(
+		postgresql.Speed.MissionID.Equals(missionID),
+		postgresql.Speed.Not(postgresql.Speed.CompletedAt.IsNull()),
+	).OrderBy(
+		postgresql.Speed.CompletedAt.Order(postgresql.ASC),
+		postgresql.Speed.CreatedAt.Order(postgresql.ASC),
+		postgresql.Speed.ID.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranked []rankedSpeed
+	var previous time.Time
+	for i, speed := range fetchedSpeeds {
+		completedAt, _ := speed.CompletedAt()
+		rank := i + 1
+		if i > 0 && completedAt.Equal(previous) {
+			rank = ranked[i-1].rank
+		}
+		ranked = append(ranked, rankedSpeed{rank: rank, place: i + 1, speed: speed})
+		previous = completedAt
+	}
+
+	return ranked, nil
+}
+
+// GetSpeedRanking orders the teams that completed a mission by their completion time,
+// the duration is counted in seconds from the start of the mission.
+func GetSpeedRanking(ctx context.Context, db *postgresql.PrismaClient, missionID string) ([]*model.SpeedRank, error) {
+	mission, err := db.Mission.FindUnique(postgresql.Mission.ID.Equals(missionID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked, err := rankSpeeds(ctx, db, missionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse speeds to graphql type
+	var speedRanks []*model.SpeedRank
+	for _, r := range ranked {
+		completedAt, _ := r.speed.CompletedAt()
+		speedRanks = append(speedRanks, &model.SpeedRank{
+			Rank:        r.rank,
+			TeamID:      r.speed.TeamID,
+			CompletedAt: completedAt.In(SpeedRankingLocation),
+			Duration:    int(completedAt.Sub(mission.StartAt).Seconds()),
+		})
+	}
+
+	return speedRanks, nil
+}
+
+func GetManySpeedAwardRule(ctx context.Context, db *postgresql.PrismaClient, params ...postgresql.SpeedAwardRuleWhereParam) ([]*model.SpeedAwardRule, error) {
+	// fetch the rules
+	fetchedSpeedAwardRules, err := db.SpeedAwardRule.FindMany(params...).OrderBy(
+		postgresql.SpeedAwardRule.CreatedAt.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse rules to graphql type
+	speedAwardRules, err := model.MapToSpeedAwardRules(fetchedSpeedAwardRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return speedAwardRules, nil
+}
+
+// SetSpeedAwardRules replaces the award rules of a mission. A rule applies to the first
+// top finishers, or every finisher when top is not set, that completed before the given
+// time if any. Teams tied on time are placed by when they started the mission. A team
+// collects the points and powercards of every rule it matches.
+func SetSpeedAwardRules(ctx context.Context, db *postgresql.PrismaClient, param *model.SetSpeedAwardRulesInput) ([]*model.SpeedAwardRule, error) {
+	var txs []transaction.Param
+	txs = append(txs, db.SpeedAwardRule.FindMany(postgresql.SpeedAwardRule.MissionID.Equals(param.MissionID)).Delete().Tx())
+	for i, rule := range param.Rules {
+		if rule.Top != nil && *rule.Top < 1 {
+			return nil, fmt.Errorf("top of rule %d must be at least 1", i+1)
+		}
+		if rule.Points == 0 && rule.Powercard == nil {
+			return nil, fmt.Errorf("rule %d awards nothing", i+1)
+		}
+		txs = append(txs, db.SpeedAwardRule.CreateOne(
+			postgresql.SpeedAwardRule.ID.Set(gofakeit.UUID()),
+			postgresql.SpeedAwardRule.Mission.Link(postgresql.Mission.ID.Equals(param.MissionID)),
+			postgresql.SpeedAwardRule.Top.SetIfPresent(rule.Top),
+			postgresql.SpeedAwardRule.Before.SetIfPresent(rule.Before),
+			postgresql.SpeedAwardRule.Points.Set(rule.Points),
+			postgresql.SpeedAwardRule.Powercard.SetIfPresent((*postgresql.Powercard)(rule.Powercard)),
+		).Tx())
+	}
+
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return GetManySpeedAwardRule(ctx, db, postgresql.SpeedAwardRule.MissionID.Equals(param.MissionID))
+}
+
+// AwardSpeedRanking applies the award rules of a mission to its ranking. Teams that were
+// awarded before are skipped so it is safe to run again when late teams complete.
+func AwardSpeedRanking(ctx context.Context, db *postgresql.PrismaClient, missionID string) ([]*model.PointAward, error) {
+	rules, err := db.SpeedAwardRule.FindMany(postgresql.SpeedAwardRule.MissionID.Equals(missionID)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("mission %s has no award rules", missionID)
+	}
+
+	ranked, err := rankSpeeds(ctx, db, missionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pointAwards []*model.PointAward
+	for _, r := range ranked {
+		// skip teams that were awarded
+		_, err := db.PointAward.FindUnique(postgresql.PointAward.SourceReference(
+			postgresql.PointAward.Source.Equals(PointAwardSourceSpeed),
+			postgresql.PointAward.Reference.Equals(r.speed.ID),
+		)).Exec(ctx)
+		if err == nil {
+			continue
+		}
+		if err != postgresql.ErrNotFound {
+			return nil, err
+		}
+
+		// collect what the team gets from the rules it matches
+		completedAt, _ := r.speed.CompletedAt()
+		var points float64
+		var powercards []postgresql.Powercard
+		for _, rule := range rules {
+			if top, ok := rule.Top(); ok && r.place > top {
+				continue
+			}
+			if before, ok := rule.Before(); ok && !completedAt.Before(before) {
+				continue
+			}
+			points += rule.Points
+			if powercard, ok := rule.Powercard(); ok {
+				powercards = append(powercards, powercard)
+			}
+		}
+		if points == 0 && len(powercards) == 0 {
+			continue
+		}
+
+		reason := fmt.Sprintf("Finished speed in place %d", r.place)
+		// grant the powercards first, granting again is skipped when the award fails
+		for _, powercard := range powercards {
+			source := PointAwardSourceSpeed
+			_, err := GrantPowercard(ctx, db, &model.GrantPowercardInput{
+				TeamID:    r.speed.TeamID,
+				Powercard: model.Powercard(powercard),
+				Reason:    reason,
+				Source:    &source,
+				Reference: &r.speed.ID,
+			}, nil)
+			if err != nil && !errors.Is(err, ErrPowercardHeld) && !errors.Is(err, ErrPowercardGranted) {
+				return nil, err
+			}
+		}
+		pointAward, err := AwardPoints(ctx, db, r.speed.TeamID, points, PointAwardSourceSpeed, r.speed.ID, &reason)
+		if err != nil {
+			return nil, err
+		}
+		pointAwards = append(pointAwards, pointAward)
+	}
+
+	return pointAwards, nil
+}
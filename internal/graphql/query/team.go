@@ -2,6 +2,7 @@ package query
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/marcustut/thebox/internal/graphql/model"
@@ -67,12 +68,38 @@ func CreateTeam(ctx context.Context, db *postgresql.PrismaClient, param *model.N
 	return team, nil
 }
 
-func UpdateUniqueTeam(ctx context.Context, db *postgresql.PrismaClient, param postgresql.TeamEqualsUniqueWhereParam, updateParam *model.UpdateTeamInput) (*model.Team, error) {
+// UpdateUniqueTeam updates the details of a team. When a user is given it has to be a
+// member of the team and cannot change the points, the points of a team are only changed
+// by the crew and the change is recorded as an adjustment award.
+func UpdateUniqueTeam(ctx context.Context, db *postgresql.PrismaClient, param postgresql.TeamEqualsUniqueWhereParam, updateParam *model.UpdateTeamInput, userID *string) (*model.Team, error) {
+	fetchedTeam, err := db.Team.FindUnique(param).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID != nil {
+		user, err := db.User.FindUnique(postgresql.User.ID.Equals(*userID)).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if res, ok := user.TeamID(); !ok || res != fetchedTeam.ID {
+			return nil, fmt.Errorf("you are not a member of team %s", fetchedTeam.ID)
+		}
+		if updateParam.Points != nil {
+			return nil, fmt.Errorf("only the crew can change the points of a team")
+		}
+	}
+
+	if updateParam.Points != nil && *updateParam.Points != fetchedTeam.Points {
+		reason := "Points adjusted by the crew"
+		if _, err := AwardPoints(ctx, db, fetchedTeam.ID, *updateParam.Points-fetchedTeam.Points, PointAwardSourceAdjustment, gofakeit.UUID(), &reason); err != nil {
+			return nil, err
+		}
+	}
+
 	updatedTeam, err := db.Team.FindUnique(param).Update(
 		postgresql.Team.Name.SetIfPresent(updateParam.Name),
 		postgresql.Team.AvatarURL.SetIfPresent(updateParam.AvatarURL),
-		postgresql.Team.Points.SetIfPresent(updateParam.Points),
-		postgresql.Team.Powercard.SetIfPresent((*postgresql.Powercard)(updateParam.Powercard)),
 	).Exec(ctx)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,279 @@
+package query
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/marcustut/thebox/internal/teamform"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// teamFormationExcludedRoles are the users that are never placed in a team.
+var teamFormationExcludedRoles = []postgresql.Role{postgresql.RoleCREW, postgresql.RoleJUDGE, postgresql.RoleCLUSTERLEADER}
+
+// TeamFormationPlan is a formation of teams that is yet to be created, the checksum
+// identifies the plan so it can only be committed after it has been previewed.
+type TeamFormationPlan struct {
+	Checksum string
+	Teams    []PlannedTeam
+	Warnings []string
+}
+
+// PlannedTeam is a team of the plan, the members are fetched along with their profile
+// and roles.
+type PlannedTeam struct {
+	Name    string
+	Cluster *postgresql.ClusterModel
+	Leader  postgresql.UserModel
+	Members []postgresql.UserModel
+}
+
+// PlanTeamFormation forms balanced teams out of the users without a team, users that are
+// crew, judges or cluster leaders are left out. When onlyVerified is set, users whose
+// payment is not verified are left out too.
+func PlanTeamFormation(ctx context.Context, db *postgresql.PrismaClient, param *model.FormTeamsInput, onlyVerified bool) (*TeamFormationPlan, error) {
+	// fetch the users without a team
+	params := []postgresql.UserWhereParam{
+		postgresql.User.TeamID.IsNull(),
+		postgresql.User.Not(postgresql.User.UserRole.Some(postgresql.UserRole.Role.In(teamFormationExcludedRoles))),
+	}
+	if onlyVerified {
+		params = append(params, postgresql.User.Profile.Where(
+			postgresql.Profile.PaymentStatus.Equals(postgresql.PaymentStatusVERIFIED),
+		))
+	}
+	users, err := db.User.FindMany(params...).With(
+		postgresql.User.Profile.Fetch(),
+		postgresql.User.UserRole.Fetch(),
+	).OrderBy(
+		postgresql.User.ID.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	byID := make(map[string]postgresql.UserModel)
+	members := make([]teamform.Member, len(users))
+	for i, user := range users {
+		byID[user.ID] = user
+		profile := user.Profile()
+		member := teamform.Member{
+			ID:     user.ID,
+			Gender: string(profile.Gender),
+			Age:    teamform.Age(profile.Dob, now),
+			Rank:   teamform.StatusRank(""),
+		}
+		if satellite, ok := profile.Satellite(); ok {
+			member.Satellite = string(satellite)
+		}
+		if status, ok := profile.Status(); ok {
+			member.Rank = teamform.StatusRank(string(status))
+		}
+		members[i] = member
+	}
+
+	teams, err := teamform.Form(members, param.Size, int64(param.Seed))
+	if err != nil {
+		return nil, err
+	}
+
+	// the new teams are numbered after the existing ones
+	existingTeams, err := db.Team.FindMany().Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var clusters []postgresql.ClusterModel
+	clusterTeams := make(map[string]int)
+	if param.DistributeClusters {
+		clusters, err = db.Cluster.FindMany().OrderBy(postgresql.Cluster.Name.Order(postgresql.ASC)).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if len(clusters) == 0 {
+			return nil, fmt.Errorf("there are no clusters to distribute the teams across")
+		}
+		for _, team := range existingTeams {
+			if clusterID, ok := team.ClusterID(); ok {
+				clusterTeams[clusterID]++
+			}
+		}
+	}
+
+	plan := &TeamFormationPlan{}
+	checksum := sha256.New()
+	for i, team := range teams {
+		planned := PlannedTeam{
+			Name:   fmt.Sprintf("%s %d", param.NamePrefix, len(existingTeams)+i+1),
+			Leader: byID[team.Members[team.Leader].ID],
+		}
+
+		// a team goes to the cluster with the fewest teams
+		if len(clusters) > 0 {
+			cluster := &clusters[0]
+			for j := range clusters {
+				if clusterTeams[clusters[j].ID] < clusterTeams[cluster.ID] {
+					cluster = &clusters[j]
+				}
+			}
+			clusterTeams[cluster.ID]++
+			planned.Cluster = cluster
+		}
+
+		var ids []string
+		for _, member := range team.Members {
+			planned.Members = append(planned.Members, byID[member.ID])
+			ids = append(ids, member.ID)
+		}
+		if !team.Members[team.Leader].IsLeader() {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s has no leader of CGL or above", planned.Name))
+		}
+		plan.Teams = append(plan.Teams, planned)
+
+		clusterID := ""
+		if planned.Cluster != nil {
+			clusterID = planned.Cluster.ID
+		}
+		fmt.Fprintf(checksum, "%s|%s|%s|%s\n", planned.Name, clusterID, planned.Leader.ID, strings.Join(ids, ","))
+	}
+	plan.Checksum = hex.EncodeToString(checksum.Sum(nil))[:12]
+
+	return plan, nil
+}
+
+// CommitTeamFormation creates the teams of a plan in a single transaction, moves the
+// members into them and makes the leaders team leaders.
+func CommitTeamFormation(ctx context.Context, db *postgresql.PrismaClient, plan *TeamFormationPlan) ([]*model.Team, error) {
+	if len(plan.Teams) == 0 {
+		return nil, fmt.Errorf("there are no users to form teams with")
+	}
+
+	var txs []transaction.Param
+	var createdTeams []func() *postgresql.TeamModel
+	for _, planned := range plan.Teams {
+		var clusterID *string
+		if planned.Cluster != nil {
+			clusterID = &planned.Cluster.ID
+		}
+		teamID := gofakeit.UUID()
+		createTeam := db.Team.CreateOne(
+			postgresql.Team.ID.Set(teamID),
+			postgresql.Team.Name.Set(planned.Name),
+			postgresql.Team.Points.Set(0),
+			postgresql.Team.Cluster.Link(postgresql.Cluster.ID.EqualsIfPresent(clusterID)),
+		).Tx()
+		txs = append(txs, createTeam)
+		createdTeams = append(createdTeams, createTeam.Result)
+
+		for _, member := range planned.Members {
+			txs = append(txs, db.User.FindUnique(postgresql.User.ID.Equals(member.ID)).Update(
+				postgresql.User.Team.Link(postgresql.Team.ID.Equals(teamID)),
+				postgresql.User.UpdatedAt.Set(time.Now()),
+			).Tx())
+		}
+
+		if !userHasRole(planned.Leader, postgresql.RoleTEAMLEADER) {
+			txs = append(txs, db.UserRole.CreateOne(
+				postgresql.UserRole.ID.Set(gofakeit.UUID()),
+				postgresql.UserRole.Role.Set(postgresql.RoleTEAMLEADER),
+				postgresql.UserRole.User.Link(postgresql.User.ID.Equals(planned.Leader.ID)),
+			).Tx())
+		}
+	}
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	// parse teams to graphql type
+	var teams []*model.Team
+	for _, createdTeam := range createdTeams {
+		team, err := model.MapToTeam(createdTeam())
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+// FormTeams previews the formation of teams, the teams are only created when the
+// checksum of the preview is given and the plan has not changed since.
+func FormTeams(ctx context.Context, db *postgresql.PrismaClient, param *model.FormTeamsInput, onlyVerified bool) (*model.TeamFormation, error) {
+	plan, err := PlanTeamFormation(ctx, db, param, onlyVerified)
+	if err != nil {
+		return nil, err
+	}
+
+	formation := &model.TeamFormation{
+		Checksum: plan.Checksum,
+		Warnings: plan.Warnings,
+		Teams:    []*model.FormedTeam{},
+	}
+	if formation.Warnings == nil {
+		formation.Warnings = []string{}
+	}
+	for _, planned := range plan.Teams {
+		formedTeam, err := mapToFormedTeam(planned)
+		if err != nil {
+			return nil, err
+		}
+		formation.Teams = append(formation.Teams, formedTeam)
+	}
+	if param.Checksum == nil {
+		return formation, nil
+	}
+
+	if *param.Checksum != plan.Checksum {
+		return nil, fmt.Errorf("the teams have changed since the preview, preview them again")
+	}
+	teams, err := CommitTeamFormation(ctx, db, plan)
+	if err != nil {
+		return nil, err
+	}
+	for i, team := range teams {
+		formation.Teams[i].Team = team
+	}
+	formation.Committed = true
+
+	return formation, nil
+}
+
+func mapToFormedTeam(planned PlannedTeam) (*model.FormedTeam, error) {
+	leader, err := model.MapToUser(&planned.Leader)
+	if err != nil {
+		return nil, err
+	}
+	members, err := model.MapToUsers(planned.Members)
+	if err != nil {
+		return nil, err
+	}
+	formedTeam := &model.FormedTeam{
+		Name:    planned.Name,
+		Leader:  leader,
+		Members: members,
+	}
+	if planned.Cluster != nil {
+		formedTeam.Cluster, err = model.MapToCluster(planned.Cluster)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return formedTeam, nil
+}
+
+func userHasRole(user postgresql.UserModel, role postgresql.Role) bool {
+	for _, userRole := range user.UserRole() {
+		if userRole.Role == role {
+			return true
+		}
+	}
+	return false
+}
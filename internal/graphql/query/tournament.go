@@ -0,0 +1,521 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/marcustut/thebox/internal/battleground"
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// tournamentRoomTeams is how many teams play in the room of a tournament match.
+const tournamentRoomTeams = 2
+
+func GetUniqueTournament(ctx context.Context, db *postgresql.PrismaClient, param postgresql.TournamentEqualsUniqueWhereParam) (*model.Tournament, error) {
+	// fetch the tournament
+	fetchedTournament, err := db.Tournament.FindUnique(param).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse tournament to graphql type
+	tournament, err := model.MapToTournament(fetchedTournament)
+	if err != nil {
+		return nil, err
+	}
+
+	return tournament, nil
+}
+
+// GetTournamentBracket returns the matches of a tournament grouped by round, in the
+// order of their position in the bracket.
+func GetTournamentBracket(ctx context.Context, db *postgresql.PrismaClient, id string) (*model.TournamentBracket, error) {
+	tournament, err := GetUniqueTournament(ctx, db, postgresql.Tournament.ID.Equals(id))
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch the tournamentMatches
+	fetchedTournamentMatches, err := db.TournamentMatch.FindMany(
+		postgresql.TournamentMatch.TournamentID.Equals(id),
+	).OrderBy(
+		postgresql.TournamentMatch.Round.Order(postgresql.ASC),
+		postgresql.TournamentMatch.Position.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse tournamentMatches to graphql type
+	tournamentMatches, err := model.MapToTournamentMatches(fetchedTournamentMatches)
+	if err != nil {
+		return nil, err
+	}
+
+	rounds := []*model.TournamentRound{}
+	for _, match := range tournamentMatches {
+		if len(rounds) == 0 || rounds[len(rounds)-1].Round != match.Round {
+			rounds = append(rounds, &model.TournamentRound{Round: match.Round, Matches: []*model.TournamentMatch{}})
+		}
+		round := rounds[len(rounds)-1]
+		round.Matches = append(round.Matches, match)
+	}
+
+	return &model.TournamentBracket{Tournament: tournament, Rounds: rounds}, nil
+}
+
+// GetTeamsInOrder fetches the teams and keeps them in the order of the ids given.
+func GetTeamsInOrder(ctx context.Context, db *postgresql.PrismaClient, teamIDs []string) ([]*model.Team, error) {
+	// fetch the teams
+	fetchedTeams, err := db.Team.FindMany(postgresql.Team.ID.In(teamIDs)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse teams to graphql type
+	teams, err := model.MapToTeams(fetchedTeams)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*model.Team)
+	for _, team := range teams {
+		byID[team.ID] = team
+	}
+	result := []*model.Team{}
+	for _, teamID := range teamIDs {
+		if team, ok := byID[teamID]; ok {
+			result = append(result, team)
+		}
+	}
+	return result, nil
+}
+
+func CreateTournament(ctx context.Context, db *postgresql.PrismaClient, param *model.NewTournament) (*model.Tournament, error) {
+	if len(param.TeamIds) < 2 {
+		return nil, fmt.Errorf("a tournament needs at least 2 teams")
+	}
+	if err := validateBattlegroundRoomTeams(ctx, db, param.TeamIds, len(param.TeamIds)); err != nil {
+		return nil, err
+	}
+	if param.RoundCount != nil {
+		if param.Format != model.TournamentFormatSwiss {
+			return nil, fmt.Errorf("the number of rounds is only set for swiss, single elimination plays until a team is left")
+		}
+		if *param.RoundCount < 1 {
+			return nil, fmt.Errorf("a tournament needs at least a round")
+		}
+	}
+	if param.RoomRoundCount != nil && (*param.RoomRoundCount < 0 || *param.RoomRoundCount > battleground.MaxRounds) {
+		return nil, fmt.Errorf("number of rounds must be between 0 and %d", battleground.MaxRounds)
+	}
+
+	createdTournament, err := db.Tournament.CreateOne(
+		postgresql.Tournament.ID.Set(gofakeit.UUID()),
+		postgresql.Tournament.Name.Set(param.Name),
+		postgresql.Tournament.Format.Set(postgresql.TournamentFormat(param.Format)),
+		postgresql.Tournament.UpdatedAt.Set(time.Now()),
+		postgresql.Tournament.TeamIDs.Set(param.TeamIds),
+		postgresql.Tournament.RoundCount.SetIfPresent(param.RoundCount),
+		postgresql.Tournament.RoomRoundCount.SetIfPresent(param.RoomRoundCount),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse tournament to graphql type
+	tournament, err := model.MapToTournament(createdTournament)
+	if err != nil {
+		return nil, err
+	}
+
+	return tournament, nil
+}
+
+// StartTournament seeds the teams of a tournament by the leaderboard, the team with the
+// most points being the first seed, and creates the rooms of the first round. Teams with
+// the same points keep the order they were listed in.
+func StartTournament(ctx context.Context, db *postgresql.PrismaClient, id string) (*model.Tournament, error) {
+	tournament, err := db.Tournament.FindUnique(postgresql.Tournament.ID.Equals(id)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tournament.Status != postgresql.RoomStatusPREPARING {
+		return nil, fmt.Errorf("tournament %s is %s", tournament.Name, tournament.Status)
+	}
+
+	seeded, err := seedTournamentTeams(ctx, db, tournament.TeamIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(seeded) < 2 {
+		return nil, fmt.Errorf("a tournament needs at least 2 teams")
+	}
+
+	roundCount := battleground.EliminationRounds(len(seeded))
+	if res, ok := tournament.RoundCount(); ok && battleground.Format(tournament.Format) == battleground.FormatSwiss {
+		roundCount = res
+	}
+	tournament.TeamIDs = seeded
+	pairs, err := getTournamentFirstPairs(tournament)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the first start goes through
+	now := time.Now()
+	result, err := db.Tournament.FindMany(
+		postgresql.Tournament.ID.Equals(id),
+		postgresql.Tournament.Status.Equals(postgresql.RoomStatusPREPARING),
+	).Update(
+		postgresql.Tournament.Status.Set(postgresql.RoomStatusONGOING),
+		postgresql.Tournament.TeamIDs.Set(seeded),
+		postgresql.Tournament.RoundCount.Set(roundCount),
+		postgresql.Tournament.StartedAt.Set(now),
+		postgresql.Tournament.UpdatedAt.Set(now),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Count == 0 {
+		return nil, fmt.Errorf("tournament %s was started by someone else", tournament.Name)
+	}
+
+	// a first round that cannot be created is created again by advancing the tournament
+	if err := createTournamentRound(ctx, db, tournament, 1, pairs); err != nil {
+		return nil, fmt.Errorf("tournament %s has started but its first round was not created, advance it to retry: %v", tournament.Name, err)
+	}
+
+	return GetUniqueTournament(ctx, db, postgresql.Tournament.ID.Equals(id))
+}
+
+// AdvanceTournament lets the crew retry advancing a tournament when starting it or ending
+// one of its rooms could not finish. A missing first round is created, the matches whose
+// room has ended get a winner and a round that is over is followed by the next one.
+func AdvanceTournament(ctx context.Context, db *postgresql.PrismaClient, id string) (*model.Tournament, error) {
+	tournament, err := db.Tournament.FindUnique(postgresql.Tournament.ID.Equals(id)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tournament.Status != postgresql.RoomStatusONGOING {
+		return nil, fmt.Errorf("tournament %s is %s", tournament.Name, tournament.Status)
+	}
+
+	matches, err := db.TournamentMatch.FindMany(
+		postgresql.TournamentMatch.TournamentID.Equals(id),
+	).OrderBy(
+		postgresql.TournamentMatch.Round.Order(postgresql.ASC),
+		postgresql.TournamentMatch.Position.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		pairs, err := getTournamentFirstPairs(tournament)
+		if err != nil {
+			return nil, err
+		}
+		if err := createTournamentRound(ctx, db, tournament, 1, pairs); err != nil {
+			return nil, err
+		}
+		return GetUniqueTournament(ctx, db, postgresql.Tournament.ID.Equals(id))
+	}
+
+	for _, match := range matches {
+		if _, ok := match.WinnerTeamID(); ok {
+			continue
+		}
+		code, ok := match.Code()
+		if !ok {
+			continue
+		}
+		room, err := db.BattlegroundRoom.FindUnique(postgresql.BattlegroundRoom.Code.Equals(code)).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if room.Status != postgresql.RoomStatusENDED {
+			continue
+		}
+		if err := advanceTournament(ctx, db, code); err != nil {
+			return nil, err
+		}
+	}
+
+	// the matches of the last round may all have a winner without the next round
+	tournament, err = db.Tournament.FindUnique(postgresql.Tournament.ID.Equals(id)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if tournament.Status == postgresql.RoomStatusONGOING {
+		if err := advanceTournamentRound(ctx, db, tournament, matches[len(matches)-1].Round); err != nil {
+			return nil, err
+		}
+	}
+
+	return GetUniqueTournament(ctx, db, postgresql.Tournament.ID.Equals(id))
+}
+
+// seedTournamentTeams orders the teams by their points, the most points first. Teams
+// with the same points keep the order of the ids given, so a seeding can be reproduced.
+func seedTournamentTeams(ctx context.Context, db *postgresql.PrismaClient, teamIDs []string) ([]string, error) {
+	teams, err := db.Team.FindMany(postgresql.Team.ID.In(teamIDs)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	points := make(map[string]float64)
+	for _, team := range teams {
+		points[team.ID] = team.Points
+	}
+
+	var seeded []string
+	for _, teamID := range teamIDs {
+		if _, ok := points[teamID]; ok {
+			seeded = append(seeded, teamID)
+		}
+	}
+	sort.SliceStable(seeded, func(i, j int) bool { return points[seeded[i]] > points[seeded[j]] })
+	return seeded, nil
+}
+
+// getTournamentFirstPairs pairs up the seeded teams of a tournament for its first round.
+func getTournamentFirstPairs(tournament *postgresql.TournamentModel) ([]battleground.Pair, error) {
+	switch battleground.Format(tournament.Format) {
+	case battleground.FormatSingleElimination:
+		return battleground.SeedElimination(tournament.TeamIDs)
+	case battleground.FormatSwiss:
+		return battleground.PairSwiss(tournament.TeamIDs, nil, nil, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown format %s", tournament.Format)
+	}
+}
+
+// advanceTournament decides the winner of the tournament match played in a room once
+// the room has ended, and pairs up the next round as soon as its round is over. Rooms
+// outside of a tournament are left alone.
+func advanceTournament(ctx context.Context, db *postgresql.PrismaClient, code string) error {
+	match, err := db.TournamentMatch.FindUnique(postgresql.TournamentMatch.Code.Equals(code)).Exec(ctx)
+	if err == postgresql.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	tournament, err := db.Tournament.FindUnique(postgresql.Tournament.ID.Equals(match.TournamentID)).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if tournament.Status != postgresql.RoomStatusONGOING {
+		return nil
+	}
+
+	winner, err := getTournamentMatchWinner(ctx, db, tournament, match)
+	if err != nil {
+		return err
+	}
+	if _, err := db.TournamentMatch.FindMany(
+		postgresql.TournamentMatch.ID.Equals(match.ID),
+		postgresql.TournamentMatch.WinnerTeamID.IsNull(),
+	).Update(
+		postgresql.TournamentMatch.WinnerTeamID.Set(winner),
+		postgresql.TournamentMatch.UpdatedAt.Set(time.Now()),
+	).Exec(ctx); err != nil {
+		return err
+	}
+
+	return advanceTournamentRound(ctx, db, tournament, match.Round)
+}
+
+// advanceTournamentRound starts the next round once every match of a round has a winner,
+// or ends the tournament after its last round.
+func advanceTournamentRound(ctx context.Context, db *postgresql.PrismaClient, tournament *postgresql.TournamentModel, round int) error {
+	matches, err := db.TournamentMatch.FindMany(
+		postgresql.TournamentMatch.TournamentID.Equals(tournament.ID),
+		postgresql.TournamentMatch.Round.Lte(round+1),
+	).OrderBy(
+		postgresql.TournamentMatch.Round.Order(postgresql.ASC),
+		postgresql.TournamentMatch.Position.Order(postgresql.ASC),
+	).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	var winners []string
+	scores := make(map[string]int)
+	played := make(map[string]map[string]bool)
+	byes := make(map[string]bool)
+	for _, match := range matches {
+		if match.Round > round {
+			// the next round is already there
+			return nil
+		}
+		winner, ok := match.WinnerTeamID()
+		if !ok {
+			if match.Round == round {
+				return nil
+			}
+			continue
+		}
+		if match.Round == round {
+			winners = append(winners, winner)
+		}
+		scores[winner]++
+		if len(match.TeamIDs) == 1 {
+			byes[winner] = true
+		}
+		for _, teamID := range match.TeamIDs {
+			if played[teamID] == nil {
+				played[teamID] = make(map[string]bool)
+			}
+			for _, other := range match.TeamIDs {
+				played[teamID][other] = teamID != other
+			}
+		}
+	}
+
+	roundCount, _ := tournament.RoundCount()
+	switch battleground.Format(tournament.Format) {
+	case battleground.FormatSingleElimination:
+		if len(winners) == 1 {
+			return endTournament(ctx, db, tournament, winners[0])
+		}
+		return createTournamentRound(ctx, db, tournament, round+1, battleground.AdvanceElimination(winners))
+	case battleground.FormatSwiss:
+		if round >= roundCount {
+			return endTournament(ctx, db, tournament, battleground.RankSwiss(tournament.TeamIDs, scores)[0])
+		}
+		return createTournamentRound(ctx, db, tournament, round+1, battleground.PairSwiss(tournament.TeamIDs, scores, played, byes))
+	default:
+		return fmt.Errorf("unknown format %s", tournament.Format)
+	}
+}
+
+// createTournamentRound creates the matches of a round along with a room for every match
+// that is not a bye, all in one transaction. The matches of a round are unique, so a
+// round cannot be created twice.
+func createTournamentRound(ctx context.Context, db *postgresql.PrismaClient, tournament *postgresql.TournamentModel, round int, pairs []battleground.Pair) error {
+	var rooms int
+	for _, pair := range pairs {
+		if len(pair) > 1 {
+			rooms++
+		}
+	}
+
+	_, err := createWithBattlegroundRoomCodes(rooms, func(codes []string) error {
+		now := time.Now()
+		var txs []transaction.Param
+		for i, pair := range pairs {
+			// a bye goes through right away
+			var winner, code *string
+			if len(pair) == 1 {
+				winner = &pair[0]
+			} else {
+				code = &codes[0]
+				codes = codes[1:]
+				txs = append(txs, db.BattlegroundRoom.CreateOne(
+					postgresql.BattlegroundRoom.Code.Set(*code),
+					postgresql.BattlegroundRoom.UpdatedAt.Set(now),
+					postgresql.BattlegroundRoom.TeamIDs.Set(pair),
+					postgresql.BattlegroundRoom.ReadyTeamIDs.Set([]string{}),
+					postgresql.BattlegroundRoom.MinTeams.Set(tournamentRoomTeams),
+					postgresql.BattlegroundRoom.MaxTeams.Set(tournamentRoomTeams),
+					postgresql.BattlegroundRoom.Seed.Set(gofakeit.Number(1, math.MaxInt32)),
+					postgresql.BattlegroundRoom.RoundCount.SetIfPresent(tournamentRoomRoundCount(tournament)),
+					postgresql.BattlegroundRoom.SelectionTimeout.Set(BattlegroundSelectionTimeout),
+				).Tx())
+			}
+			txs = append(txs, db.TournamentMatch.CreateOne(
+				postgresql.TournamentMatch.ID.Set(gofakeit.UUID()),
+				postgresql.TournamentMatch.Round.Set(round),
+				postgresql.TournamentMatch.Position.Set(i+1),
+				postgresql.TournamentMatch.UpdatedAt.Set(now),
+				postgresql.TournamentMatch.Tournament.Link(postgresql.Tournament.ID.Equals(tournament.ID)),
+				postgresql.TournamentMatch.TeamIDs.Set(pair),
+				postgresql.TournamentMatch.WinnerTeamID.SetIfPresent(winner),
+				postgresql.TournamentMatch.Code.SetIfPresent(code),
+			).Tx())
+		}
+		err := db.Prisma.Transaction(txs...).Exec(ctx)
+		if !isUniqueConstraintError(err) {
+			return err
+		}
+
+		// the round was created at the same time, otherwise a code was taken
+		created, findErr := db.TournamentMatch.FindFirst(
+			postgresql.TournamentMatch.TournamentID.Equals(tournament.ID),
+			postgresql.TournamentMatch.Round.Equals(round),
+		).Exec(ctx)
+		if findErr == nil && created != nil {
+			return nil
+		}
+		return err
+	})
+	return err
+}
+
+// tournamentRoomRoundCount is the number of rounds played in every room of a tournament,
+// nil to play a full cycle.
+func tournamentRoomRoundCount(tournament *postgresql.TournamentModel) *int {
+	if res, ok := tournament.RoomRoundCount(); ok {
+		return &res
+	}
+	return nil
+}
+
+func endTournament(ctx context.Context, db *postgresql.PrismaClient, tournament *postgresql.TournamentModel, winner string) error {
+	now := time.Now()
+	_, err := db.Tournament.FindMany(
+		postgresql.Tournament.ID.Equals(tournament.ID),
+		postgresql.Tournament.Status.Equals(postgresql.RoomStatusONGOING),
+	).Update(
+		postgresql.Tournament.Status.Set(postgresql.RoomStatusENDED),
+		postgresql.Tournament.WinnerTeamID.Set(winner),
+		postgresql.Tournament.EndedAt.Set(now),
+		postgresql.Tournament.UpdatedAt.Set(now),
+	).Exec(ctx)
+	return err
+}
+
+// getTournamentMatchWinner picks the team that won the most rounds in the room of a
+// match, then the team that made the most points in it, then the better seed. A room
+// that was ended without being played goes to the better seed.
+func getTournamentMatchWinner(ctx context.Context, db *postgresql.PrismaClient, tournament *postgresql.TournamentModel, match *postgresql.TournamentMatchModel) (string, error) {
+	code, _ := match.Code()
+	rounds, err := GetManyBattlegroundRound(ctx, db, postgresql.BattlegroundRound.Code.Equals(code))
+	if err != nil {
+		return "", err
+	}
+	standings, err := getBattlegroundStandings(ctx, db, code, rounds)
+	if err != nil {
+		return "", err
+	}
+	if len(standings) == 0 {
+		return "", fmt.Errorf("room %s has no teams", code)
+	}
+
+	seeds := make(map[string]int)
+	for i, teamID := range tournament.TeamIDs {
+		seeds[teamID] = i
+	}
+	best := standings[0]
+	for _, standing := range standings[1:] {
+		switch {
+		case standing.Wins != best.Wins:
+			if standing.Wins > best.Wins {
+				best = standing
+			}
+		case standing.Change != best.Change:
+			if standing.Change > best.Change {
+				best = standing
+			}
+		case seeds[standing.TeamID] < seeds[best.TeamID]:
+			best = standing
+		}
+	}
+	return best.TeamID, nil
+}
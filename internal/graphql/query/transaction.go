@@ -2,7 +2,6 @@ package query
 
 import (
 	"context"
-	"log"
 	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
@@ -11,91 +10,83 @@ import (
 	"github.com/prisma/prisma-client-go/runtime/transaction"
 )
 
-// This is unsafe because currently Go's prisma client does not support nested writes
-// in other words, dependent writes cannot be performed within a transaction
-// hence 2 transaction is used to create user but this is unsafe as atomicity is not
-// guaranteed, as one transaction might be aborted and the other still remains intact.
-func CreateUserWithTxUnsafe(ctx context.Context, db *postgresql.PrismaClient, param *model.NewUser) (*model.User, error) {
+// CreateUser creates a user along with the profile, address and roles. Go's
+// prisma client does not support nested writes, so the ids are generated up front to
+// link the dependent writes together within a single transaction.
+func CreateUser(ctx context.Context, db *postgresql.PrismaClient, param *model.NewUser) (*model.User, error) {
+	userID, txs := createUserTxs(db, param)
+	if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	return GetUniqueUser(ctx, db, postgresql.User.ID.Equals(userID))
+}
+
+// createUserTxs returns the writes that create a user, so several users can be created
+// in the same transaction.
+func createUserTxs(db *postgresql.PrismaClient, param *model.NewUser) (string, []transaction.Param) {
 	status := (*postgresql.PastoralStatus)(param.Profile.Status)
 	satellite := (*postgresql.Satellite)(param.Profile.Satellite)
 
-	var t1 []transaction.Param
-
-	dbAddress := db.Address.CreateOne(
-		postgresql.Address.ID.Set(gofakeit.UUID()),
-		postgresql.Address.City.Set(param.Profile.Address.City),
-		postgresql.Address.Line1.Set(param.Profile.Address.Line1),
-		postgresql.Address.State.Set(param.Profile.Address.State),
-		postgresql.Address.Country.Set(param.Profile.Address.Country),
-		postgresql.Address.PostalCode.Set(param.Profile.Address.PostalCode),
-		postgresql.Address.Line2.SetIfPresent(param.Profile.Address.Line2),
-	).Tx()
+	var txs []transaction.Param
 
-	dbProfile := db.Profile.CreateOne(
-		postgresql.Profile.ID.Set(gofakeit.UUID()),
-		postgresql.Profile.Gender.Set(postgresql.Gender(param.Profile.Gender)),
-		postgresql.Profile.NameEng.Set(param.Profile.NameEng),
-		postgresql.Profile.Contact.Set(param.Profile.Contact),
-		postgresql.Profile.Dob.Set(param.Profile.Dob),
-		postgresql.Profile.UpdatedAt.Set(time.Now()),
+	profileParams := []postgresql.ProfileSetParam{
 		postgresql.Profile.NameChi.SetIfPresent(param.Profile.NameChi),
 		postgresql.Profile.TngReceiptURL.SetIfPresent(param.Profile.TngReceiptURL),
 		postgresql.Profile.AvatarURL.SetIfPresent(param.Profile.AvatarURL),
 		postgresql.Profile.Satellite.SetIfPresent(satellite),
 		postgresql.Profile.Status.SetIfPresent(status),
 		postgresql.Profile.InvitedBy.SetIfPresent(param.Profile.InvitedBy),
-	).Tx()
-
-	t1 = append(t1, dbAddress, dbProfile)
-
-	if err := db.Prisma.Transaction(t1...).Exec(ctx); err != nil {
-		return nil, err
 	}
 
-	var t2 []transaction.Param
-
-	updatedProfile := db.Profile.FindUnique(
-		postgresql.Profile.ID.Equals(dbProfile.Result().ID),
-	).Update(
-		postgresql.Profile.AddressID.Set(dbAddress.Result().ID),
-	).Tx()
+	if param.Profile.Address != nil {
+		addressID := gofakeit.UUID()
+		txs = append(txs, db.Address.CreateOne(
+			postgresql.Address.ID.Set(addressID),
+			postgresql.Address.City.Set(param.Profile.Address.City),
+			postgresql.Address.Line1.Set(param.Profile.Address.Line1),
+			postgresql.Address.State.Set(param.Profile.Address.State),
+			postgresql.Address.Country.Set(param.Profile.Address.Country),
+			postgresql.Address.PostalCode.Set(param.Profile.Address.PostalCode),
+			postgresql.Address.Line2.SetIfPresent(param.Profile.Address.Line2),
+		).Tx())
+		profileParams = append(profileParams, postgresql.Profile.Address.Link(postgresql.Address.ID.Equals(addressID)))
+	}
 
-	log.Println(dbProfile.Result().ID)
+	profileID := gofakeit.UUID()
+	txs = append(txs, db.Profile.CreateOne(
+		postgresql.Profile.ID.Set(profileID),
+		postgresql.Profile.Gender.Set(postgresql.Gender(param.Profile.Gender)),
+		postgresql.Profile.NameEng.Set(param.Profile.NameEng),
+		postgresql.Profile.Contact.Set(param.Profile.Contact),
+		postgresql.Profile.Dob.Set(param.Profile.Dob),
+		postgresql.Profile.UpdatedAt.Set(time.Now()),
+		profileParams...,
+	).Tx())
 
-	var userId string
+	var userID string
 	if param.ID != nil {
-		userId = *param.ID
+		userID = *param.ID
 	} else {
-		userId = gofakeit.UUID()
+		userID = gofakeit.UUID()
 	}
 
-	dbUser := db.User.CreateOne(
-		postgresql.User.ID.Set(userId),
+	txs = append(txs, db.User.CreateOne(
+		postgresql.User.ID.Set(userID),
 		postgresql.User.Username.Set(param.Username),
 		postgresql.User.UpdatedAt.Set(time.Now()),
 		postgresql.User.Email.Set(param.Email),
-		postgresql.User.Profile.Link(postgresql.Profile.ID.Equals(dbProfile.Result().ID)),
+		postgresql.User.Profile.Link(postgresql.Profile.ID.Equals(profileID)),
 		postgresql.User.Team.Link(postgresql.Team.ID.EqualsIfPresent(param.TeamID)),
-	).Tx()
-
-	t2 = append(t2, updatedProfile, dbUser)
-
-	if err := db.Prisma.Transaction(t2...).Exec(ctx); err != nil {
-		return nil, err
-	}
+	).Tx())
 
 	for _, role := range param.Roles {
-		db.UserRole.CreateOne(
+		txs = append(txs, db.UserRole.CreateOne(
 			postgresql.UserRole.ID.Set(gofakeit.UUID()),
 			postgresql.UserRole.Role.Set(postgresql.Role(role)),
-			postgresql.UserRole.User.Link(postgresql.User.ID.Equals(dbUser.Result().ID)),
-		).Exec(ctx)
-	}
-
-	user, err := model.MapToUser(dbUser.Result())
-	if err != nil {
-		return nil, err
+			postgresql.UserRole.User.Link(postgresql.User.ID.Equals(userID)),
+		).Tx())
 	}
 
-	return user, nil
+	return userID, txs
 }
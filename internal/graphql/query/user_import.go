@@ -0,0 +1,395 @@
+package query
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/marcustut/thebox/internal/graphql/model"
+	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/prisma/prisma-client-go/runtime/transaction"
+)
+
+// UserImportColumns are the columns a file of registrations can have, they are named
+// like the columns of the user export so an export can be imported again. The columns
+// that only the export has, such as teamName, are ignored.
+var UserImportColumns = []string{
+	"id", "username", "email", "nameEng", "nameChi", "gender", "dob", "contact",
+	"status", "satellite", "invitedBy", "teamId", "roles",
+	"line1", "line2", "city", "state", "postalCode", "country",
+}
+
+// userImportRequiredColumns must be in the header and have a value in every row.
+var userImportRequiredColumns = []string{"username", "email", "nameEng", "gender", "dob", "contact"}
+
+// userImportRoles are the roles an import can give, the crew and the judges are only
+// given their roles by hand.
+var userImportRoles = []model.Role{model.RolePlayer, model.RoleTeamleader, model.RoleClusterleader}
+
+// userImportAddressColumns are all given or all left empty, line2 is optional either way.
+var userImportAddressColumns = []string{"line1", "city", "state", "postalCode", "country"}
+
+// userImportContact is a phone number with an optional country code, spaces and dashes
+// are removed before it is matched.
+var userImportContact = regexp.MustCompile(`^\+?[0-9]{8,15}$`)
+
+var userImportUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// userImportRow is a parsed row of an import, row is its line in the file.
+type userImportRow struct {
+	row  int
+	user *model.NewUser
+}
+
+// ImportUsers validates every row of a CSV of registrations and creates the users along
+// with their profiles, addresses and roles in a single transaction. Nothing is created
+// when any row is invalid or when it is a dry run, the errors are reported by row where
+// the first row after the header is row 2. The users are yet to pay, so they cannot be
+// placed in a team when a verified payment is required.
+func ImportUsers(ctx context.Context, db *postgresql.PrismaClient, r io.Reader, dryRun bool, requireVerifiedPayment bool) (*model.UserImportResult, error) {
+	rows, total, errs, err := parseUserImport(r)
+	if err != nil {
+		return nil, err
+	}
+	if requireVerifiedPayment {
+		for _, row := range rows {
+			if row.user.TeamID != nil {
+				errs = append(errs, userImportError(row.row, "teamId", fmt.Sprintf("payment must be %s to join a team", model.PaymentStatusVerified)))
+			}
+		}
+	}
+	conflicts, err := getUserImportConflicts(ctx, db, rows)
+	if err != nil {
+		return nil, err
+	}
+	errs = append(errs, conflicts...)
+
+	result := &model.UserImportResult{
+		DryRun: dryRun,
+		Rows:   total,
+		Errors: errs,
+	}
+	if dryRun || len(errs) > 0 {
+		return result, nil
+	}
+
+	var txs []transaction.Param
+	for _, row := range rows {
+		_, userTxs := createUserTxs(db, row.user)
+		txs = append(txs, userTxs...)
+	}
+	if len(txs) > 0 {
+		if err := db.Prisma.Transaction(txs...).Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+	result.Created = len(rows)
+
+	return result, nil
+}
+
+// parseUserImport parses the rows of the file into users and counts the rows, a row with
+// any error is left out of the users. An error is only returned when the file itself
+// cannot be read.
+func parseUserImport(r io.Reader) ([]userImportRow, int, []*model.ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, 0, nil, fmt.Errorf("the file is empty")
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	// map the columns by name, the file may start with the byte order mark of the export
+	columns := make(map[string]int)
+	known := make(map[string]bool)
+	for _, column := range UserExport.Columns {
+		known[column] = true
+	}
+	for _, column := range UserImportColumns {
+		known[column] = true
+	}
+	var errs []*model.ImportRowError
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		if !known[column] {
+			errs = append(errs, userImportError(1, column, "unknown column"))
+			continue
+		}
+		if _, ok := columns[column]; ok {
+			errs = append(errs, userImportError(1, column, "duplicate column"))
+			continue
+		}
+		columns[column] = i
+	}
+	for _, column := range userImportRequiredColumns {
+		if _, ok := columns[column]; !ok {
+			errs = append(errs, userImportError(1, column, "missing column"))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, 0, errs, nil
+	}
+
+	var rows []userImportRow
+	total := 0
+	seen := map[string]map[string]int{"id": {}, "username": {}, "email": {}}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		total++
+		if len(record) != len(header) {
+			errs = append(errs, userImportError(line, "", fmt.Sprintf("expected %d fields but got %d", len(header), len(record))))
+			continue
+		}
+
+		value := func(column string) string {
+			if i, ok := columns[column]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		user, rowErrs := parseUserImportRow(line, value)
+
+		// the same user must not be in the file twice
+		for _, column := range []string{"id", "username", "email"} {
+			values := seen[column]
+			v := value(column)
+			if v == "" {
+				continue
+			}
+			if first, ok := values[v]; ok {
+				rowErrs = append(rowErrs, userImportError(line, column, fmt.Sprintf("%s is already in row %d", value(column), first)))
+				continue
+			}
+			values[v] = line
+		}
+
+		if len(rowErrs) > 0 {
+			errs = append(errs, rowErrs...)
+			continue
+		}
+		rows = append(rows, userImportRow{row: line, user: user})
+	}
+
+	return rows, total, errs, nil
+}
+
+// parseUserImportRow validates the values of a row and converts them into the input of
+// createUser.
+func parseUserImportRow(line int, value func(column string) string) (*model.NewUser, []*model.ImportRowError) {
+	var errs []*model.ImportRowError
+	invalid := func(column string, format string, args ...interface{}) {
+		errs = append(errs, userImportError(line, column, fmt.Sprintf(format, args...)))
+	}
+	optional := func(column string) *string {
+		if v := value(column); v != "" {
+			return &v
+		}
+		return nil
+	}
+
+	for _, column := range userImportRequiredColumns {
+		if value(column) == "" {
+			invalid(column, "%s is required", column)
+		}
+	}
+
+	user := &model.NewUser{
+		ID:       optional("id"),
+		Username: value("username"),
+		Email:    value("email"),
+		TeamID:   optional("teamId"),
+		Profile: &model.NewProfile{
+			NameEng:   value("nameEng"),
+			NameChi:   optional("nameChi"),
+			InvitedBy: optional("invitedBy"),
+		},
+	}
+
+	if user.ID != nil && !userImportUUID.MatchString(*user.ID) {
+		invalid("id", "%s is not a valid id", *user.ID)
+	}
+	if user.TeamID != nil && !userImportUUID.MatchString(*user.TeamID) {
+		invalid("teamId", "%s is not a valid id", *user.TeamID)
+	}
+	if user.Email != "" {
+		if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+			invalid("email", "%s is not a valid email", user.Email)
+		}
+	}
+
+	if contact := value("contact"); contact != "" {
+		user.Profile.Contact = strings.NewReplacer(" ", "", "-", "").Replace(contact)
+		if !userImportContact.MatchString(user.Profile.Contact) {
+			invalid("contact", "%s is not a valid contact number", contact)
+		}
+	}
+
+	if dob := value("dob"); dob != "" {
+		t, err := time.Parse("2006-01-02", dob)
+		if err != nil {
+			invalid("dob", "%s is not a date such as 2000-12-31", dob)
+		} else if t.After(time.Now()) {
+			invalid("dob", "%s is in the future", dob)
+		}
+		user.Profile.Dob = t
+	}
+
+	if gender := value("gender"); gender != "" {
+		user.Profile.Gender = model.Gender(strings.ToUpper(gender))
+		if !user.Profile.Gender.IsValid() {
+			invalid("gender", "%s is not one of %s", gender, userImportEnumValues(model.AllGender))
+		}
+	}
+	if status := value("status"); status != "" {
+		s := model.PastoralStatus(strings.ToUpper(status))
+		if !s.IsValid() {
+			invalid("status", "%s is not one of %s", status, userImportEnumValues(model.AllPastoralStatus))
+		}
+		user.Profile.Status = &s
+	}
+	if satellite := value("satellite"); satellite != "" {
+		s := model.Satellite(strings.ToUpper(satellite))
+		if !s.IsValid() {
+			invalid("satellite", "%s is not one of %s", satellite, userImportEnumValues(model.AllSatellite))
+		}
+		user.Profile.Satellite = &s
+	}
+
+	// the roles are separated like the lists of the exports, a user is a player by default
+	user.Roles = []model.Role{model.RolePlayer}
+	if roles := value("roles"); roles != "" {
+		user.Roles = nil
+		for _, role := range strings.Split(roles, ";") {
+			r := model.Role(strings.ToUpper(strings.TrimSpace(role)))
+			if !isUserImportRole(r) {
+				invalid("roles", "%s is not one of %s", role, userImportEnumValues(userImportRoles))
+				continue
+			}
+			user.Roles = append(user.Roles, r)
+		}
+	}
+
+	var missing []string
+	for _, column := range userImportAddressColumns {
+		if value(column) == "" {
+			missing = append(missing, column)
+		}
+	}
+	switch {
+	case len(missing) == 0:
+		user.Profile.Address = &model.NewAddress{
+			Line1:      value("line1"),
+			Line2:      optional("line2"),
+			City:       value("city"),
+			State:      value("state"),
+			PostalCode: value("postalCode"),
+			Country:    value("country"),
+		}
+	case len(missing) < len(userImportAddressColumns) || value("line2") != "":
+		invalid(missing[0], "the address is missing %s", strings.Join(missing, ", "))
+	}
+
+	return user, errs
+}
+
+// getUserImportConflicts finds the users of an import that already exist and the teams
+// they are placed in that do not.
+func getUserImportConflicts(ctx context.Context, db *postgresql.PrismaClient, rows []userImportRow) ([]*model.ImportRowError, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	var ids, usernames, emails, teamIDs []string
+	for _, row := range rows {
+		if row.user.ID != nil {
+			ids = append(ids, *row.user.ID)
+		}
+		if row.user.TeamID != nil {
+			teamIDs = append(teamIDs, *row.user.TeamID)
+		}
+		usernames = append(usernames, row.user.Username)
+		emails = append(emails, row.user.Email)
+	}
+
+	// fetch the existing users
+	taken := map[string]map[string]bool{"id": {}, "username": {}, "email": {}}
+	users, err := db.User.FindMany(postgresql.User.Or(
+		postgresql.User.ID.In(ids),
+		postgresql.User.Username.In(usernames),
+		postgresql.User.Email.In(emails),
+	)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		taken["id"][user.ID] = true
+		taken["username"][user.Username] = true
+		taken["email"][user.Email] = true
+	}
+
+	// fetch the teams
+	teams, err := db.Team.FindMany(postgresql.Team.ID.In(teamIDs)).Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existingTeams := make(map[string]bool)
+	for _, team := range teams {
+		existingTeams[team.ID] = true
+	}
+
+	var errs []*model.ImportRowError
+	for _, row := range rows {
+		if row.user.ID != nil && taken["id"][*row.user.ID] {
+			errs = append(errs, userImportError(row.row, "id", fmt.Sprintf("a user with id %s already exists", *row.user.ID)))
+		}
+		if taken["username"][row.user.Username] {
+			errs = append(errs, userImportError(row.row, "username", fmt.Sprintf("a user with username %s already exists", row.user.Username)))
+		}
+		if taken["email"][row.user.Email] {
+			errs = append(errs, userImportError(row.row, "email", fmt.Sprintf("a user with email %s already exists", row.user.Email)))
+		}
+		if row.user.TeamID != nil && !existingTeams[*row.user.TeamID] {
+			errs = append(errs, userImportError(row.row, "teamId", fmt.Sprintf("there is no team %s", *row.user.TeamID)))
+		}
+	}
+
+	return errs, nil
+}
+
+func userImportError(row int, column string, message string) *model.ImportRowError {
+	e := &model.ImportRowError{Row: row, Message: message}
+	if column != "" {
+		e.Column = &column
+	}
+	return e
+}
+
+func isUserImportRole(role model.Role) bool {
+	for _, r := range userImportRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// userImportEnumValues lists the values of an enum for an error message.
+func userImportEnumValues(values interface{}) string {
+	return strings.ReplaceAll(strings.Trim(fmt.Sprint(values), "[]"), " ", ", ")
+}
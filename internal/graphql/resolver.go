@@ -1,8 +1,14 @@
 package graphql
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+
 	"github.com/labstack/echo/v4"
+	"github.com/marcustut/thebox/internal/battleground"
 	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/marcustut/thebox/internal/storage"
 )
 
 // This file will not be regenerated automatically.
@@ -10,20 +16,48 @@ import (
 // It serves as dependency injection for your app, add any dependencies you require here.
 
 type Resolver struct {
-	db  *postgresql.PrismaClient
-	app *echo.Echo
+	db                 *postgresql.PrismaClient
+	app                *echo.Echo
+	storage            storage.Storage
+	storageConfig      storage.Config
+	battlegroundTimers *battleground.Timers
+	battlegroundHub    *battleground.Hub
+	// requireVerifiedPayment blocks users from joining a team until their payment is verified
+	requireVerifiedPayment bool
 }
 
-func NewResolver(app *echo.Echo) *Resolver {
+// NewResolver connects to the database and sets up the storage, a storage that is not
+// configured is returned as an error so the server does not start half configured. The
+// round deadlines of the battleground run on the given clock.
+func NewResolver(app *echo.Echo, clock battleground.Clock) (*Resolver, error) {
+	store, storageConfig, err := storage.NewFromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("unable to configure storage: %w", err)
+	}
+
 	client := postgresql.NewClient()
 	if err := client.Connect(); err != nil {
-		panic(err)
+		return nil, fmt.Errorf("unable to connect to the database: %w", err)
 	}
 
+	requireVerifiedPayment, _ := strconv.ParseBool(os.Getenv("REQUIRE_VERIFIED_PAYMENT"))
+
 	r := &Resolver{
-		db:  client,
-		app: app,
+		db:                     client,
+		app:                    app,
+		storage:                store,
+		storageConfig:          storageConfig,
+		battlegroundTimers:     battleground.NewTimers(clock),
+		battlegroundHub:        battleground.NewHub(),
+		requireVerifiedPayment: requireVerifiedPayment,
 	}
 
-	return r
+	return r, nil
+}
+
+// LocalStorage returns the storage when media is kept on the disk of this server, which
+// then has to serve it.
+func (r *Resolver) LocalStorage() (*storage.Local, bool) {
+	local, ok := r.storage.(*storage.Local)
+	return local, ok
 }
@@ -7,12 +7,58 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/marcustut/thebox/internal/auth"
 	"github.com/marcustut/thebox/internal/graphql/generated"
 	"github.com/marcustut/thebox/internal/graphql/model"
 	"github.com/marcustut/thebox/internal/graphql/query"
 	"github.com/marcustut/thebox/internal/postgresql"
+	"github.com/marcustut/thebox/internal/rubric"
 )
 
+func (r *battlegroundActivityResolver) Team(ctx context.Context, obj *model.BattlegroundActivity) (*model.Team, error) {
+	if obj.TeamID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(*obj.TeamID))
+}
+
+func (r *battlegroundRoomResolver) Rounds(ctx context.Context, obj *model.BattlegroundRoom) ([]*model.BattlegroundRound, error) {
+	return query.GetManyBattlegroundRound(ctx, r.db, postgresql.BattlegroundRound.Code.Equals(obj.Code))
+}
+
+func (r *battlegroundRoundResolver) Attacker(ctx context.Context, obj *model.BattlegroundRound) (*model.User, error) {
+	if obj.Attacker == nil {
+		return nil, nil
+	}
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.Username.Equals(*obj.Attacker))
+}
+
+func (r *battlegroundRoundResolver) Defender(ctx context.Context, obj *model.BattlegroundRound) (*model.User, error) {
+	if obj.Defender == nil {
+		return nil, nil
+	}
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.Username.Equals(*obj.Defender))
+}
+
+func (r *battlegroundRoundResolver) AttackerTeam(ctx context.Context, obj *model.BattlegroundRound) (*model.Team, error) {
+	if obj.AttackerTeamID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(*obj.AttackerTeamID))
+}
+
+func (r *battlegroundRoundResolver) DefenderTeam(ctx context.Context, obj *model.BattlegroundRound) (*model.Team, error) {
+	if obj.DefenderTeamID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(*obj.DefenderTeamID))
+}
+
+func (r *battlegroundStandingResolver) Team(ctx context.Context, obj *model.BattlegroundStanding) (*model.Team, error) {
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
+}
+
 func (r *clusterResolver) Teams(ctx context.Context, obj *model.Cluster) ([]*model.Team, error) {
 	return query.GetManyTeam(ctx, r.db, model.PaginationInput{Limit: 50}, postgresql.Team.ClusterID.Equals(obj.ID))
 }
@@ -37,10 +83,49 @@ func (r *discoveryResolver) Mission(ctx context.Context, obj *model.Discovery) (
 	return query.GetUniqueMission(ctx, r.db, postgresql.Mission.ID.Equals(obj.MissionID))
 }
 
+func (r *discoveryResolver) Revisions(ctx context.Context, obj *model.Discovery) ([]*model.DiscoveryRevision, error) {
+	return query.GetManyDiscoveryRevision(ctx, r.db, postgresql.DiscoveryRevision.DiscoveryID.Equals(obj.ID))
+}
+
+func (r *discoveryRevisionResolver) Reviewer(ctx context.Context, obj *model.DiscoveryRevision) (*model.User, error) {
+	if obj.ReviewerID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(*obj.ReviewerID))
+}
+
 func (r *escapeResolver) Team(ctx context.Context, obj *model.Escape) (*model.Team, error) {
 	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
 }
 
+func (r *escapeProgressResolver) Team(ctx context.Context, obj *model.EscapeProgress) (*model.Team, error) {
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
+}
+
+func (r *escapeProgressResolver) Mission(ctx context.Context, obj *model.EscapeProgress) (*model.Mission, error) {
+	return query.GetUniqueMission(ctx, r.db, postgresql.Mission.ID.Equals(obj.MissionID))
+}
+
+func (r *escapeStageResolver) Mission(ctx context.Context, obj *model.EscapeStage) (*model.Mission, error) {
+	return query.GetUniqueMission(ctx, r.db, postgresql.Mission.ID.Equals(obj.MissionID))
+}
+
+func (r *humanityResolver) Photo1(ctx context.Context, obj *model.Humanity) (*string, error) {
+	return query.GetMediaURL(ctx, r.storage, r.storageConfig, obj.Photo1)
+}
+
+func (r *humanityResolver) Photo2(ctx context.Context, obj *model.Humanity) (*string, error) {
+	return query.GetMediaURL(ctx, r.storage, r.storageConfig, obj.Photo2)
+}
+
+func (r *humanityResolver) Photo3(ctx context.Context, obj *model.Humanity) (*string, error) {
+	return query.GetMediaURL(ctx, r.storage, r.storageConfig, obj.Photo3)
+}
+
+func (r *humanityResolver) Images(ctx context.Context, obj *model.Humanity, size *model.ImageSize) ([]string, error) {
+	return query.GetImageURLs(ctx, r.storage, r.storageConfig, obj.Photos(), size)
+}
+
 func (r *humanityResolver) Team(ctx context.Context, obj *model.Humanity) (*model.Team, error) {
 	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
 }
@@ -49,6 +134,22 @@ func (r *humanityResolver) Mission(ctx context.Context, obj *model.Humanity) (*m
 	return query.GetUniqueMission(ctx, r.db, postgresql.Mission.ID.Equals(obj.MissionID))
 }
 
+func (r *humanityResolver) Reviews(ctx context.Context, obj *model.Humanity) ([]*model.HumanityReview, error) {
+	return query.GetManyHumanityReview(ctx, r.db, postgresql.HumanityAssignment.HumanityID.Equals(obj.ID))
+}
+
+func (r *humanityResolver) AverageScore(ctx context.Context, obj *model.Humanity) (*float64, error) {
+	return query.GetHumanityAverageScore(ctx, r.db, obj.ID)
+}
+
+func (r *humanityReviewResolver) Humanity(ctx context.Context, obj *model.HumanityReview) (*model.Humanity, error) {
+	return query.GetUniqueHumanity(ctx, r.db, postgresql.Humanity.ID.Equals(obj.HumanityID))
+}
+
+func (r *humanityReviewResolver) Judge(ctx context.Context, obj *model.HumanityReview) (*model.User, error) {
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(obj.JudgeID))
+}
+
 func (r *invitationResolver) From(ctx context.Context, obj *model.Invitation) (*model.User, error) {
 	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.EqualsIfPresent(obj.FromID))
 }
@@ -65,8 +166,48 @@ func (r *missionResolver) CompletedBy(ctx context.Context, obj *model.Mission) (
 	return query.GetManyTeam(ctx, r.db, model.PaginationInput{Limit: 50}, postgresql.Team.TeamMission.Some(postgresql.TeamMission.MissionID.Equals(obj.ID)))
 }
 
+func (r *missionResolver) EscapeStages(ctx context.Context, obj *model.Mission) ([]*model.EscapeStage, error) {
+	return query.GetManyEscapeStage(ctx, r.db, postgresql.EscapeStage.MissionID.Equals(obj.ID))
+}
+
+func (r *missionResolver) SpeedAwardRules(ctx context.Context, obj *model.Mission) ([]*model.SpeedAwardRule, error) {
+	return query.GetManySpeedAwardRule(ctx, r.db, postgresql.SpeedAwardRule.MissionID.Equals(obj.ID))
+}
+
 func (r *mutationResolver) CreateUser(ctx context.Context, param model.NewUser) (*model.User, error) {
-	return query.CreateUserWithTxUnsafe(ctx, r.db, &param)
+	// only the crew picks the id and roles of a user, everyone else registers themselves
+	// as a player under the id they are logged in with
+	if r.hasRole(ctx, []model.Role{model.RoleCrew}) != nil {
+		userID, ok := auth.UserID(ctx)
+		if !ok {
+			return nil, fmt.Errorf("unauthenticated")
+		}
+		param.ID = &userID
+		param.Roles = []model.Role{model.RolePlayer}
+	}
+	// a new user is yet to pay so they cannot be placed in a team right away
+	if param.TeamID != nil && r.requireVerifiedPayment {
+		return nil, fmt.Errorf("payment must be %s to join a team", model.PaymentStatusVerified)
+	}
+	return query.CreateUser(ctx, r.db, &param)
+}
+
+func (r *mutationResolver) ImportUsers(ctx context.Context, file graphql.Upload, dryRun bool) (*model.UserImportResult, error) {
+	return query.ImportUsers(ctx, r.db, file.File, dryRun, r.requireVerifiedPayment)
+}
+
+func (r *mutationResolver) VerifyPayment(ctx context.Context, userID string, note *string) (*model.User, error) {
+	verifierID, _ := auth.UserID(ctx)
+	return query.VerifyPayment(ctx, r.db, verifierID, userID, note)
+}
+
+func (r *mutationResolver) RejectPayment(ctx context.Context, userID string, note string) (*model.User, error) {
+	verifierID, _ := auth.UserID(ctx)
+	return query.RejectPayment(ctx, r.db, verifierID, userID, note)
+}
+
+func (r *mutationResolver) FormTeams(ctx context.Context, param model.FormTeamsInput) (*model.TeamFormation, error) {
+	return query.FormTeams(ctx, r.db, &param, r.requireVerifiedPayment)
 }
 
 func (r *mutationResolver) CreatePost(ctx context.Context, param model.NewPost) (*model.Post, error) {
@@ -89,7 +230,25 @@ func (r *mutationResolver) CreateBattlegroundRoom(ctx context.Context, param mod
 	return query.CreateBattlegroundRoom(ctx, r.db, &param)
 }
 
+func (r *mutationResolver) CreatePracticeBattleground(ctx context.Context, param model.NewPracticeBattleground) (*model.BattlegroundRoom, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	battlegroundRoom, err := query.CreatePracticeBattleground(ctx, r.db, userID, &param)
+	if err != nil {
+		return nil, err
+	}
+	r.syncBattlegroundTimer(ctx, battlegroundRoom.Code)
+	return battlegroundRoom, nil
+}
+
 func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, param model.UpdateUserInput) (*model.User, error) {
+	if param.TeamID != nil && r.requireVerifiedPayment {
+		if err := query.CheckPaymentVerified(ctx, r.db, userID); err != nil {
+			return nil, err
+		}
+	}
 	user, err := query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(userID))
 	if err != nil {
 		return nil, err
@@ -99,17 +258,159 @@ func (r *mutationResolver) UpdateUser(ctx context.Context, userID string, param
 }
 
 func (r *mutationResolver) UpdateTeam(ctx context.Context, teamID string, param model.UpdateTeamInput) (*model.Team, error) {
-	return query.UpdateUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(teamID), &param)
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	// the crew can update any team
+	if r.hasRole(ctx, []model.Role{model.RoleCrew}) == nil {
+		return query.UpdateUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(teamID), &param, nil)
+	}
+	return query.UpdateUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(teamID), &param, &userID)
+}
+
+func (r *mutationResolver) GrantPowercard(ctx context.Context, param model.GrantPowercardInput) (*model.Team, error) {
+	userID, _ := auth.UserID(ctx)
+	return query.GrantPowercard(ctx, r.db, &param, &userID)
+}
+
+func (r *mutationResolver) RevokePowercard(ctx context.Context, param model.RevokePowercardInput) (*model.Team, error) {
+	userID, _ := auth.UserID(ctx)
+	return query.RevokePowercard(ctx, r.db, &param, &userID)
+}
+
+func (r *mutationResolver) EquipPowercard(ctx context.Context, teamID string, powercard model.Powercard) (*model.Team, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	return query.EquipPowercard(ctx, r.db, teamID, powercard, &userID)
 }
 
 func (r *mutationResolver) UpdateBattlegroundRoom(ctx context.Context, code string, param model.UpdateBattlegroundRoomInput) (*model.BattlegroundRoom, error) {
-	return query.UpdateUniqueBattlegroundRoom(ctx, r.db, postgresql.BattlegroundRoom.Code.Equals(code), &param)
+	battlegroundRoom, err := query.UpdateUniqueBattlegroundRoom(ctx, r.db, postgresql.BattlegroundRoom.Code.Equals(code), &param)
+	if err != nil {
+		return nil, err
+	}
+	r.syncBattlegroundTimer(ctx, code)
+	return battlegroundRoom, nil
+}
+
+func (r *mutationResolver) JoinBattlegroundRoom(ctx context.Context, code string) (*model.BattlegroundRoom, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	defer r.battlegroundHub.Publish(code)
+	return query.JoinBattlegroundRoom(ctx, r.db, userID, code)
+}
+
+func (r *mutationResolver) LeaveBattlegroundRoom(ctx context.Context, code string) (*model.BattlegroundRoom, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	defer r.battlegroundHub.Publish(code)
+	return query.LeaveBattlegroundRoom(ctx, r.db, userID, code)
+}
+
+func (r *mutationResolver) SetReady(ctx context.Context, code string, ready bool) (*model.BattlegroundRoom, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	defer r.battlegroundHub.Publish(code)
+	return query.SetBattlegroundReady(ctx, r.db, userID, code, ready)
+}
+
+func (r *mutationResolver) StartBattleground(ctx context.Context, code string) (*model.BattlegroundRoom, error) {
+	battlegroundRoom, err := query.StartBattleground(ctx, r.db, code)
+	if err != nil {
+		return nil, err
+	}
+	r.syncBattlegroundTimer(ctx, code)
+	return battlegroundRoom, nil
+}
+
+func (r *mutationResolver) EndBattleground(ctx context.Context, code string) (*model.BattlegroundRoom, error) {
+	battlegroundRoom, err := query.EndBattleground(ctx, r.db, code)
+	if err != nil {
+		return nil, err
+	}
+	r.syncBattlegroundTimer(ctx, code)
+	return battlegroundRoom, nil
+}
+
+func (r *mutationResolver) SubmitBattlegroundSelection(ctx context.Context, code string, round int, selection model.BattlegroundSelection) (*model.BattlegroundRound, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	battlegroundRound, err := query.SubmitBattlegroundSelection(ctx, r.db, userID, code, round, selection, r.battlegroundTimers.Clock().Now())
+	if err != nil {
+		return nil, err
+	}
+	r.syncBattlegroundTimer(ctx, code)
+	return battlegroundRound, nil
+}
+
+func (r *mutationResolver) OpenBattlegroundEffect(ctx context.Context, code string, round int, effect model.BattlegroundEffect) (*model.BattlegroundRound, error) {
+	defer r.battlegroundHub.Publish(code)
+	return query.OpenBattlegroundEffect(ctx, r.db, code, round, effect, r.battlegroundTimers.Clock().Now())
+}
+
+func (r *mutationResolver) PlayBattlegroundPowercard(ctx context.Context, code string, round int, teamID string) (*model.BattlegroundRound, error) {
+	userID, _ := auth.UserID(ctx)
+	defer r.battlegroundHub.Publish(code)
+	return query.PlayBattlegroundPowercard(ctx, r.db, code, round, teamID, &userID, r.battlegroundTimers.Clock().Now())
+}
+
+func (r *mutationResolver) OpenPracticeEffect(ctx context.Context, code string, round int, effect model.BattlegroundEffect) (*model.BattlegroundRound, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	defer r.battlegroundHub.Publish(code)
+	return query.OpenPracticeBattlegroundEffect(ctx, r.db, userID, code, round, effect, r.battlegroundTimers.Clock().Now())
+}
+
+func (r *mutationResolver) PlayPracticePowercard(ctx context.Context, code string, round int, powercard model.Powercard) (*model.BattlegroundRound, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	defer r.battlegroundHub.Publish(code)
+	return query.PlayPracticeBattlegroundPowercard(ctx, r.db, userID, code, round, powercard, r.battlegroundTimers.Clock().Now())
+}
+
+func (r *mutationResolver) CreateTournament(ctx context.Context, param model.NewTournament) (*model.Tournament, error) {
+	return query.CreateTournament(ctx, r.db, &param)
+}
+
+func (r *mutationResolver) StartTournament(ctx context.Context, id string) (*model.Tournament, error) {
+	return query.StartTournament(ctx, r.db, id)
+}
+
+func (r *mutationResolver) AdvanceTournament(ctx context.Context, id string) (*model.Tournament, error) {
+	return query.AdvanceTournament(ctx, r.db, id)
 }
 
 func (r *mutationResolver) UpsertEscape(ctx context.Context, param model.UpsertEscapeInput) (*model.Escape, error) {
 	return query.UpsertUniqueEscape(ctx, r.db, &param)
 }
 
+func (r *mutationResolver) SetEscapeStages(ctx context.Context, param model.SetEscapeStagesInput) ([]*model.EscapeStage, error) {
+	return query.SetEscapeStages(ctx, r.db, &param)
+}
+
+func (r *mutationResolver) SubmitEscapeAnswer(ctx context.Context, param model.SubmitEscapeAnswerInput) (*model.EscapeAnswerResult, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	return query.SubmitEscapeAnswer(ctx, r.db, userID, &param)
+}
+
 func (r *mutationResolver) UpsertSpeed(ctx context.Context, param model.UpsertSpeedInput) (*model.Speed, error) {
 	return query.UpsertUniqueSpeed(ctx, r.db, &param)
 }
@@ -139,6 +440,15 @@ func (r *mutationResolver) UnlikeComment(ctx context.Context, param model.Commen
 }
 
 func (r *mutationResolver) AcceptInvitation(ctx context.Context, invitationID string) (*bool, error) {
+	if r.requireVerifiedPayment {
+		invitation, err := query.GetUniqueInvitation(ctx, r.db, postgresql.Invitation.ID.Equals(invitationID))
+		if err != nil {
+			return nil, err
+		}
+		if err := query.CheckPaymentVerified(ctx, r.db, invitation.UserID); err != nil {
+			return nil, err
+		}
+	}
 	return query.AcceptInvitation(ctx, r.db, invitationID)
 }
 
@@ -146,6 +456,80 @@ func (r *mutationResolver) RejectInvitation(ctx context.Context, invitationID st
 	return query.RejectInvitation(ctx, r.db, invitationID)
 }
 
+func (r *mutationResolver) UploadMedia(ctx context.Context, param model.UploadMediaInput) (*model.Media, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, errUnauthenticated
+	}
+	// the crew can upload for anyone, everyone else only for what is their own
+	if r.hasRole(ctx, []model.Role{model.RoleCrew}) != nil {
+		if err := query.CheckMediaTarget(ctx, r.db, userID, param.Target, param.TargetID); err != nil {
+			return nil, err
+		}
+	}
+	return query.UploadMedia(ctx, r.db, r.storage, r.storageConfig, &param)
+}
+
+func (r *mutationResolver) AssignHumanityReviews(ctx context.Context, missionID string, judgesPerSubmission int) ([]*model.HumanityReview, error) {
+	return query.AssignHumanityReviews(ctx, r.db, missionID, judgesPerSubmission)
+}
+
+func (r *mutationResolver) ScoreHumanity(ctx context.Context, param model.ScoreHumanityInput) (*model.HumanityReview, error) {
+	judgeID, _ := auth.UserID(ctx)
+	return query.ScoreHumanity(ctx, r.db, judgeID, &param)
+}
+
+func (r *mutationResolver) PublishHumanityResults(ctx context.Context, missionID string) ([]*model.PointAward, error) {
+	return query.PublishHumanityResults(ctx, r.db, missionID)
+}
+
+func (r *mutationResolver) SetSpeedAnswers(ctx context.Context, param model.SetSpeedAnswersInput) (*model.Mission, error) {
+	return query.SetSpeedAnswers(ctx, r.db, &param)
+}
+
+func (r *mutationResolver) SubmitSpeedAnswer(ctx context.Context, param model.SubmitSpeedAnswerInput) (*model.SpeedAnswerResult, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, fmt.Errorf("unauthenticated")
+	}
+	return query.SubmitSpeedAnswer(ctx, r.db, userID, &param)
+}
+
+func (r *mutationResolver) SetSpeedAwardRules(ctx context.Context, param model.SetSpeedAwardRulesInput) ([]*model.SpeedAwardRule, error) {
+	return query.SetSpeedAwardRules(ctx, r.db, &param)
+}
+
+func (r *mutationResolver) AwardSpeedRanking(ctx context.Context, missionID string) ([]*model.PointAward, error) {
+	return query.AwardSpeedRanking(ctx, r.db, missionID)
+}
+
+func (r *mutationResolver) SubmitDiscovery(ctx context.Context, param model.SubmitDiscoveryInput) (*model.Discovery, error) {
+	return query.SubmitDiscovery(ctx, r.db, &param)
+}
+
+func (r *mutationResolver) StartDiscoveryReview(ctx context.Context, discoveryID string) (*model.Discovery, error) {
+	reviewerID, _ := auth.UserID(ctx)
+	return query.StartDiscoveryReview(ctx, r.db, reviewerID, discoveryID)
+}
+
+func (r *mutationResolver) AcceptDiscovery(ctx context.Context, param model.AcceptDiscoveryInput) (*model.Discovery, error) {
+	reviewerID, _ := auth.UserID(ctx)
+	return query.AcceptDiscovery(ctx, r.db, reviewerID, &param)
+}
+
+func (r *mutationResolver) RejectDiscovery(ctx context.Context, discoveryID string, feedback string) (*model.Discovery, error) {
+	reviewerID, _ := auth.UserID(ctx)
+	return query.RejectDiscovery(ctx, r.db, reviewerID, discoveryID, feedback)
+}
+
+func (r *pointAwardResolver) Team(ctx context.Context, obj *model.PointAward) (*model.Team, error) {
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
+}
+
+func (r *postResolver) Images(ctx context.Context, obj *model.Post, size *model.ImageSize) ([]string, error) {
+	return query.GetImageURLs(ctx, r.storage, r.storageConfig, obj.Images, size)
+}
+
 func (r *postResolver) User(ctx context.Context, obj *model.Post) (*model.User, error) {
 	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(obj.UserID))
 }
@@ -162,6 +546,25 @@ func (r *postResolver) Comments(ctx context.Context, obj *model.Post, page model
 	return query.GetManyComment(ctx, r.db, page, postgresql.Comment.PostID.Equals(obj.ID))
 }
 
+func (r *powercardEventResolver) Team(ctx context.Context, obj *model.PowercardEvent) (*model.Team, error) {
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
+}
+
+func (r *powercardEventResolver) User(ctx context.Context, obj *model.PowercardEvent) (*model.User, error) {
+	if obj.UserID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(*obj.UserID))
+}
+
+func (r *profileResolver) TngReceiptURL(ctx context.Context, obj *model.Profile) (*string, error) {
+	return query.GetMediaURL(ctx, r.storage, r.storageConfig, obj.TngReceiptURL)
+}
+
+func (r *profileResolver) AvatarURL(ctx context.Context, obj *model.Profile) (*string, error) {
+	return query.GetMediaURL(ctx, r.storage, r.storageConfig, obj.AvatarURL)
+}
+
 func (r *profileResolver) Address(ctx context.Context, obj *model.Profile) (*model.Address, error) {
 	if obj.AddressID == nil {
 		return nil, fmt.Errorf("profile %s does not have an address", obj.ID)
@@ -169,6 +572,13 @@ func (r *profileResolver) Address(ctx context.Context, obj *model.Profile) (*mod
 	return query.GetUniqueAddress(ctx, r.db, postgresql.Address.ID.Equals(*obj.AddressID))
 }
 
+func (r *profileResolver) PaymentVerifier(ctx context.Context, obj *model.Profile) (*model.User, error) {
+	if obj.PaymentVerifierID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(*obj.PaymentVerifierID))
+}
+
 func (r *queryResolver) User(ctx context.Context, userID string) (*model.User, error) {
 	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(userID))
 }
@@ -181,6 +591,14 @@ func (r *queryResolver) UserCount(ctx context.Context) (int, error) {
 	return query.GetTotalUserCount(ctx, r.db)
 }
 
+func (r *queryResolver) RegistrationStats(ctx context.Context) (*model.RegistrationStats, error) {
+	return query.GetRegistrationStats(ctx, r.db)
+}
+
+func (r *queryResolver) PendingPayments(ctx context.Context, page model.PaginationInput) ([]*model.User, error) {
+	return query.GetPendingPayments(ctx, r.db, page)
+}
+
 func (r *queryResolver) Team(ctx context.Context, teamID string) (*model.Team, error) {
 	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(teamID))
 }
@@ -193,6 +611,10 @@ func (r *queryResolver) Escape(ctx context.Context, teamID string) (*model.Escap
 	return query.GetUniqueEscape(ctx, r.db, postgresql.Escape.TeamID.Equals(teamID))
 }
 
+func (r *queryResolver) EscapeProgress(ctx context.Context, teamID string) ([]*model.EscapeProgress, error) {
+	return query.GetManyEscapeProgress(ctx, r.db, teamID)
+}
+
 func (r *queryResolver) Speed(ctx context.Context, teamID string) (*model.Speed, error) {
 	return query.GetUniqueSpeed(ctx, r.db, postgresql.Speed.TeamID.Equals(teamID))
 }
@@ -201,6 +623,10 @@ func (r *queryResolver) Speeds(ctx context.Context, page model.PaginationInput)
 	return query.GetManySpeed(ctx, r.db, page)
 }
 
+func (r *queryResolver) SpeedRanking(ctx context.Context, missionID string) ([]*model.SpeedRank, error) {
+	return query.GetSpeedRanking(ctx, r.db, missionID)
+}
+
 func (r *queryResolver) Humanity(ctx context.Context, teamID string) (*model.Humanity, error) {
 	return query.GetUniqueHumanity(ctx, r.db, postgresql.Humanity.TeamID.Equals(teamID))
 }
@@ -234,21 +660,105 @@ func (r *queryResolver) BattlegroundRooms(ctx context.Context, page model.Pagina
 }
 
 func (r *queryResolver) BattlegroundRound(ctx context.Context, code string, round int) (*model.BattlegroundRound, error) {
-	panic(fmt.Errorf("not implemented"))
+	return query.GetUniqueBattlegroundRound(ctx, r.db, postgresql.BattlegroundRound.CodeRound(
+		postgresql.BattlegroundRound.Code.Equals(code),
+		postgresql.BattlegroundRound.Round.Equals(round),
+	))
+}
+
+func (r *queryResolver) BattlegroundHistory(ctx context.Context, teamID string) ([]*model.BattlegroundRound, error) {
+	return query.GetBattlegroundHistory(ctx, r.db, teamID)
+}
+
+func (r *queryResolver) BattlegroundReplay(ctx context.Context, code string) (*model.BattlegroundReplay, error) {
+	return query.GetBattlegroundReplay(ctx, r.db, code)
+}
+
+func (r *queryResolver) BattlegroundSpectator(ctx context.Context, code string) (*model.BattlegroundSpectatorView, error) {
+	return query.GetBattlegroundSpectatorView(ctx, r.db, code)
+}
+
+func (r *queryResolver) BattlegroundTimer(ctx context.Context, code string) (*model.BattlegroundTimer, error) {
+	return query.GetBattlegroundTimer(ctx, r.db, code, r.battlegroundTimers.Clock().Now())
+}
+
+func (r *queryResolver) Tournament(ctx context.Context, id string) (*model.Tournament, error) {
+	return query.GetUniqueTournament(ctx, r.db, postgresql.Tournament.ID.Equals(id))
+}
+
+func (r *queryResolver) TournamentBracket(ctx context.Context, id string) (*model.TournamentBracket, error) {
+	return query.GetTournamentBracket(ctx, r.db, id)
 }
 
 func (r *queryResolver) Post(ctx context.Context, postID string) (*model.Post, error) {
 	return query.GetUniquePost(ctx, r.db, postgresql.Post.ID.Equals(postID))
 }
 
-func (r *queryResolver) Posts(ctx context.Context, page model.PaginationInput) ([]*model.Post, error) {
-	return query.GetManyPost(ctx, r.db, page)
+func (r *queryResolver) Posts(ctx context.Context, page model.PaginationInput, orderBy *model.PostOrder) ([]*model.Post, error) {
+	return query.GetManyOrderedPost(ctx, r.db, page, orderBy)
+}
+
+func (r *queryResolver) UserPosts(ctx context.Context, userID string, page model.CursorPaginationInput, orderBy *model.PostOrder) (*model.PostFeed, error) {
+	return query.GetUserPostFeed(ctx, r.db, userID, page, orderBy)
+}
+
+func (r *queryResolver) TeamFeed(ctx context.Context, teamID string, page model.CursorPaginationInput, orderBy *model.PostOrder) (*model.PostFeed, error) {
+	return query.GetTeamPostFeed(ctx, r.db, teamID, page, orderBy)
+}
+
+func (r *queryResolver) ClusterFeed(ctx context.Context, clusterID string, page model.CursorPaginationInput, orderBy *model.PostOrder) (*model.PostFeed, error) {
+	return query.GetClusterPostFeed(ctx, r.db, clusterID, page, orderBy)
 }
 
 func (r *queryResolver) Invitations(ctx context.Context, userID string, page model.PaginationInput) ([]*model.Invitation, error) {
 	return query.GetManyInvitation(ctx, r.db, page, postgresql.Invitation.UserID.Equals(userID))
 }
 
+func (r *queryResolver) HumanityRubric(ctx context.Context) ([]*model.RubricCriterion, error) {
+	return query.GetRubricCriteria(rubric.Humanity), nil
+}
+
+func (r *queryResolver) DiscoveryRubric(ctx context.Context) ([]*model.RubricCriterion, error) {
+	return query.GetRubricCriteria(rubric.Discovery), nil
+}
+
+func (r *queryResolver) DiscoveriesForReview(ctx context.Context, status *model.DiscoveryStatus, page model.PaginationInput) ([]*model.Discovery, error) {
+	if status == nil {
+		return query.GetManyDiscovery(ctx, r.db, page, postgresql.Discovery.Not(postgresql.Discovery.Status.IsNull()))
+	}
+	return query.GetManyDiscovery(ctx, r.db, page, postgresql.Discovery.Status.Equals(postgresql.DiscoveryStatus(*status)))
+}
+
+func (r *queryResolver) PendingHumanityReviews(ctx context.Context, page model.PaginationInput) ([]*model.HumanityReview, error) {
+	judgeID, _ := auth.UserID(ctx)
+	return query.GetPendingHumanityReviews(ctx, r.db, judgeID, page)
+}
+
+func (r *queryResolver) Search(ctx context.Context, keyword string, types []model.SearchType, page model.PaginationInput) ([]*model.SearchResult, error) {
+	return query.Search(ctx, r.db, keyword, types, page)
+}
+
+func (r *searchResultResolver) User(ctx context.Context, obj *model.SearchResult) (*model.User, error) {
+	if obj.Type != model.SearchTypeUser {
+		return nil, nil
+	}
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(obj.ID))
+}
+
+func (r *searchResultResolver) Team(ctx context.Context, obj *model.SearchResult) (*model.Team, error) {
+	if obj.Type != model.SearchTypeTeam {
+		return nil, nil
+	}
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.ID))
+}
+
+func (r *searchResultResolver) Post(ctx context.Context, obj *model.SearchResult) (*model.Post, error) {
+	if obj.Type != model.SearchTypePost {
+		return nil, nil
+	}
+	return query.GetUniquePost(ctx, r.db, postgresql.Post.ID.Equals(obj.ID))
+}
+
 func (r *speedResolver) Team(ctx context.Context, obj *model.Speed) (*model.Team, error) {
 	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
 }
@@ -257,6 +767,37 @@ func (r *speedResolver) Mission(ctx context.Context, obj *model.Speed) (*model.M
 	return query.GetUniqueMission(ctx, r.db, postgresql.Mission.ID.Equals(obj.MissionID))
 }
 
+func (r *speedResolver) Attempts(ctx context.Context, obj *model.Speed) ([]*model.SpeedAttempt, error) {
+	return query.GetManySpeedAttempt(ctx, r.db, postgresql.SpeedAttempt.SpeedID.Equals(obj.ID))
+}
+
+func (r *speedAttemptResolver) User(ctx context.Context, obj *model.SpeedAttempt) (*model.User, error) {
+	if obj.UserID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueUser(ctx, r.db, postgresql.User.ID.Equals(*obj.UserID))
+}
+
+func (r *speedRankResolver) Team(ctx context.Context, obj *model.SpeedRank) (*model.Team, error) {
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(obj.TeamID))
+}
+
+func (r *subscriptionResolver) BattlegroundTimer(ctx context.Context, code string) (<-chan *model.BattlegroundTimer, error) {
+	return r.battlegroundTimer(ctx, code), nil
+}
+
+func (r *subscriptionResolver) BattlegroundSpectator(ctx context.Context, code string) (<-chan *model.BattlegroundSpectatorView, error) {
+	return r.battlegroundSpectator(ctx, code)
+}
+
+func (r *teamResolver) AvatarURL(ctx context.Context, obj *model.Team) (*string, error) {
+	return query.GetMediaURL(ctx, r.storage, r.storageConfig, obj.AvatarUrl)
+}
+
+func (r *teamResolver) PowercardHistory(ctx context.Context, obj *model.Team) ([]*model.PowercardEvent, error) {
+	return query.GetManyPowercardEvent(ctx, r.db, postgresql.PowercardEvent.TeamID.Equals(obj.ID))
+}
+
 func (r *teamResolver) Cluster(ctx context.Context, obj *model.Team) (*model.Cluster, error) {
 	if obj.ClusterID == nil {
 		// return nil, gqlerror.Errorf("team %s does not have a cluster", obj.ID)
@@ -273,6 +814,35 @@ func (r *teamResolver) Members(ctx context.Context, obj *model.Team) ([]*model.U
 	return query.GetManyUser(ctx, r.db, model.PaginationInput{Limit: 100}, postgresql.User.TeamID.Equals(obj.ID))
 }
 
+func (r *tournamentResolver) Teams(ctx context.Context, obj *model.Tournament) ([]*model.Team, error) {
+	return query.GetTeamsInOrder(ctx, r.db, obj.TeamIds)
+}
+
+func (r *tournamentResolver) Winner(ctx context.Context, obj *model.Tournament) (*model.Team, error) {
+	if obj.WinnerTeamID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(*obj.WinnerTeamID))
+}
+
+func (r *tournamentMatchResolver) Teams(ctx context.Context, obj *model.TournamentMatch) ([]*model.Team, error) {
+	return query.GetTeamsInOrder(ctx, r.db, obj.TeamIds)
+}
+
+func (r *tournamentMatchResolver) Room(ctx context.Context, obj *model.TournamentMatch) (*model.BattlegroundRoom, error) {
+	if obj.Code == nil {
+		return nil, nil
+	}
+	return query.GetUniqueBattlegroundRoom(ctx, r.db, postgresql.BattlegroundRoom.Code.Equals(*obj.Code))
+}
+
+func (r *tournamentMatchResolver) Winner(ctx context.Context, obj *model.TournamentMatch) (*model.Team, error) {
+	if obj.WinnerTeamID == nil {
+		return nil, nil
+	}
+	return query.GetUniqueTeam(ctx, r.db, postgresql.Team.ID.Equals(*obj.WinnerTeamID))
+}
+
 func (r *userResolver) Profile(ctx context.Context, obj *model.User) (*model.Profile, error) {
 	return query.GetUniqueProfile(ctx, r.db, postgresql.Profile.ID.Equals(obj.ProfileID))
 }
@@ -289,6 +859,26 @@ func (r *userResolver) Roles(ctx context.Context, obj *model.User) ([]model.Role
 	return query.GetManyRoles(ctx, r.db, postgresql.UserRole.UserID.Equals(obj.ID))
 }
 
+// BattlegroundActivity returns generated.BattlegroundActivityResolver implementation.
+func (r *Resolver) BattlegroundActivity() generated.BattlegroundActivityResolver {
+	return &battlegroundActivityResolver{r}
+}
+
+// BattlegroundRoom returns generated.BattlegroundRoomResolver implementation.
+func (r *Resolver) BattlegroundRoom() generated.BattlegroundRoomResolver {
+	return &battlegroundRoomResolver{r}
+}
+
+// BattlegroundRound returns generated.BattlegroundRoundResolver implementation.
+func (r *Resolver) BattlegroundRound() generated.BattlegroundRoundResolver {
+	return &battlegroundRoundResolver{r}
+}
+
+// BattlegroundStanding returns generated.BattlegroundStandingResolver implementation.
+func (r *Resolver) BattlegroundStanding() generated.BattlegroundStandingResolver {
+	return &battlegroundStandingResolver{r}
+}
+
 // Cluster returns generated.ClusterResolver implementation.
 func (r *Resolver) Cluster() generated.ClusterResolver { return &clusterResolver{r} }
 
@@ -298,12 +888,30 @@ func (r *Resolver) Comment() generated.CommentResolver { return &commentResolver
 // Discovery returns generated.DiscoveryResolver implementation.
 func (r *Resolver) Discovery() generated.DiscoveryResolver { return &discoveryResolver{r} }
 
+// DiscoveryRevision returns generated.DiscoveryRevisionResolver implementation.
+func (r *Resolver) DiscoveryRevision() generated.DiscoveryRevisionResolver {
+	return &discoveryRevisionResolver{r}
+}
+
 // Escape returns generated.EscapeResolver implementation.
 func (r *Resolver) Escape() generated.EscapeResolver { return &escapeResolver{r} }
 
+// EscapeProgress returns generated.EscapeProgressResolver implementation.
+func (r *Resolver) EscapeProgress() generated.EscapeProgressResolver {
+	return &escapeProgressResolver{r}
+}
+
+// EscapeStage returns generated.EscapeStageResolver implementation.
+func (r *Resolver) EscapeStage() generated.EscapeStageResolver { return &escapeStageResolver{r} }
+
 // Humanity returns generated.HumanityResolver implementation.
 func (r *Resolver) Humanity() generated.HumanityResolver { return &humanityResolver{r} }
 
+// HumanityReview returns generated.HumanityReviewResolver implementation.
+func (r *Resolver) HumanityReview() generated.HumanityReviewResolver {
+	return &humanityReviewResolver{r}
+}
+
 // Invitation returns generated.InvitationResolver implementation.
 func (r *Resolver) Invitation() generated.InvitationResolver { return &invitationResolver{r} }
 
@@ -313,35 +921,79 @@ func (r *Resolver) Mission() generated.MissionResolver { return &missionResolver
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
+// PointAward returns generated.PointAwardResolver implementation.
+func (r *Resolver) PointAward() generated.PointAwardResolver { return &pointAwardResolver{r} }
+
 // Post returns generated.PostResolver implementation.
 func (r *Resolver) Post() generated.PostResolver { return &postResolver{r} }
 
+// PowercardEvent returns generated.PowercardEventResolver implementation.
+func (r *Resolver) PowercardEvent() generated.PowercardEventResolver {
+	return &powercardEventResolver{r}
+}
+
 // Profile returns generated.ProfileResolver implementation.
 func (r *Resolver) Profile() generated.ProfileResolver { return &profileResolver{r} }
 
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// SearchResult returns generated.SearchResultResolver implementation.
+func (r *Resolver) SearchResult() generated.SearchResultResolver { return &searchResultResolver{r} }
+
 // Speed returns generated.SpeedResolver implementation.
 func (r *Resolver) Speed() generated.SpeedResolver { return &speedResolver{r} }
 
+// SpeedAttempt returns generated.SpeedAttemptResolver implementation.
+func (r *Resolver) SpeedAttempt() generated.SpeedAttemptResolver { return &speedAttemptResolver{r} }
+
+// SpeedRank returns generated.SpeedRankResolver implementation.
+func (r *Resolver) SpeedRank() generated.SpeedRankResolver { return &speedRankResolver{r} }
+
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
 // Team returns generated.TeamResolver implementation.
 func (r *Resolver) Team() generated.TeamResolver { return &teamResolver{r} }
 
+// Tournament returns generated.TournamentResolver implementation.
+func (r *Resolver) Tournament() generated.TournamentResolver { return &tournamentResolver{r} }
+
+// TournamentMatch returns generated.TournamentMatchResolver implementation.
+func (r *Resolver) TournamentMatch() generated.TournamentMatchResolver {
+	return &tournamentMatchResolver{r}
+}
+
 // User returns generated.UserResolver implementation.
 func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
 
+type battlegroundActivityResolver struct{ *Resolver }
+type battlegroundRoomResolver struct{ *Resolver }
+type battlegroundRoundResolver struct{ *Resolver }
+type battlegroundStandingResolver struct{ *Resolver }
 type clusterResolver struct{ *Resolver }
 type commentResolver struct{ *Resolver }
 type discoveryResolver struct{ *Resolver }
+type discoveryRevisionResolver struct{ *Resolver }
 type escapeResolver struct{ *Resolver }
+type escapeProgressResolver struct{ *Resolver }
+type escapeStageResolver struct{ *Resolver }
 type humanityResolver struct{ *Resolver }
+type humanityReviewResolver struct{ *Resolver }
 type invitationResolver struct{ *Resolver }
 type missionResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type pointAwardResolver struct{ *Resolver }
 type postResolver struct{ *Resolver }
+type powercardEventResolver struct{ *Resolver }
 type profileResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type searchResultResolver struct{ *Resolver }
 type speedResolver struct{ *Resolver }
+type speedAttemptResolver struct{ *Resolver }
+type speedRankResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
 type teamResolver struct{ *Resolver }
+type tournamentResolver struct{ *Resolver }
+type tournamentMatchResolver struct{ *Resolver }
 type userResolver struct{ *Resolver }
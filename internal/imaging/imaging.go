@@ -0,0 +1,255 @@
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+// The largest images accepted, they are checked before decoding as a small file can
+// decode into a huge image. Every frame of a GIF is decoded at once, so GIFs are held
+// to a smaller size and a number of frames.
+const (
+	maxPixels    = 40_000_000
+	maxGIFPixels = 2_000_000
+	maxGIFFrames = 500
+)
+
+// Variant is a size an uploaded image is resized to, MaxDimension is the limit of
+// the longest side and 0 keeps the original dimensions.
+type Variant struct {
+	Name         string
+	MaxDimension int
+}
+
+var (
+	Small    = Variant{Name: "small", MaxDimension: 320}
+	Medium   = Variant{Name: "medium", MaxDimension: 1080}
+	Original = Variant{Name: "original"}
+
+	Variants = []Variant{Small, Medium, Original}
+)
+
+// Result holds the encoded variants of a processed image, all of them share the
+// same content type.
+type Result struct {
+	ContentType string
+	Ext         string
+	Variants    map[Variant][]byte
+}
+
+// Process decodes an image, applies its EXIF orientation and re-encodes it in every
+// variant. Re-encoding drops all metadata so EXIF data such as GPS never leaves the
+// server. PNGs stay PNG to keep transparency, GIFs stay GIF so animations survive and
+// everything else becomes JPEG.
+func Process(data []byte, contentType string) (*Result, error) {
+	if err := checkSize(data, contentType); err != nil {
+		return nil, err
+	}
+	if contentType == "image/gif" {
+		return processGIF(data)
+	}
+
+	img, err := decode(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "image/jpeg" {
+		img = orient(img, orientation(data))
+	}
+
+	result := &Result{ContentType: "image/jpeg", Ext: ".jpg", Variants: map[Variant][]byte{}}
+	if contentType == "image/png" {
+		result.ContentType, result.Ext = "image/png", ".png"
+	}
+
+	for _, variant := range Variants {
+		var buf bytes.Buffer
+		resized := resize(img, variant.MaxDimension)
+		if result.ContentType == "image/png" {
+			err = png.Encode(&buf, resized)
+		} else {
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: jpegQuality})
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Variants[variant] = buf.Bytes()
+	}
+
+	return result, nil
+}
+
+// processGIF resizes every frame of a GIF. GIFs carry no EXIF, but the original is still
+// re-encoded as comment and XMP blocks could hold anything.
+func processGIF(data []byte) (*Result, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(g.Image) > maxGIFFrames {
+		return nil, fmt.Errorf("GIF has more than %d frames", maxGIFFrames)
+	}
+
+	result := &Result{ContentType: "image/gif", Ext: ".gif", Variants: map[Variant][]byte{}}
+	for _, variant := range Variants {
+		resized := g
+		if w, h := fit(g.Config.Width, g.Config.Height, variant.MaxDimension); w != g.Config.Width || h != g.Config.Height {
+			resized = resizeGIF(g, w, h)
+		}
+
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, resized); err != nil {
+			return nil, err
+		}
+		result.Variants[variant] = buf.Bytes()
+	}
+
+	return result, nil
+}
+
+// checkSize rejects images with more pixels than can be safely decoded, reading only
+// the header of the image.
+func checkSize(data []byte, contentType string) error {
+	var config image.Config
+	var err error
+	r := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		config, err = jpeg.DecodeConfig(r)
+	case "image/png":
+		config, err = png.DecodeConfig(r)
+	case "image/gif":
+		config, err = gif.DecodeConfig(r)
+	case "image/webp":
+		config, err = webp.DecodeConfig(r)
+	default:
+		return fmt.Errorf("unsupported content type %s", contentType)
+	}
+	if err != nil {
+		return err
+	}
+
+	limit := int64(maxPixels)
+	if contentType == "image/gif" {
+		limit = maxGIFPixels
+	}
+	if int64(config.Width)*int64(config.Height) > limit {
+		return fmt.Errorf("image is %dx%d, larger than %d pixels", config.Width, config.Height, limit)
+	}
+
+	return nil
+}
+
+// VariantKey returns the storage key of a variant given the key of the original,
+// e.g. post_image/1/2.jpg becomes post_image/1/2_small.jpg.
+func VariantKey(key string, variant Variant) string {
+	if variant == Original {
+		return key
+	}
+
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "_" + variant.Name + ext
+}
+
+func decode(data []byte, contentType string) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Decode(r)
+	case "image/png":
+		return png.Decode(r)
+	case "image/webp":
+		return webp.Decode(r)
+	default:
+		return nil, fmt.Errorf("unsupported content type %s", contentType)
+	}
+}
+
+// resize scales img down so its longest side fits in maxDimension, images that
+// are already small enough are returned as is.
+func resize(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	w, h := fit(bounds.Dx(), bounds.Dy(), maxDimension)
+	if w == bounds.Dx() && h == bounds.Dy() {
+		return img
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// resizeGIF scales every frame of g to a w by h canvas. Frames only cover part of the
+// canvas, so their bounds are scaled along with them, and they keep their palette by
+// picking the nearest pixel instead of blending.
+func resizeGIF(g *gif.GIF, w int, h int) *gif.GIF {
+	resized := &gif.GIF{
+		Delay:           g.Delay,
+		LoopCount:       g.LoopCount,
+		Disposal:        g.Disposal,
+		BackgroundIndex: g.BackgroundIndex,
+		Config:          image.Config{ColorModel: g.Config.ColorModel, Width: w, Height: h},
+	}
+
+	for _, frame := range g.Image {
+		b := frame.Bounds()
+		r := image.Rect(b.Min.X*w/g.Config.Width, b.Min.Y*h/g.Config.Height, b.Max.X*w/g.Config.Width, b.Max.Y*h/g.Config.Height)
+		// a frame too thin to survive the scaling keeps a pixel, inside the canvas
+		if r.Dx() < 1 {
+			r.Min.X = min(r.Min.X, w-1)
+			r.Max.X = r.Min.X + 1
+		}
+		if r.Dy() < 1 {
+			r.Min.Y = min(r.Min.Y, h-1)
+			r.Max.Y = r.Min.Y + 1
+		}
+
+		dst := image.NewPaletted(r, frame.Palette)
+		draw.NearestNeighbor.Scale(dst, r, frame, b, draw.Src, nil)
+		resized.Image = append(resized.Image, dst)
+	}
+
+	return resized
+}
+
+// fit returns the dimensions of a w by h image scaled down so its longest side fits in
+// maxDimension, 0 or a small enough image keeps the dimensions as they are.
+func fit(w int, h int, maxDimension int) (int, int) {
+	if maxDimension == 0 || (w <= maxDimension && h <= maxDimension) {
+		return w, h
+	}
+
+	if w >= h {
+		h = h * maxDimension / w
+		w = maxDimension
+	} else {
+		w = w * maxDimension / h
+		h = maxDimension
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	return w, h
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,70 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// testGIF encodes a w by h GIF of two frames with a comment block before its trailer.
+func testGIF(t *testing.T, w int, h int, comment string) []byte {
+	t.Helper()
+
+	palette := color.Palette{color.Black, color.White}
+	g := &gif.GIF{Delay: []int{10, 10}}
+	for i := 0; i < 2; i++ {
+		frame := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+		frame.SetColorIndex(i, 0, 1)
+		g.Image = append(g.Image, frame)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatalf("gif.EncodeAll() error = %v", err)
+	}
+
+	data := buf.Bytes()
+	block := append([]byte{0x21, 0xFE, byte(len(comment))}, comment...)
+	block = append(block, 0x00)
+	return append(append(data[:len(data)-1:len(data)-1], block...), data[len(data)-1])
+}
+
+func TestProcessGIFStripsComments(t *testing.T) {
+	const comment = "GPS 3.1390,101.6869"
+
+	tests := []struct {
+		name string
+		w, h int
+	}{
+		{"kept at its size", 40, 30},
+		{"resized", 400, 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testGIF(t, tt.w, tt.h, comment)
+			if !bytes.Contains(data, []byte(comment)) {
+				t.Fatalf("testGIF() left out the comment")
+			}
+
+			result, err := Process(data, "image/gif")
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+			for _, variant := range Variants {
+				content := result.Variants[variant]
+				if bytes.Contains(content, []byte(comment)) {
+					t.Errorf("the %s variant kept the comment", variant.Name)
+				}
+				g, err := gif.DecodeAll(bytes.NewReader(content))
+				if err != nil {
+					t.Fatalf("the %s variant does not decode: %v", variant.Name, err)
+				}
+				if len(g.Image) != 2 {
+					t.Errorf("the %s variant has %d frames, want 2", variant.Name, len(g.Image))
+				}
+			}
+		})
+	}
+}
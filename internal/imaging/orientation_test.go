@@ -0,0 +1,184 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifTIFF builds the TIFF header of an EXIF block with a single IFD entry.
+func exifTIFF(order binary.ByteOrder, tag uint16, value uint16) []byte {
+	tiff := make([]byte, 26)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], tag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], value)
+	return tiff
+}
+
+// withAPP1 inserts an APP1 segment holding payload right after the SOI marker of a JPEG.
+func withAPP1(jpg []byte, payload []byte) []byte {
+	segment := []byte{0xFF, markerAPP1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(2+len(payload)))
+	segment = append(segment, payload...)
+
+	data := append([]byte{}, jpg[:2]...)
+	data = append(data, segment...)
+	return append(data, jpg[2:]...)
+}
+
+// testJPEG encodes a w by h JPEG with an EXIF orientation, 0 leaves out the EXIF block.
+func testJPEG(t *testing.T, w int, h int, order binary.ByteOrder, orientation uint16) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	if orientation == 0 {
+		return buf.Bytes()
+	}
+	return withAPP1(buf.Bytes(), append([]byte("Exif\x00\x00"), exifTIFF(order, tagOrientation, orientation)...))
+}
+
+func TestOrientation(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for want := 1; want <= 8; want++ {
+			data := testJPEG(t, 4, 2, order, uint16(want))
+			if got := orientation(data); got != want {
+				t.Errorf("orientation() of a %s JPEG = %d, want %d", order, got, want)
+			}
+		}
+	}
+}
+
+func TestOrientationMalformed(t *testing.T) {
+	jpg := testJPEG(t, 4, 2, nil, 0)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a JPEG", []byte("GIF89a\x01\x00\x01\x00")},
+		{"no EXIF", jpg},
+		{"APP1 without an EXIF header", withAPP1(jpg, []byte("http://ns.adobe.com/xap/1.0/\x00"))},
+		{"segment longer than the file", []byte{0xFF, markerSOI, 0xFF, markerAPP1, 0xFF, 0xFF, 'E', 'x'}},
+		{"segment length too small", []byte{0xFF, markerSOI, 0xFF, markerAPP1, 0x00, 0x01, 0x00, 0x00}},
+		{"no marker after a segment", []byte{0xFF, markerSOI, 0x00, markerAPP1, 0x00, 0x02}},
+		{"scan before EXIF", append([]byte{0xFF, markerSOI, 0xFF, markerSOS, 0x00, 0x02}, jpg[2:]...)},
+		{"truncated TIFF", withAPP1(jpg, []byte("Exif\x00\x00II*\x00"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orientation(tt.data); got != 1 {
+				t.Errorf("orientation() = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestTIFFOrientationMalformed(t *testing.T) {
+	valid := exifTIFF(binary.LittleEndian, tagOrientation, 6)
+	with := func(f func(tiff []byte)) []byte {
+		tiff := append([]byte{}, valid...)
+		f(tiff)
+		return tiff
+	}
+
+	tests := []struct {
+		name string
+		tiff []byte
+	}{
+		{"shorter than a header", valid[:6]},
+		{"unknown byte order", with(func(tiff []byte) { copy(tiff, "XX") })},
+		{"IFD past the end", with(func(tiff []byte) { binary.LittleEndian.PutUint32(tiff[4:], 1000) })},
+		{"more entries than the data", with(func(tiff []byte) {
+			binary.LittleEndian.PutUint16(tiff[8:], 3)
+			binary.LittleEndian.PutUint16(tiff[10:], 0x010F)
+		})},
+		{"entry cut short", valid[:16]},
+		{"no orientation tag", exifTIFF(binary.LittleEndian, 0x010F, 6)},
+		{"orientation 0", exifTIFF(binary.LittleEndian, tagOrientation, 0)},
+		{"orientation 9", exifTIFF(binary.BigEndian, tagOrientation, 9)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tiffOrientation(tt.tiff); got != 1 {
+				t.Errorf("tiffOrientation() = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// a 3 by 2 image with its top left pixel marked, which has to end up in the corner
+	// it is displayed in
+	marked := color.NRGBA{R: 255, A: 255}
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.SetNRGBA(0, 0, marked)
+
+	tests := []struct {
+		orientation int
+		w, h        int
+		x, y        int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+
+	for _, tt := range tests {
+		got := orient(img, tt.orientation)
+		if b := got.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+			t.Errorf("orient(%d) is %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.w, tt.h)
+			continue
+		}
+		if c := color.NRGBAModel.Convert(got.At(tt.x, tt.y)); c != marked {
+			t.Errorf("orient(%d) has %v at (%d, %d), want the marked pixel", tt.orientation, c, tt.x, tt.y)
+		}
+	}
+}
+
+func TestProcessOrientsJPEG(t *testing.T) {
+	for value := uint16(1); value <= 8; value++ {
+		result, err := Process(testJPEG(t, 4, 2, binary.BigEndian, value), "image/jpeg")
+		if err != nil {
+			t.Fatalf("Process() error = %v", err)
+		}
+		config, err := jpeg.DecodeConfig(bytes.NewReader(result.Variants[Original]))
+		if err != nil {
+			t.Fatalf("jpeg.DecodeConfig() error = %v", err)
+		}
+
+		w, h := 4, 2
+		if value >= 5 {
+			w, h = 2, 4
+		}
+		if config.Width != w || config.Height != h {
+			t.Errorf("Process() with orientation %d is %dx%d, want %dx%d", value, config.Width, config.Height, w, h)
+		}
+		if got := orientation(result.Variants[Original]); got != 1 {
+			t.Errorf("Process() with orientation %d kept the EXIF block", value)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+-- These indexes are expression indexes which cannot be described in schema.prisma,
+-- they back the full-text search in internal/graphql/query/search.go so the
+-- expressions here must stay identical to the ones used there.
+
+-- CreateExtension
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- CreateIndex
+CREATE INDEX IF NOT EXISTS "User_username_search_idx" ON "User" USING GIN (to_tsvector('simple', "username"));
+
+-- CreateIndex
+CREATE INDEX IF NOT EXISTS "Profile_nameEng_search_idx" ON "Profile" USING GIN (to_tsvector('simple', "nameEng"));
+
+-- CreateIndex
+CREATE INDEX IF NOT EXISTS "Profile_nameChi_trgm_idx" ON "Profile" USING GIN ("nameChi" gin_trgm_ops);
+
+-- CreateIndex
+CREATE INDEX IF NOT EXISTS "Team_name_search_idx" ON "Team" USING GIN (to_tsvector('simple', COALESCE("name", '')));
+
+-- CreateIndex
+CREATE INDEX IF NOT EXISTS "Post_content_search_idx" ON "Post" USING GIN (to_tsvector('simple', "content"));
@@ -0,0 +1,67 @@
+package rubric
+
+import "fmt"
+
+// Criterion is one aspect a submission is scored on, scores range from 0 to Max.
+type Criterion struct {
+	Key         string
+	Description string
+	Max         float64
+}
+
+// Rubric is the set of criteria judges score a submission against.
+type Rubric struct {
+	Name     string
+	Criteria []Criterion
+}
+
+// Humanity is scored for each of the three photos of a team.
+var Humanity = Rubric{
+	Name: "humanity",
+	Criteria: []Criterion{
+		{Key: "creativity", Description: "How original and creative the photo is", Max: 40},
+		{Key: "relevance", Description: "How well the photo fits the theme of the mission", Max: 30},
+		{Key: "effort", Description: "How much effort the team has put into the photo", Max: 30},
+	},
+}
+
+// Discovery is scored once for the video of a team.
+var Discovery = Rubric{
+	Name: "discovery",
+	Criteria: []Criterion{
+		{Key: "storytelling", Description: "How well the video tells the story of the discovery", Max: 40},
+		{Key: "creativity", Description: "How original and creative the video is", Max: 30},
+		{Key: "production", Description: "The quality of filming and editing", Max: 30},
+	},
+}
+
+// Criterion returns the criterion with the given key.
+func (r Rubric) Criterion(key string) (Criterion, bool) {
+	for _, c := range r.Criteria {
+		if c.Key == key {
+			return c, true
+		}
+	}
+	return Criterion{}, false
+}
+
+// Validate checks that score is within the range of the criterion with the given key.
+func (r Rubric) Validate(key string, score float64) error {
+	c, ok := r.Criterion(key)
+	if !ok {
+		return fmt.Errorf("%s rubric has no criterion %s", r.Name, key)
+	}
+	if score < 0 || score > c.Max {
+		return fmt.Errorf("score for %s must be between 0 and %v", key, c.Max)
+	}
+	return nil
+}
+
+// Max returns the highest total score of a single scored item.
+func (r Rubric) Max() float64 {
+	var max float64
+	for _, c := range r.Criteria {
+		max += c.Max
+	}
+	return max
+}
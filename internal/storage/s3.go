@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+type S3Config struct {
+	// Endpoint is the host of the S3 compatible service, e.g. s3.amazonaws.com or
+	// localhost:9000 for MinIO.
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Region    string
+	UseSSL    bool
+}
+
+// S3 keeps media in a bucket of any S3 compatible service.
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3(config S3Config) (*S3, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for s3 storage")
+	}
+
+	client, err := minio.New(config.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.AccessKey, config.SecretKey, ""),
+		Secure: config.UseSSL,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &S3{client: client, bucket: config.Bucket}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3) SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, key, expiry, url.Values{})
+	if err != nil {
+		return "", err
+	}
+
+	return u.String(), nil
+}
+
+func (s *S3) KeyFromURL(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+
+	// presigned URLs use path style (host/bucket/key) or virtual host style (bucket.host/key)
+	host := s.client.EndpointURL().Host
+	key := strings.TrimPrefix(u.Path, "/")
+	switch {
+	case u.Host == host && strings.HasPrefix(key, s.bucket+"/"):
+		return strings.TrimPrefix(key, s.bucket+"/"), true
+	case u.Host == s.bucket+"."+host && key != "":
+		return key, true
+	default:
+		return "", false
+	}
+}
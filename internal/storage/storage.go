@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// defaultURLExpiry is also the longest expiry S3 accepts for a presigned URL.
+	defaultURLExpiry     = 7 * 24 * time.Hour
+	defaultMaxUploadSize = 10 << 20
+)
+
+// Storage is a backend where uploaded media is kept. Objects are private and can
+// only be accessed through signed URLs.
+type Storage interface {
+	// Put stores the content of r under key, size is the exact length of r.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// SignedURL returns a URL to key that is valid for the given duration.
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+	// KeyFromURL returns the key of a URL signed by this storage, ok is false for
+	// URLs pointing anywhere else.
+	KeyFromURL(url string) (key string, ok bool)
+}
+
+// Config holds the settings shared by all storage backends.
+type Config struct {
+	Driver        string
+	URLExpiry     time.Duration
+	MaxUploadSize int64
+}
+
+// NewFromEnv creates the storage backend selected by STORAGE_DRIVER, which is
+// either "local" (the default) or "s3".
+func NewFromEnv() (Storage, Config, error) {
+	config := Config{
+		Driver:        os.Getenv("STORAGE_DRIVER"),
+		URLExpiry:     defaultURLExpiry,
+		MaxUploadSize: defaultMaxUploadSize,
+	}
+	if config.Driver == "" {
+		config.Driver = "local"
+	}
+	if expiry := os.Getenv("STORAGE_URL_EXPIRY"); expiry != "" {
+		d, err := time.ParseDuration(expiry)
+		if err != nil {
+			return nil, config, fmt.Errorf("invalid STORAGE_URL_EXPIRY: %w", err)
+		}
+		config.URLExpiry = d
+	}
+	if size := os.Getenv("STORAGE_MAX_UPLOAD_SIZE"); size != "" {
+		n, err := strconv.ParseInt(size, 10, 64)
+		if err != nil {
+			return nil, config, fmt.Errorf("invalid STORAGE_MAX_UPLOAD_SIZE: %w", err)
+		}
+		config.MaxUploadSize = n
+	}
+
+	switch config.Driver {
+	case "local":
+		store, err := NewLocal(LocalConfig{
+			Dir:       getenv("STORAGE_LOCAL_DIR", "./uploads"),
+			PublicURL: getenv("STORAGE_PUBLIC_URL", "http://localhost:8080"),
+			Secret:    os.Getenv("STORAGE_SIGNING_SECRET"),
+		})
+		return store, config, err
+	case "s3":
+		useSSL, _ := strconv.ParseBool(getenv("S3_USE_SSL", "true"))
+		store, err := NewS3(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			Region:    os.Getenv("S3_REGION"),
+			UseSSL:    useSSL,
+		})
+		return store, config, err
+	default:
+		return nil, config, fmt.Errorf("unknown STORAGE_DRIVER %s", config.Driver)
+	}
+}
+
+func getenv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
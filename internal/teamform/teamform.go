@@ -0,0 +1,174 @@
+// Package teamform splits registrants into balanced teams. It does not depend on the
+// database, so a formation can be previewed before any team is created.
+package teamform
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Member is a registrant to be placed in a team, along with what the teams are
+// balanced on.
+type Member struct {
+	ID        string
+	Satellite string
+	Gender    string
+	Age       int
+	// Rank is the seniority of the pastoral status, see StatusRank.
+	Rank int
+}
+
+// statuses are the pastoral statuses from the most senior.
+var statuses = []string{"PASTOR", "SCGL", "CGL", "PCGL", "ACGL", "OM", "NB", "NF"}
+
+// LeaderRank is the least senior status that can lead a team, a CGL.
+const LeaderRank = 2
+
+// StatusRank is the position of a pastoral status from the most senior, a member without
+// a known status ranks below every status.
+func StatusRank(status string) int {
+	for i, s := range statuses {
+		if s == status {
+			return i
+		}
+	}
+	return len(statuses)
+}
+
+// IsLeader tells whether the member is senior enough to lead a team.
+func (m Member) IsLeader() bool {
+	return m.Rank <= LeaderRank
+}
+
+// Team is a formed team, Leader is the index of its leader in Members.
+type Team struct {
+	Members []Member
+	Leader  int
+}
+
+// The weights of placing a member in a team that already has members alike, leaders
+// are spread first and the satellites and genders are mixed before the ages.
+const (
+	leaderWeight    = 1000
+	satelliteWeight = 10
+	genderWeight    = 10
+	ageWeight       = 5
+	rankWeight      = 3
+)
+
+// Form splits the members into teams of at most size members, the teams differ in size
+// by at most one. The most senior members are placed first, one leader per team while
+// there are enough of them, and every other member goes to the team with the fewest
+// members alike. The same members and seed always give the same teams.
+func Form(members []Member, size int, seed int64) ([]Team, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("team size must be at least 1, got %d", size)
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+
+	n := (len(members) + size - 1) / size
+	capacity := make([]int, n)
+	for i := range capacity {
+		capacity[i] = len(members) / n
+		if i < len(members)%n {
+			capacity[i]++
+		}
+	}
+
+	// shuffle so that members alike are not always placed in the order they came in
+	rng := rand.New(rand.NewSource(seed))
+	ordered := make([]Member, len(members))
+	copy(ordered, members)
+	rng.Shuffle(len(ordered), func(i, j int) {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	})
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Rank < ordered[j].Rank
+	})
+
+	teams := make([]Team, n)
+	for _, member := range ordered {
+		best := -1
+		bestCost := 0
+		for i, team := range teams {
+			if len(team.Members) >= capacity[i] {
+				continue
+			}
+			cost := placementCost(team, member)
+			if best == -1 || cost < bestCost || (cost == bestCost && len(team.Members) < len(teams[best].Members)) {
+				best, bestCost = i, cost
+			}
+		}
+		teams[best].Members = append(teams[best].Members, member)
+	}
+
+	for i := range teams {
+		teams[i].Leader = pickLeader(teams[i].Members)
+	}
+
+	return teams, nil
+}
+
+// placementCost is how alike the member is to the members already in the team.
+func placementCost(team Team, member Member) int {
+	cost := 0
+	for _, m := range team.Members {
+		if member.IsLeader() && m.IsLeader() {
+			cost += leaderWeight
+		}
+		if member.Satellite != "" && m.Satellite == member.Satellite {
+			cost += satelliteWeight
+		}
+		if m.Gender == member.Gender {
+			cost += genderWeight
+		}
+		if AgeBand(m.Age) == AgeBand(member.Age) {
+			cost += ageWeight
+		}
+		if m.Rank == member.Rank {
+			cost += rankWeight
+		}
+	}
+	return cost
+}
+
+// pickLeader picks the most senior member, the oldest of them when several are as senior.
+func pickLeader(members []Member) int {
+	leader := 0
+	for i, m := range members {
+		if m.Rank < members[leader].Rank || (m.Rank == members[leader].Rank && m.Age > members[leader].Age) {
+			leader = i
+		}
+	}
+	return leader
+}
+
+// AgeBand buckets an age like the registration stats do, under 18, 18 to 24, 25 to 34,
+// 35 to 44 and 45 and above.
+func AgeBand(age int) int {
+	switch {
+	case age < 18:
+		return 0
+	case age < 25:
+		return 1
+	case age < 35:
+		return 2
+	case age < 45:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// Age is how many full years old someone born on dob is.
+func Age(dob time.Time, now time.Time) int {
+	years := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		years--
+	}
+	return years
+}
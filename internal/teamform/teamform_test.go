@@ -0,0 +1,157 @@
+package teamform
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// testMembers makes n members, the first leaders of them senior enough to lead a team.
+func testMembers(n int, leaders int) []Member {
+	satellites := []string{"KL", "PJ", "Puchong", "Klang"}
+	genders := []string{"MALE", "FEMALE"}
+
+	members := make([]Member, n)
+	for i := range members {
+		rank := LeaderRank + 1 + i%(len(statuses)-LeaderRank)
+		if i < leaders {
+			rank = i % (LeaderRank + 1)
+		}
+		members[i] = Member{
+			ID:        fmt.Sprintf("m%d", i),
+			Satellite: satellites[i%len(satellites)],
+			Gender:    genders[i%len(genders)],
+			Age:       16 + i*7%40,
+			Rank:      rank,
+		}
+	}
+	return members
+}
+
+func TestFormSizes(t *testing.T) {
+	tests := []struct {
+		members int
+		size    int
+		teams   int
+	}{
+		{1, 5, 1},
+		{5, 5, 1},
+		{6, 5, 2},
+		{11, 5, 3},
+		{23, 4, 6},
+		{30, 1, 30},
+		{3, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d members of %d", tt.members, tt.size), func(t *testing.T) {
+			members := testMembers(tt.members, 2)
+			teams, err := Form(members, tt.size, 1)
+			if err != nil {
+				t.Fatalf("Form() error = %v", err)
+			}
+			if len(teams) != tt.teams {
+				t.Fatalf("Form() gave %d teams, want %d", len(teams), tt.teams)
+			}
+
+			smallest, largest := tt.size, 0
+			placed := map[string]int{}
+			for _, team := range teams {
+				if len(team.Members) < smallest {
+					smallest = len(team.Members)
+				}
+				if len(team.Members) > largest {
+					largest = len(team.Members)
+				}
+				for _, m := range team.Members {
+					placed[m.ID]++
+				}
+			}
+			if largest > tt.size {
+				t.Errorf("Form() gave a team of %d, larger than %d", largest, tt.size)
+			}
+			if largest-smallest > 1 {
+				t.Errorf("Form() gave teams of %d to %d members, want them to differ by at most one", smallest, largest)
+			}
+			for _, m := range members {
+				if placed[m.ID] != 1 {
+					t.Errorf("Form() placed %s in %d teams, want 1", m.ID, placed[m.ID])
+				}
+			}
+		})
+	}
+}
+
+func TestFormLeaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		members int
+		size    int
+		leaders int
+	}{
+		{"one leader per team", 20, 5, 4},
+		{"more leaders than teams", 20, 5, 7},
+		{"fewer leaders than teams", 20, 5, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			teams, err := Form(testMembers(tt.members, tt.leaders), tt.size, 2)
+			if err != nil {
+				t.Fatalf("Form() error = %v", err)
+			}
+
+			led := 0
+			for i, team := range teams {
+				leaders := 0
+				for _, m := range team.Members {
+					if m.IsLeader() {
+						leaders++
+					}
+				}
+				if leaders > 0 {
+					led++
+					if !team.Members[team.Leader].IsLeader() {
+						t.Errorf("team %d has a leader but is led by %s of rank %d", i, team.Members[team.Leader].ID, team.Members[team.Leader].Rank)
+					}
+				}
+				if tt.leaders <= len(teams) && leaders > 1 {
+					t.Errorf("team %d has %d leaders while other teams could have had one", i, leaders)
+				}
+			}
+
+			want := tt.leaders
+			if want > len(teams) {
+				want = len(teams)
+			}
+			if led != want {
+				t.Errorf("Form() gave %d teams a leader, want %d", led, want)
+			}
+		})
+	}
+}
+
+func TestFormIsReproducible(t *testing.T) {
+	members := testMembers(37, 6)
+	for _, seed := range []int64{0, 1, 42, -7} {
+		first, err := Form(members, 6, seed)
+		if err != nil {
+			t.Fatalf("Form() error = %v", err)
+		}
+		second, err := Form(members, 6, seed)
+		if err != nil {
+			t.Fatalf("Form() error = %v", err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("Form() with seed %d gave %v, then %v", seed, first, second)
+		}
+	}
+}
+
+func TestFormRejectsSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if _, err := Form(testMembers(4, 1), size, 1); err == nil {
+			t.Errorf("Form() with size %d gave no error", size)
+		}
+	}
+}
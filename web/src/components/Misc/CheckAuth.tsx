@@ -2,10 +2,18 @@ import React, { useState } from 'react'
 import { useEffectOnce } from 'react-use'
 
 import { LoadingPage } from '@/components/Misc'
+import { Role } from '@/graphql'
+import { useAuth } from '@/lib/auth'
 import { supabase } from '@/lib/supabase'
 
-export const CheckAuth: React.FC = ({ children }) => {
+type CheckAuthProps = {
+  // roles allowed to see the page, any logged in user when not given
+  roles?: Role[]
+}
+
+export const CheckAuth: React.FC<CheckAuthProps> = ({ roles, children }) => {
   const [mounted, setMounted] = useState<boolean>(false)
+  const { user } = useAuth()
 
   useEffectOnce(() => setMounted(true))
 
@@ -13,7 +21,10 @@ export const CheckAuth: React.FC = ({ children }) => {
     if (!supabase.auth.session()) window.location.replace('/login')
   })
 
-  if (!mounted) return <LoadingPage />
+  if (!mounted || (roles && !user)) return <LoadingPage />
+
+  if (roles && user && !user.user.roles.some(role => roles.includes(role)))
+    return <div className='container p-4 mx-auto'>You are not allowed to view this page</div>
 
   return <>{children}</>
 }
@@ -90,7 +90,7 @@ export const Battleground: React.FC = () => {
             className='text-white'
             placeholder='Enter room code...'
             maxLength={4}
-            onChange={e => setInputCode(e.target.value)}
+            onChange={e => setInputCode(e.target.value.toUpperCase())}
           />
           <Button
             size='small'
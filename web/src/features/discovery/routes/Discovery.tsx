@@ -139,7 +139,6 @@ export const Discovery: React.FC = () => {
                             param: {
                               teamId: user.user.team.id,
                               missionId: MISSION_ID,
-                              submittedAt: new Date().toISOString(),
                               videoUrl: publicURL
                             }
                           }
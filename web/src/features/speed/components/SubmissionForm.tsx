@@ -6,7 +6,7 @@ import React from 'react'
 import * as Yup from 'yup'
 
 import { Button, InputField } from '@/components/Elements'
-import { useUpsertSpeed } from '@/features/speed'
+import { useSubmitSpeedAnswer } from '@/features/speed'
 import { GetMission_mission } from '@/graphql/types/GetMission'
 import { GetUser_user_team } from '@/graphql/types/GetUser'
 import { useSpeedGame } from '@/hooks/stores'
@@ -30,7 +30,7 @@ type SubmissionFormProps = {
 
 export const SubmissionForm: React.FC<SubmissionFormProps> = ({ team, mission, completed, setCompleted }) => {
   const { enqueueSnackbar } = useSnackbar()
-  const { upsertSpeed } = useUpsertSpeed()
+  const { submitSpeedAnswer } = useSubmitSpeedAnswer()
   const { setCompletedAt } = useSpeedGame()
 
   return (
@@ -56,17 +56,29 @@ export const SubmissionForm: React.FC<SubmissionFormProps> = ({ team, mission, c
             enqueueSnackbar('You have already completed', { variant: 'info' })
             return
           }
-          const { data, errors } = await upsertSpeed({
+          const { data, errors } = await submitSpeedAnswer({
             teamId: team.id,
             missionId: mission.id,
-            completedAt: Dayjs().toISOString()
-          })
+            answer: [values.we, values.must, values.think, values.out, values.of, values.the, values.box].join(' ')
+          }).catch(err => ({ data: undefined, errors: [err] }))
           if (errors || !data) {
             enqueueSnackbar('Unable to submit your answer now', { variant: 'error' })
             console.error(errors)
             return
           }
-          setCompletedAt(Dayjs().toISOString())
+          const { correct, attemptsLeft, retryAt, speed } = data.submitSpeedAnswer
+          if (!correct) {
+            enqueueSnackbar(
+              retryAt
+                ? `Wrong answer, try again ${Dayjs(retryAt).format('h:mm:ss A')}`
+                : attemptsLeft !== null
+                ? `Wrong answer, ${attemptsLeft} attempts left`
+                : 'Wrong answer',
+              { variant: 'error' }
+            )
+            return
+          }
+          setCompletedAt(speed.completedAt ?? Dayjs().toISOString())
           enqueueSnackbar('Successfully submitted your answer', { variant: 'success' })
           setCompleted(true)
         }}
@@ -1,2 +1,3 @@
 export * from './useFetchSpeed'
 export * from './useUpsertSpeed'
+export * from './useSubmitSpeedAnswer'
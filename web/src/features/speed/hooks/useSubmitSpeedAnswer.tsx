@@ -0,0 +1,37 @@
+import { useMutation } from '@apollo/client'
+import { useCallback } from 'react'
+
+import { GET_SPEED, SubmitSpeedAnswerInput, SUBMIT_SPEED_ANSWER } from '@/graphql'
+import { GetSpeed, GetSpeedVariables } from '@/graphql/types/GetSpeed'
+import { SubmitSpeedAnswer, SubmitSpeedAnswerVariables } from '@/graphql/types/SubmitSpeedAnswer'
+
+export const useSubmitSpeedAnswer = () => {
+  const [_submitSpeedAnswer] = useMutation<SubmitSpeedAnswer, SubmitSpeedAnswerVariables>(SUBMIT_SPEED_ANSWER)
+
+  const submitSpeedAnswer = useCallback(
+    async (param: SubmitSpeedAnswerInput) => {
+      const result = await _submitSpeedAnswer({
+        variables: { param },
+        update: (cache, { data }) => {
+          try {
+            if (!data) return
+
+            cache.writeQuery<GetSpeed, GetSpeedVariables>({
+              query: GET_SPEED,
+              variables: { team_id: param.teamId },
+              data: {
+                speed: data.submitSpeedAnswer.speed
+              }
+            })
+          } catch (err) {
+            console.error(err)
+          }
+        }
+      })
+      return result
+    },
+    [_submitSpeedAnswer]
+  )
+
+  return { submitSpeedAnswer }
+}
@@ -5,8 +5,8 @@ import React, { useEffect } from 'react'
 
 import { Avatar, Spinner } from '@/components/Elements'
 import { cards, Powercard, useFetchTeam } from '@/features/team'
-import { UPDATE_TEAM } from '@/graphql'
-import { UpdateTeam, UpdateTeamVariables } from '@/graphql/types/UpdateTeam'
+import { EQUIP_POWERCARD } from '@/graphql'
+import { EquipPowercard, EquipPowercardVariables } from '@/graphql/types/EquipPowercard'
 
 type TeamDetailProps = {
   teamId: string
@@ -15,7 +15,7 @@ type TeamDetailProps = {
 export const TeamDetail: React.FC<TeamDetailProps> = ({ teamId }) => {
   const { enqueueSnackbar } = useSnackbar()
   const { team, fetchTeam } = useFetchTeam()
-  const [updateTeam] = useMutation<UpdateTeam, UpdateTeamVariables>(UPDATE_TEAM)
+  const [equipPowercard] = useMutation<EquipPowercard, EquipPowercardVariables>(EQUIP_POWERCARD)
 
   useEffect(() => {
     console.log(teamId)
@@ -76,8 +76,8 @@ export const TeamDetail: React.FC<TeamDetailProps> = ({ teamId }) => {
                             )
                           ) {
                             try {
-                              const { data, errors } = await updateTeam({
-                                variables: { team_id: team.data.team.id, param: { powercard: p } }
+                              const { data, errors } = await equipPowercard({
+                                variables: { team_id: team.data.team.id, powercard: p }
                               })
                               if (errors || !data) {
                                 enqueueSnackbar(`Unable to select powercard\n${errors}`, { variant: 'error' })
@@ -110,6 +110,15 @@ export const UPDATE_TEAM = gql`
   }
 `
 
+export const EQUIP_POWERCARD = gql`
+  ${CORE_TEAM_FIELDS}
+  mutation EquipPowercard($team_id: ID!, $powercard: Powercard!) {
+    equipPowercard(team_id: $team_id, powercard: $powercard) {
+      ...CoreTeamFields
+    }
+  }
+`
+
 export const UPDATE_USER = gql`
   ${CORE_USER_FIELDS}
   ${CORE_PROFILE_FIELDS}
@@ -155,6 +164,23 @@ export const UPSERT_SPEED = gql`
   }
 `
 
+export const SUBMIT_SPEED_ANSWER = gql`
+  mutation SubmitSpeedAnswer($param: SubmitSpeedAnswerInput!) {
+    submitSpeedAnswer(param: $param) {
+      correct
+      attemptsLeft
+      retryAt
+      speed {
+        id
+        completedAt
+        answer
+        createdAt
+        updatedAt
+      }
+    }
+  }
+`
+
 export const UPSERT_HUMANITY = gql`
   mutation UpsertHumanity($param: UpsertHumanityInput!) {
     upsertHumanity(param: $param) {
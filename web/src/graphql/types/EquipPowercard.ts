@@ -0,0 +1,37 @@
+/* tslint:disable */
+/* eslint-disable */
+// @generated
+// This file was automatically generated and should not be edited.
+
+import { Powercard } from "./globalTypes";
+
+// ====================================================
+// GraphQL mutation operation: EquipPowercard
+// ====================================================
+
+export interface EquipPowercard_equipPowercard_cluster {
+  __typename: "Cluster";
+  id: string;
+  name: string;
+  color: string;
+}
+
+export interface EquipPowercard_equipPowercard {
+  __typename: "Team";
+  id: string;
+  name: string | null;
+  avatarUrl: string | null;
+  points: number;
+  powercard: Powercard | null;
+  eligiblePowercards: Powercard[];
+  cluster: EquipPowercard_equipPowercard_cluster | null;
+}
+
+export interface EquipPowercard {
+  equipPowercard: EquipPowercard_equipPowercard | null;
+}
+
+export interface EquipPowercardVariables {
+  team_id: string;
+  powercard: Powercard;
+}
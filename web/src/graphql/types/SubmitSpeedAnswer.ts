@@ -0,0 +1,35 @@
+/* tslint:disable */
+/* eslint-disable */
+// @generated
+// This file was automatically generated and should not be edited.
+
+import { SubmitSpeedAnswerInput } from "./globalTypes";
+
+// ====================================================
+// GraphQL mutation operation: SubmitSpeedAnswer
+// ====================================================
+
+export interface SubmitSpeedAnswer_submitSpeedAnswer_speed {
+  __typename: "Speed";
+  id: string;
+  completedAt: TheBox.Time | null;
+  answer: string | null;
+  createdAt: TheBox.Time;
+  updatedAt: TheBox.Time;
+}
+
+export interface SubmitSpeedAnswer_submitSpeedAnswer {
+  __typename: "SpeedAnswerResult";
+  correct: boolean;
+  attemptsLeft: number | null;
+  retryAt: TheBox.Time | null;
+  speed: SubmitSpeedAnswer_submitSpeedAnswer_speed;
+}
+
+export interface SubmitSpeedAnswer {
+  submitSpeedAnswer: SubmitSpeedAnswer_submitSpeedAnswer;
+}
+
+export interface SubmitSpeedAnswerVariables {
+  param: SubmitSpeedAnswerInput;
+}
@@ -145,6 +145,12 @@ export interface PostLikeInput {
   userId: string;
 }
 
+export interface SubmitSpeedAnswerInput {
+  teamId: string;
+  missionId: string;
+  answer: string;
+}
+
 export interface UpdateBattlegroundRoomInput {
   teamIds?: string[] | null;
   status?: RoomStatus | null;
@@ -161,7 +167,6 @@ export interface UpdateTeamInput {
   name?: string | null;
   avatarUrl?: string | null;
   points?: number | null;
-  powercard?: Powercard | null;
 }
 
 export interface UpdateUserInput {
@@ -173,7 +178,6 @@ export interface UpsertDiscoveryInput {
   teamId: string;
   missionId: string;
   videoUrl?: string | null;
-  submittedAt?: TheBox.Time | null;
 }
 
 export interface UpsertEscapeInput {
@@ -197,8 +201,6 @@ export interface UpsertHumanityInput {
 export interface UpsertSpeedInput {
   teamId: string;
   missionId: string;
-  completedAt?: TheBox.Time | null;
-  answer?: string | null;
 }
 
 //==============================================================
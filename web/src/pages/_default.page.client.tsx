@@ -1,4 +1,5 @@
-import { ApolloClient, InMemoryCache } from '@apollo/client'
+import { ApolloClient, InMemoryCache, createHttpLink } from '@apollo/client'
+import { setContext } from '@apollo/client/link/context'
 import type { NormalizedCacheObject } from '@apollo/client'
 // import { offsetLimitPagination } from '@apollo/client/utilities'
 import React from 'react'
@@ -7,15 +8,24 @@ import { getPage } from 'vite-plugin-ssr/client'
 import type { PageContextBuiltInClient } from 'vite-plugin-ssr/types'
 
 import { AppProvider } from '@/context'
+import { supabase } from '@/lib/supabase'
 import type { PageContext } from '@/types/ssr'
 import { createThemeHelper, createEmotionCache } from '@/utils'
 
 import 'virtual:windi.css'
 import '@/styles/globals.css'
 
+// authLink sends the access token of the logged in user along with every request
+const authLink = setContext((_, { headers }) => {
+  const session = supabase.auth.session()
+  return {
+    headers: session ? { ...headers, Authorization: `Bearer ${session.access_token}` } : headers
+  }
+})
+
 const makeApolloClient = (apolloInitialState?: NormalizedCacheObject) => {
   return new ApolloClient({
-    uri: `${import.meta.env.VITE_API_URL}/graphql`,
+    link: authLink.concat(createHttpLink({ uri: `${import.meta.env.VITE_API_URL}/graphql` })),
     cache: apolloInitialState ? new InMemoryCache().restore(apolloInitialState) : new InMemoryCache()
   })
 }
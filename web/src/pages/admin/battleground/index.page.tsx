@@ -2,9 +2,10 @@ import React from 'react'
 
 import { CheckAuth } from '@/components/Misc'
 import { ControlPanel } from '@/features/battleground'
+import { Role } from '@/graphql'
 
 const BattlegroundControlPanel: React.FC = () => (
-  <CheckAuth>
+  <CheckAuth roles={[Role.CREW]}>
     <ControlPanel />
   </CheckAuth>
 )
@@ -3,6 +3,7 @@ import { useEffectOnce } from 'react-use'
 
 import { CheckAuth, LoadingPage } from '@/components/Misc'
 import { RoomControlPanel } from '@/features/battleground'
+import { Role } from '@/graphql'
 
 const BattlegroundRoomControlPanel: React.FC = () => {
   const [roomCode, setRoomCode] = useState<string>()
@@ -15,7 +16,7 @@ const BattlegroundRoomControlPanel: React.FC = () => {
 
   if (!roomCode) return <LoadingPage />
   return (
-    <CheckAuth>
+    <CheckAuth roles={[Role.CREW]}>
       <RoomControlPanel roomCode={roomCode} />
     </CheckAuth>
   )
@@ -1,12 +1,15 @@
 import React from 'react'
 
-import { LockPage } from '@/components/Misc'
+import { CheckAuth, LockPage } from '@/components/Misc'
 import { Marking } from '@/features/marking'
+import { Role } from '@/graphql'
 
 export const MarkingPage: React.FC = () => (
-  <LockPage>
-    <Marking />
-  </LockPage>
+  <CheckAuth roles={[Role.CREW]}>
+    <LockPage>
+      <Marking />
+    </LockPage>
+  </CheckAuth>
 )
 
 export default MarkingPage